@@ -0,0 +1,36 @@
+package main
+
+import (
+	"github.com/NethermindEth/starknet-staking-v2/signer"
+	"github.com/spf13/cobra"
+)
+
+func NewAuditCommand() cobra.Command {
+	cmd := cobra.Command{
+		Use:   "audit",
+		Short: "Inspect the audit log of the signer",
+	}
+
+	verifyCmd := cobra.Command{
+		Use:   "verify <audit log path>",
+		Short: "Check no entry of the audit log was modified, removed or reordered",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			result, err := signer.VerifyAuditLog(args[0])
+			if err != nil {
+				if result.Entries != 0 {
+					cmd.Printf("Audit log is valid up to entry %d\n", result.Entries)
+				}
+				return err
+			}
+			cmd.Printf("Audit log is valid, %d entries\n", result.Entries)
+			if result.Entries != 0 {
+				cmd.Printf("Last entry hash: %s\n", result.LastHash)
+			}
+			return nil
+		},
+	}
+	cmd.AddCommand(&verifyCmd)
+
+	return cmd
+}
@@ -0,0 +1,292 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/NethermindEth/juno/core/felt"
+	"github.com/NethermindEth/starknet-staking-v2/signer"
+	"github.com/NethermindEth/starknet.go/contracts"
+	"github.com/NethermindEth/starknet.go/curve"
+	"github.com/joho/godotenv"
+	"github.com/spf13/cobra"
+)
+
+// Flags selecting the key of the commands that sign or derive the public key offline,
+// the same the signer is run with
+type keyFlags struct {
+	envFilePath  string
+	backend      string
+	keystorePath string
+	passwordFile string
+	transitURL   string
+	transitKey   string
+}
+
+func addKeyFlags(cmd *cobra.Command, flags *keyFlags) {
+	cmd.Flags().StringVar(&flags.envFilePath, "env", ".env", "Path to the env file")
+	cmd.Flags().StringVar(
+		&flags.backend,
+		"key-backend",
+		"",
+		"Where the private key is held. Options: memory, keystore, transit. Defaults to"+
+			" keystore when --keystore is set and to memory, with the key in the"+
+			" SIGNER_PRIVATE_KEY env var, otherwise",
+	)
+	cmd.Flags().StringVar(
+		&flags.keystorePath, "keystore", "", "Path to the encrypted keystore with the private key",
+	)
+	cmd.Flags().StringVar(
+		&flags.passwordFile,
+		"password-file",
+		"",
+		"Path to the file with the keystore password. It's prompted when not set",
+	)
+	cmd.Flags().StringVar(
+		&flags.transitURL,
+		"transit-url",
+		"",
+		"Url of the transit key management service holding the private key."+
+			" Its token is read from the SIGNER_TRANSIT_TOKEN env var",
+	)
+	cmd.Flags().StringVar(
+		&flags.transitKey, "transit-key", "", "Name of the key in the transit service",
+	)
+}
+
+func (f *keyFlags) newKeyBackend() (signer.KeyBackend, error) {
+	// The env file is optional, just like when running the signer
+	_ = godotenv.Load(f.envFilePath)
+	return newKeyBackend(f.backend, f.keystorePath, f.passwordFile, f.transitURL, f.transitKey)
+}
+
+type keyPair struct {
+	PrivateKey *felt.Felt `json:"privateKey"`
+	PublicKey  *felt.Felt `json:"publicKey"`
+}
+
+func NewKeygenCommand() cobra.Command {
+	return cobra.Command{
+		Use:   "keygen",
+		Short: "Generate a new Stark key pair and print it",
+		Long: "Generate a new Stark key pair and print it. The private key is printed in" +
+			" plain text, use `keystore create` to keep it encrypted instead",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			privateKey, err := signer.NewPrivateKey()
+			if err != nil {
+				return err
+			}
+			publicKey, _, err := curve.Curve.PrivateToPoint(privateKey)
+			if err != nil {
+				return fmt.Errorf("cannot derive public key from private key: %w", err)
+			}
+
+			return printJSON(cmd, keyPair{
+				PrivateKey: new(felt.Felt).SetBigInt(privateKey),
+				PublicKey:  new(felt.Felt).SetBigInt(publicKey),
+			})
+		},
+	}
+}
+
+func NewPubkeyCommand() cobra.Command {
+	var flags keyFlags
+
+	cmd := cobra.Command{
+		Use:   "pubkey",
+		Short: "Print the public key of the configured private key",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			keys, err := flags.newKeyBackend()
+			if err != nil {
+				return err
+			}
+			publicKey, err := keys.PublicKey(cmd.Context())
+			if err != nil {
+				return err
+			}
+			_, err = fmt.Fprintln(cmd.OutOrStdout(), publicKey)
+			return err
+		},
+	}
+	addKeyFlags(&cmd, &flags)
+
+	return cmd
+}
+
+// Signature of a hash, as printed by `sign-hash`
+type hashSignature struct {
+	Hash      *felt.Felt   `json:"hash"`
+	PublicKey *felt.Felt   `json:"publicKey"`
+	Signature []*felt.Felt `json:"signature"`
+}
+
+func NewSignHashCommand() cobra.Command {
+	var flags keyFlags
+
+	cmd := cobra.Command{
+		Use:   "sign-hash <hash>",
+		Short: "Sign a hash with the configured private key, without any policy check",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			hash, err := parseFelt("hash", args[0])
+			if err != nil {
+				return err
+			}
+			keys, err := flags.newKeyBackend()
+			if err != nil {
+				return err
+			}
+			publicKey, err := keys.PublicKey(cmd.Context())
+			if err != nil {
+				return err
+			}
+			signature, err := keys.SignHash(cmd.Context(), hash)
+			if err != nil {
+				return err
+			}
+
+			return printJSON(cmd, hashSignature{
+				Hash: hash, PublicKey: publicKey, Signature: signature,
+			})
+		},
+	}
+	addKeyFlags(&cmd, &flags)
+
+	return cmd
+}
+
+func NewVerifyCommand() cobra.Command {
+	var publicKeyF string
+
+	cmd := cobra.Command{
+		Use:   "verify <hash> <r> <s>",
+		Short: "Check the signature `r`, `s` of a hash was made by the key of a public key",
+		Args:  cobra.ExactArgs(3),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			publicKey, err := parseFelt("public key", publicKeyF)
+			if err != nil {
+				return err
+			}
+			values := make([]*big.Int, len(args))
+			for i, name := range []string{"hash", "signature r", "signature s"} {
+				value, err := parseFelt(name, args[i])
+				if err != nil {
+					return err
+				}
+				values[i] = value.BigInt(new(big.Int))
+			}
+
+			publicKeyX := publicKey.BigInt(new(big.Int))
+			publicKeyY := curve.Curve.GetYCoordinate(publicKeyX)
+			if publicKeyY == nil {
+				return fmt.Errorf("public key %s is not on the Stark curve", publicKey)
+			}
+			if !curve.Curve.Verify(values[0], values[1], values[2], publicKeyX, publicKeyY) {
+				return errors.New("the signature is not valid")
+			}
+			cmd.Println("Signature is valid")
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&publicKeyF, "public-key", "", "Public key of the signing key")
+	_ = cmd.MarkFlagRequired("public-key")
+
+	return cmd
+}
+
+func NewAccountAddressCommand() cobra.Command {
+	var classHashF string
+	var publicKeyF string
+	var saltF string
+	var deployerF string
+	var calldataF []string
+
+	cmd := cobra.Command{
+		Use:   "account-address",
+		Short: "Compute the address an account contract will be deployed at",
+		Long: "Compute the address an account contract will be deployed at, before it's" +
+			" deployed. By default the account is deployed with a DEPLOY_ACCOUNT transaction," +
+			" with the public key as salt and only constructor argument, as done by" +
+			" OpenZeppelin accounts",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			classHash, err := parseFelt("class hash", classHashF)
+			if err != nil {
+				return err
+			}
+			publicKey, err := parseFelt("public key", publicKeyF)
+			if err != nil {
+				return err
+			}
+
+			salt := publicKey
+			if saltF != "" {
+				if salt, err = parseFelt("salt", saltF); err != nil {
+					return err
+				}
+			}
+			deployer, err := parseFelt("deployer address", deployerF)
+			if err != nil {
+				return err
+			}
+
+			calldata := []*felt.Felt{publicKey}
+			if cmd.Flags().Changed("calldata") {
+				calldata = make([]*felt.Felt, len(calldataF))
+				for i := range calldataF {
+					name := fmt.Sprintf("constructor calldata %d", i+1)
+					if calldata[i], err = parseFelt(name, calldataF[i]); err != nil {
+						return err
+					}
+				}
+			}
+
+			address := contracts.PrecomputeAddress(deployer, salt, classHash, calldata)
+			_, err = fmt.Fprintln(cmd.OutOrStdout(), address)
+			return err
+		},
+	}
+	cmd.Flags().StringVar(&classHashF, "class-hash", "", "Class hash of the account contract")
+	cmd.Flags().StringVar(&publicKeyF, "public-key", "", "Public key of the account")
+	cmd.Flags().StringVar(&saltF, "salt", "", "Deployment salt. Defaults to the public key")
+	cmd.Flags().StringVar(
+		&deployerF,
+		"deployer",
+		"0x0",
+		"Address of the contract deploying the account. Zero for DEPLOY_ACCOUNT transactions",
+	)
+	cmd.Flags().StringSliceVar(
+		&calldataF,
+		"calldata",
+		nil,
+		"Comma separated constructor calldata. Defaults to the public key",
+	)
+	_ = cmd.MarkFlagRequired("class-hash")
+	_ = cmd.MarkFlagRequired("public-key")
+
+	return cmd
+}
+
+func parseFelt(name string, value string) (*felt.Felt, error) {
+	if value == "" {
+		return nil, fmt.Errorf("the %s is not set", name)
+	}
+	f, err := new(felt.Felt).SetString(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", name, err)
+	}
+	return f, nil
+}
+
+func printJSON(cmd *cobra.Command, value any) error {
+	output, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(cmd.OutOrStdout(), string(output))
+	return err
+}
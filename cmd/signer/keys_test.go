@@ -0,0 +1,125 @@
+package main_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/big"
+	"strings"
+	"testing"
+
+	main "github.com/NethermindEth/starknet-staking-v2/cmd/signer"
+	"github.com/NethermindEth/starknet.go/curve"
+	"github.com/stretchr/testify/require"
+)
+
+// Runs the signer command with `args` and returns what it printed
+func runCommand(t *testing.T, args ...string) (string, error) {
+	t.Helper()
+
+	command := main.NewCommand()
+	command.SetArgs(args)
+	// The subcommands were added to the command before it was copied, so the output
+	// is set on the one run
+	subcommand, _, err := command.Find(args)
+	require.NoError(t, err)
+	var output bytes.Buffer
+	subcommand.SetOut(&output)
+	subcommand.SetErr(&output)
+
+	err = command.ExecuteContext(t.Context())
+	return strings.TrimSpace(output.String()), err
+}
+
+const (
+	testPrivateKey = "0x123"
+	testPublicKey  = "0x566d69d8c99f62bc71118399bab25c1f03719463eab8d6a444cd11ece131616"
+)
+
+func TestKeygenCommand(t *testing.T) {
+	output, err := runCommand(t, "keygen")
+	require.NoError(t, err)
+
+	var keyPair struct {
+		PrivateKey string `json:"privateKey"`
+		PublicKey  string `json:"publicKey"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(output), &keyPair))
+
+	privateKey, ok := new(big.Int).SetString(keyPair.PrivateKey, 0)
+	require.True(t, ok)
+	publicKey, _, err := curve.Curve.PrivateToPoint(privateKey)
+	require.NoError(t, err)
+	require.Equal(t, "0x"+publicKey.Text(16), keyPair.PublicKey)
+}
+
+func TestPubkeyCommand(t *testing.T) {
+	t.Setenv("SIGNER_PRIVATE_KEY", testPrivateKey)
+
+	output, err := runCommand(t, "pubkey", "--env", "")
+	require.NoError(t, err)
+	require.Equal(t, testPublicKey, output)
+
+	t.Setenv("SIGNER_PRIVATE_KEY", "")
+	_, err = runCommand(t, "pubkey", "--env", "")
+	require.ErrorContains(t, err, "couldn't read SIGNER_PRIVATE_KEY env var")
+}
+
+func TestSignHashAndVerifyCommands(t *testing.T) {
+	t.Setenv("SIGNER_PRIVATE_KEY", testPrivateKey)
+
+	output, err := runCommand(t, "sign-hash", "0xabcdef", "--env", "")
+	require.NoError(t, err)
+
+	var signed struct {
+		Hash      string    `json:"hash"`
+		PublicKey string    `json:"publicKey"`
+		Signature [2]string `json:"signature"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(output), &signed))
+	require.Equal(t, "0xabcdef", signed.Hash)
+	require.Equal(t, testPublicKey, signed.PublicKey)
+
+	r, s := signed.Signature[0], signed.Signature[1]
+	output, err = runCommand(t, "verify", "0xabcdef", r, s, "--public-key", testPublicKey)
+	require.NoError(t, err)
+	require.Equal(t, "Signature is valid", output)
+
+	_, err = runCommand(t, "verify", "0xabcde0", r, s, "--public-key", testPublicKey)
+	require.EqualError(t, err, "the signature is not valid")
+
+	_, err = runCommand(t, "verify", "0xabcdef", r, "xyz", "--public-key", testPublicKey)
+	require.ErrorContains(t, err, "invalid signature s")
+
+	_, err = runCommand(t, "sign-hash", "not a hash", "--env", "")
+	require.ErrorContains(t, err, "invalid hash")
+}
+
+func TestAccountAddressCommand(t *testing.T) {
+	// Accounts deployed on Sepolia by DEPLOY_ACCOUNT transactions
+	t.Run("Public key as salt and constructor calldata", func(t *testing.T) {
+		output, err := runCommand(t,
+			"account-address",
+			"--class-hash", "0x061dac032f228abef9c6626f995015233097ae253a7f72d68552db02f2971b8f",
+			"--public-key", "0x023a851e8aeba201772098e1a1db3448f6238b20f928527242eb383905d91a87",
+		)
+		require.NoError(t, err)
+		require.Equal(t, "0x28771beb7a2522a07d2ae6fc1fa5af942e8e863f70e6d7d74f9600ea3d5c242", output)
+	})
+
+	t.Run("Given salt and constructor calldata", func(t *testing.T) {
+		output, err := runCommand(t,
+			"account-address",
+			"--class-hash", "0x064728e0c0713811c751930f8d3292d683c23f107c89b0a101425d9e80adb1c0",
+			"--public-key", "0x1",
+			"--salt", "0x0702e82f1ec15656ad4502268dad530197141f3b59f5529835af9318ef399da5",
+			"--calldata", "0x022f3e55b61d86c2ac5239fa3b3b8761f26b9a5c0b5f61ddbd5d756ced498b46",
+		)
+		require.NoError(t, err)
+		require.Equal(t, "0x31463b5263a6631be4d1fe92d64d13e3a8498c440bf789e69ccb951eb8ad5da", output)
+	})
+
+	t.Run("Class hash is required", func(t *testing.T) {
+		_, err := runCommand(t, "account-address", "--public-key", testPublicKey)
+		require.ErrorContains(t, err, `required flag(s) "class-hash" not set`)
+	})
+}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+
+	"github.com/NethermindEth/starknet-staking-v2/signer"
+	"github.com/joho/godotenv"
+	"github.com/spf13/cobra"
+)
+
+func NewKeystoreCommand() cobra.Command {
+	cmd := cobra.Command{
+		Use:   "keystore",
+		Short: "Manage the encrypted keystores holding the signer private key",
+	}
+
+	createCmd := newKeystoreCreateCommand()
+	importCmd := newKeystoreImportCommand()
+	inspectCmd := newKeystoreInspectCommand()
+	cmd.AddCommand(&createCmd, &importCmd, &inspectCmd)
+
+	return cmd
+}
+
+func newKeystoreCreateCommand() cobra.Command {
+	var kdf string
+	var passwordFile string
+
+	cmd := cobra.Command{
+		Use:   "create <keystore path>",
+		Short: "Generate a new private key and store it encrypted",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			privateKey, err := signer.NewPrivateKey()
+			if err != nil {
+				return err
+			}
+			return writeKeystore(cmd, args[0], privateKey, kdf, passwordFile)
+		},
+	}
+	addKeystoreFlags(&cmd, &kdf, &passwordFile)
+
+	return cmd
+}
+
+func newKeystoreImportCommand() cobra.Command {
+	var kdf string
+	var passwordFile string
+	var envFilePath string
+
+	cmd := cobra.Command{
+		Use:   "import <keystore path>",
+		Short: "Store encrypted the private key set in the SIGNER_PRIVATE_KEY env var",
+		Long: "Store encrypted the private key set in the SIGNER_PRIVATE_KEY env var." +
+			" If it's not set, the private key is prompted",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			privateKeyStr, err := readPrivateKeyToImport(envFilePath)
+			if err != nil {
+				return err
+			}
+			privateKey, ok := new(big.Int).SetString(privateKeyStr, 0)
+			if !ok || privateKey.Sign() <= 0 {
+				return errors.New("invalid private key, expected a positive hex or decimal integer")
+			}
+			return writeKeystore(cmd, args[0], privateKey, kdf, passwordFile)
+		},
+	}
+	addKeystoreFlags(&cmd, &kdf, &passwordFile)
+	cmd.Flags().StringVar(&envFilePath, "env", ".env", "Path to the env file")
+
+	return cmd
+}
+
+// Information of a keystore that doesn't require the password
+type keystoreInfo struct {
+	Path      string `json:"path"`
+	Id        string `json:"id"`
+	Version   int    `json:"version"`
+	PublicKey string `json:"publicKey"`
+	Cipher    string `json:"cipher"`
+	KDF       string `json:"kdf"`
+	// Only set when the password is checked
+	PasswordValid *bool `json:"passwordValid,omitempty"`
+}
+
+func newKeystoreInspectCommand() cobra.Command {
+	var checkPassword bool
+	var passwordFile string
+
+	cmd := cobra.Command{
+		Use:   "inspect <keystore path>",
+		Short: "Show the public key and encryption details of a keystore",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			keystore, err := signer.KeystoreFromFile(args[0])
+			if err != nil {
+				return err
+			}
+			info := keystoreInfo{
+				Path:      args[0],
+				Id:        keystore.Id,
+				Version:   keystore.Version,
+				PublicKey: keystore.PublicKey,
+				Cipher:    keystore.Crypto.Cipher,
+				KDF:       keystore.Crypto.KDF,
+			}
+
+			if checkPassword || passwordFile != "" {
+				password, err := signer.ReadPassword(passwordFile, "Keystore password: ")
+				if err != nil {
+					return err
+				}
+				_, err = keystore.Decrypt(password)
+				if err != nil && !errors.Is(err, signer.ErrWrongPassword) {
+					return err
+				}
+				passwordValid := err == nil
+				info.PasswordValid = &passwordValid
+			}
+
+			output, err := json.MarshalIndent(info, "", "  ")
+			if err != nil {
+				return err
+			}
+			cmd.Println(string(output))
+			return nil
+		},
+	}
+	cmd.Flags().BoolVar(
+		&checkPassword, "check-password", false, "Check the keystore can be decrypted",
+	)
+	cmd.Flags().StringVar(
+		&passwordFile,
+		"password-file",
+		"",
+		"Path to the file with the keystore password. Implies --check-password",
+	)
+
+	return cmd
+}
+
+func addKeystoreFlags(cmd *cobra.Command, kdf *string, passwordFile *string) {
+	cmd.Flags().StringVar(
+		kdf, "kdf", signer.KDF_SCRYPT, "Key derivation function. Options: scrypt, pbkdf2",
+	)
+	cmd.Flags().StringVar(
+		passwordFile,
+		"password-file",
+		"",
+		"Path to the file with the keystore password. It's prompted when not set",
+	)
+}
+
+func writeKeystore(
+	cmd *cobra.Command, path string, privateKey *big.Int, kdf string, passwordFile string,
+) error {
+	kdfParams, err := signer.KDFParamsFromName(kdf)
+	if err != nil {
+		return err
+	}
+	password, err := signer.ReadNewPassword(passwordFile)
+	if err != nil {
+		return err
+	}
+	if password == "" {
+		return errors.New("the keystore password can't be empty")
+	}
+
+	keystore, err := signer.EncryptKey(privateKey, password, kdf, kdfParams)
+	if err != nil {
+		return err
+	}
+	if err := keystore.Save(path); err != nil {
+		return err
+	}
+
+	cmd.Printf("Keystore saved at %s\nPublic key: %s\n", path, keystore.PublicKey)
+	return nil
+}
+
+func readPrivateKeyToImport(envFilePath string) (string, error) {
+	// The env file is optional, just like when running the signer
+	_ = godotenv.Load(envFilePath)
+	if privateKey := os.Getenv("SIGNER_PRIVATE_KEY"); privateKey != "" {
+		return privateKey, nil
+	}
+
+	privateKey, err := signer.ReadPassword("", "Private key: ")
+	if err != nil {
+		return "", fmt.Errorf("cannot read the private key to import: %w", err)
+	}
+	return privateKey, nil
+}
@@ -2,25 +2,56 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
 	"errors"
 	"fmt"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
+	"github.com/NethermindEth/juno/core/felt"
 	"github.com/NethermindEth/juno/utils"
 	"github.com/NethermindEth/starknet-staking-v2/signer"
 	"github.com/joho/godotenv"
 	"github.com/spf13/cobra"
 )
 
+// How long the requests being signed have to finish once a shutdown signal is received
+const shutdownTimeout = 15 * time.Second
+
 func NewCommand() cobra.Command {
 	var address string
 	var envFilePath string
 	var logLevelF string
 
-	var privKey string
+	var keysFile string
+	var keyBackendF string
+	var keystorePath string
+	var passwordFile string
+	var transitURL string
+	var transitKey string
+
+	var authSchemeF string
+	var insecureNoAuth bool
+
+	var tlsCertFile string
+	var tlsKeyFile string
+	var tlsClientCAFile string
+
+	var policyFile string
+	var allowHashSigning bool
+	var auditLogPath string
+	var attestContractF string
+
+	var keys signer.KeyBackend
+	var accountKeys []signer.AccountKey
+	var auth signer.Auth
+	var tlsConfig *tls.Config
+	var policy signer.Policy
 	var logger *utils.ZapLogger
 
-	preRunE := func(_ *cobra.Command, args []string) error {
+	preRunE := func(cmd *cobra.Command, args []string) error {
 		var err error
 
 		logLevel := utils.NewLogLevel(utils.INFO)
@@ -32,20 +63,149 @@ func NewCommand() cobra.Command {
 			return err
 		}
 
-		privKey, err = readSignerKeyFromEnv(envFilePath, logger)
+		if err := godotenv.Load(envFilePath); err != nil {
+			logger.Debugf("couldn't load env var at %s: %s", envFilePath, err)
+		}
+
+		if keysFile != "" {
+			for _, flag := range []string{
+				"key-backend", "keystore", "password-file", "transit-url", "transit-key",
+			} {
+				if cmd.Flags().Changed(flag) {
+					return fmt.Errorf(
+						"--%s cannot be used with --keys, the keys file sets the key of each account",
+						flag,
+					)
+				}
+			}
+			accountKeys, err = loadAccountKeys(keysFile)
+		} else {
+			keys, err = newKeyBackend(keyBackendF, keystorePath, passwordFile, transitURL, transitKey)
+		}
+		if err != nil {
+			return err
+		}
+
+		// The attestation contract differs on each network, so it's never assumed
+		var attestContract *felt.Felt
+		if attestContractF != "" {
+			attestContract, err = new(felt.Felt).SetString(attestContractF)
+			if err != nil {
+				return fmt.Errorf("invalid attestation contract address: %w", err)
+			}
+		} else if policyFile == "" {
+			return errors.New(
+				"--attest-contract-address is required by the default policy, which only" +
+					" allows calling attest on the attestation contract",
+			)
+		}
+		if policyFile != "" {
+			policy, err = signer.PolicyFromFile(policyFile, attestContract)
+			if err != nil {
+				return err
+			}
+		} else {
+			policy = signer.DefaultPolicy(attestContract)
+		}
+
+		if tlsCertFile != "" || tlsKeyFile != "" || tlsClientCAFile != "" {
+			tlsConfig, err = signer.ServerTLSConfig(tlsCertFile, tlsKeyFile, tlsClientCAFile)
+			if err != nil {
+				return err
+			}
+		}
+
+		if insecureNoAuth {
+			logger.Warn(
+				"Running without authentication, anybody reaching the signer can get" +
+					" transactions signed",
+			)
+			return nil
+		}
+		auth, err = signer.NewAuth(authSchemeF, os.Getenv("SIGNER_AUTH_SECRET"))
 		if err != nil {
 			return err
 		}
+		if auth.Scheme == signer.AuthNone {
+			return errors.New(
+				"authentication is required, set an auth scheme or use --insecure-no-auth",
+			)
+		}
+		if auth.Scheme == signer.AuthBearer && tlsConfig == nil {
+			logger.Warn("The bearer token is sent unencrypted, consider enabling TLS")
+		}
 
 		return nil
 	}
 
 	runE := func(_ *cobra.Command, args []string) error {
-		remoteSigner, err := signer.New(privKey, logger)
-		if err != nil {
+		var auditLog *signer.AuditLog
+		if auditLogPath != "" {
+			var err error
+			auditLog, err = signer.OpenAuditLog(auditLogPath)
+			if err != nil {
+				return err
+			}
+			defer func() { _ = auditLog.Close() }()
+		} else {
+			logger.Warn("Running without an audit log of the signed transactions")
+		}
+
+		var remoteSigner signer.Signer
+		var err error
+		if accountKeys != nil {
+			remoteSigner, err = signer.NewMultiKey(accountKeys, auth, policy, auditLog, logger)
+			if err != nil {
+				return err
+			}
+			for _, key := range remoteSigner.Keys() {
+				logger.Infof("Signing for account %s with public key %s", key.Address, key.PublicKey)
+			}
+		} else {
+			remoteSigner, err = signer.New(keys, auth, policy, auditLog, logger)
+			if err != nil {
+				return err
+			}
+			logger.Infof("Signing with public key %s", remoteSigner.PublicKey())
+		}
+
+		if allowHashSigning {
+			remoteSigner.EnableHashSigning()
+			logger.Warn(
+				"Signing bare hashes, the policy only applies to requests with the transaction",
+			)
+		}
+		if adminSecret := os.Getenv("SIGNER_ADMIN_SECRET"); adminSecret != "" {
+			if err := remoteSigner.EnableAdmin(adminSecret); err != nil {
+				return err
+			}
+			logger.Infof("Serving the signer keys at %s", signer.ADMIN_KEYS_ENDPOINT)
+		}
+
+		signalCh := make(chan os.Signal, 1)
+		signal.Notify(signalCh, syscall.SIGINT, syscall.SIGTERM)
+		defer signal.Stop(signalCh)
+
+		errCh := make(chan error, 1)
+		go func() {
+			errCh <- remoteSigner.Listen(address, tlsConfig)
+		}()
+
+		select {
+		case <-signalCh:
+			logger.Info("Received shutdown signal")
+		case err := <-errCh:
 			return err
 		}
-		return remoteSigner.Listen(address)
+
+		// The transactions being signed are answered, and recorded in the audit log,
+		// before it's closed
+		ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		if err := remoteSigner.Shutdown(ctx); err != nil {
+			return fmt.Errorf("cannot shut down the signer: %w", err)
+		}
+		return <-errCh
 	}
 
 	cmd := cobra.Command{
@@ -60,10 +220,116 @@ func NewCommand() cobra.Command {
 		&address, "address", "localhost:8080", "Address where to listen for requests",
 	)
 	cmd.Flags().StringVar(&envFilePath, "env", ".env", "Path to JSON config file")
+	cmd.Flags().StringVar(
+		&keysFile,
+		"keys",
+		"",
+		"Path to the JSON file with the address of each account to sign for and where its key"+
+			" is held. Transactions from other senders are refused",
+	)
+	cmd.Flags().StringVar(
+		&keyBackendF,
+		"key-backend",
+		"",
+		"Where the private key is held. Options: memory, keystore, transit. Defaults to"+
+			" keystore when --keystore is set and to memory, with the key in the"+
+			" SIGNER_PRIVATE_KEY env var, otherwise",
+	)
+	cmd.Flags().StringVar(
+		&keystorePath,
+		"keystore",
+		"",
+		"Path to the encrypted keystore with the private key, used instead of the"+
+			" SIGNER_PRIVATE_KEY env var",
+	)
+	cmd.Flags().StringVar(
+		&passwordFile,
+		"password-file",
+		"",
+		"Path to the file with the keystore password. It's prompted when not set",
+	)
+	cmd.Flags().StringVar(
+		&transitURL,
+		"transit-url",
+		"",
+		"Url of the transit key management service holding the private key."+
+			" Its token is read from the SIGNER_TRANSIT_TOKEN env var",
+	)
+	cmd.Flags().StringVar(
+		&transitKey, "transit-key", "", "Name of the key in the transit service",
+	)
+	cmd.Flags().StringVar(
+		&authSchemeF,
+		"auth-scheme",
+		string(signer.AuthHMAC),
+		"How requests are authenticated with the secret in the SIGNER_AUTH_SECRET env var."+
+			" Options: hmac, bearer",
+	)
+	cmd.Flags().BoolVar(
+		&insecureNoAuth,
+		"insecure-no-auth",
+		false,
+		"Accept requests without authentication. Only meant for local setups",
+	)
+	cmd.Flags().StringVar(
+		&policyFile,
+		"policy",
+		"",
+		"Path to the JSON file with the rules a transaction must follow to be signed."+
+			" By default only attest calls to the attestation contract are signed",
+	)
+	cmd.Flags().BoolVar(
+		&allowHashSigning,
+		"allow-hash-signing",
+		false,
+		"Sign the bare hashes sent in hash mode to /v2/sign. The policy can't be applied to"+
+			" them, so only enable it when the clients are trusted",
+	)
+	cmd.Flags().StringVar(
+		&auditLogPath,
+		"audit-log",
+		"",
+		"Path to the append-only audit log recording every request and the decision taken",
+	)
+	cmd.Flags().StringVar(
+		&attestContractF,
+		"attest-contract-address",
+		"",
+		"Address of the attestation contract of the network, the only contract the default"+
+			" policy allows calling. Required unless the policy file sets the allowed calls",
+	)
+	cmd.Flags().StringVar(
+		&tlsCertFile, "tls-cert", "", "Path to the PEM encoded TLS certificate. Enables TLS",
+	)
+	cmd.Flags().StringVar(&tlsKeyFile, "tls-key", "", "Path to the PEM encoded TLS private key")
+	cmd.Flags().StringVar(
+		&tlsClientCAFile,
+		"tls-client-ca",
+		"",
+		"Path to the PEM encoded CA certificates that sign the accepted client certificates."+
+			" Requires clients to present a certificate (mutual TLS)",
+	)
 	cmd.Flags().StringVar(
 		&logLevelF, "log-level", utils.INFO.String(), "Options: trace, debug, info, warn, error",
 	)
 
+	keystoreCmd := NewKeystoreCommand()
+	auditCmd := NewAuditCommand()
+	keygenCmd := NewKeygenCommand()
+	pubkeyCmd := NewPubkeyCommand()
+	signHashCmd := NewSignHashCommand()
+	verifyCmd := NewVerifyCommand()
+	accountAddressCmd := NewAccountAddressCommand()
+	cmd.AddCommand(
+		&keystoreCmd,
+		&auditCmd,
+		&keygenCmd,
+		&pubkeyCmd,
+		&signHashCmd,
+		&verifyCmd,
+		&accountAddressCmd,
+	)
+
 	return cmd
 }
 
@@ -76,20 +342,45 @@ func main() {
 	}
 }
 
-func readSignerKeyFromEnv(envFilePath string, logger *utils.ZapLogger) (string, error) {
-	err := godotenv.Load(envFilePath)
-	if err != nil {
-		logger.Debugf("couldn't load env var at %s: %s", envFilePath, err)
+// Returns the backend holding the private key the signer signs with
+func newKeyBackend(
+	backend string,
+	keystorePath string,
+	passwordFile string,
+	transitURL string,
+	transitKey string,
+) (signer.KeyBackend, error) {
+	if backend == "" {
+		backend = signer.KEY_BACKEND_MEMORY
+		if keystorePath != "" {
+			backend = signer.KEY_BACKEND_KEYSTORE
+		}
 	}
 
-	signerKey := os.Getenv("SIGNER_PRIVATE_KEY")
-	if signerKey == "" {
-		return "",
-			errors.New(
-				"couldn't read SIGNER_PRIVATE_KEY env var." +
-					"Please make sure it is set before running this program",
-			)
+	keyConfig := signer.KeyConfig{
+		Backend:      backend,
+		Keystore:     keystorePath,
+		PasswordFile: passwordFile,
+		TransitURL:   transitURL,
+		TransitKey:   transitKey,
 	}
+	return keyConfig.NewBackend()
+}
 
-	return signerKey, nil
+// Returns the key of each account in the keys file
+func loadAccountKeys(keysFile string) ([]signer.AccountKey, error) {
+	configs, err := signer.AccountKeysFromFile(keysFile)
+	if err != nil {
+		return nil, err
+	}
+
+	accountKeys := make([]signer.AccountKey, len(configs))
+	for i := range configs {
+		keys, err := configs[i].NewBackend()
+		if err != nil {
+			return nil, fmt.Errorf("key of account %s: %w", configs[i].Address, err)
+		}
+		accountKeys[i] = signer.AccountKey{Address: configs[i].Address, Keys: keys}
+	}
+	return accountKeys, nil
 }
@@ -1 +1,16 @@
 package main_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignerCommand(t *testing.T) {
+	t.Setenv("SIGNER_PRIVATE_KEY", testPrivateKey)
+
+	t.Run("Attestation contract is required by the default policy", func(t *testing.T) {
+		_, err := runCommand(t, "--insecure-no-auth")
+		require.ErrorContains(t, err, "--attest-contract-address is required by the default policy")
+	})
+}
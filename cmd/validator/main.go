@@ -2,15 +2,18 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"os/signal"
+	"strconv"
 	"syscall"
 	"time"
 
 	"github.com/NethermindEth/juno/utils"
 	"github.com/NethermindEth/starknet-staking-v2/validator"
 	configP "github.com/NethermindEth/starknet-staking-v2/validator/config"
+	"github.com/NethermindEth/starknet-staking-v2/validator/constants"
 	"github.com/NethermindEth/starknet-staking-v2/validator/metrics"
 	"github.com/NethermindEth/starknet-staking-v2/validator/types"
 	"github.com/spf13/cobra"
@@ -31,6 +34,10 @@ func NewCommand() cobra.Command {
 	var logLevelF string
 	var maxRetriesF string
 	var metricsAddressF string
+	var pollInterval time.Duration
+	var fallbackProvidersF []string
+	var journalDir string
+	var noJournal bool
 
 	var config configP.Config
 	var maxRetries types.Retries
@@ -38,6 +45,12 @@ func NewCommand() cobra.Command {
 	var logger utils.ZapLogger
 
 	preRunE := func(cmd *cobra.Command, args []string) error {
+		for _, fallbackProvider := range fallbackProvidersF {
+			config.FallbackProviders = append(
+				config.FallbackProviders, configP.ProviderFromString(fallbackProvider),
+			)
+		}
+
 		// Config takes the values from flags directly,
 		// then fills the missing ones from the env vars
 		configFromEnv := configP.FromEnv()
@@ -62,6 +75,32 @@ func NewCommand() cobra.Command {
 		}
 		maxRetries = parsedRetries
 
+		if err := validator.CheckPollInterval(pollInterval); err != nil {
+			return err
+		}
+
+		if noJournal {
+			if journalDir != "" {
+				return errors.New("--journal-dir cannot be used with --no-journal")
+			}
+		} else if journalDir == "" {
+			journalDir, err = validator.DefaultJournalDir()
+			if err != nil {
+				return fmt.Errorf("%w. Set one with --journal-dir or use --no-journal", err)
+			}
+		}
+
+		if _, err := types.AttestFeeFromString(
+			snConfig.AttestOptions, snConfig.FeeMultiplier,
+		); err != nil {
+			return err
+		}
+		if _, err := types.FeeCapFromStrings(
+			snConfig.MaxAttestFee, snConfig.DailyFeeBudget,
+		); err != nil {
+			return err
+		}
+
 		logLevel := utils.NewLogLevel(utils.INFO)
 		if err := logLevel.Set(logLevelF); err != nil {
 			return err
@@ -100,7 +139,16 @@ func NewCommand() cobra.Command {
 		// Start validator in a goroutine
 		errCh := make(chan error, 1)
 		go func() {
-			if err := validator.Attest(ctx, &config, &snConfig, maxRetries, logger, metricsServer); err != nil {
+			if err := validator.Attest(
+				ctx,
+				&config,
+				&snConfig,
+				maxRetries,
+				pollInterval,
+				journalDir,
+				logger,
+				metricsServer,
+			); err != nil {
 				logger.Error(err)
 				errCh <- err
 			}
@@ -137,7 +185,19 @@ func NewCommand() cobra.Command {
 
 	// Config provider flags
 	cmd.Flags().StringVar(&config.Provider.Http, "provider-http", "", "Provider http address")
-	cmd.Flags().StringVar(&config.Provider.Ws, "provider-ws", "", "Provider ws address")
+	cmd.Flags().StringVar(
+		&config.Provider.Ws,
+		"provider-ws",
+		"",
+		"Provider ws address. If not set, new blocks are polled through the http provider",
+	)
+	cmd.Flags().StringArrayVar(
+		&fallbackProvidersF,
+		"provider-fallback",
+		nil,
+		"Fallback provider used when the ones before it are unhealthy, written as"+
+			" \"<http address>[,<ws address>]\". Can be repeated, providers are tried in order",
+	)
 
 	// Config signer flags
 	cmd.Flags().StringVar(
@@ -146,15 +206,103 @@ func NewCommand() cobra.Command {
 		"",
 		"Signer url address, required if using an external signer",
 	)
+	cmd.Flags().StringArrayVar(
+		&config.Signer.FallbackURLs,
+		"signer-fallback-url",
+		nil,
+		"Url of an external signer holding the same key, used when the ones before it are"+
+			" failing. Can be repeated, signers are tried in order",
+	)
 	cmd.Flags().StringVar(
 		&config.Signer.PrivKey, "signer-priv-key", "", "Signer private key, required for signing",
 	)
+	cmd.Flags().StringVar(
+		&config.Signer.Keystore,
+		"signer-keystore",
+		"",
+		"Path to the encrypted keystore with the signer private key",
+	)
+	cmd.Flags().StringVar(
+		&config.Signer.KeystorePasswordFile,
+		"signer-keystore-password-file",
+		"",
+		"Path to the file with the keystore password. It's prompted when not set",
+	)
 	cmd.Flags().StringVar(
 		&config.Signer.OperationalAddress,
 		"signer-op-address",
 		"",
 		"Signer operational address, required for attesting",
 	)
+	cmd.Flags().StringVar(
+		&config.Signer.AuthScheme,
+		"signer-auth-scheme",
+		"",
+		"How requests to the external signer are authenticated. Options: hmac, bearer."+
+			" Defaults to hmac when a secret is set",
+	)
+	cmd.Flags().StringVar(
+		&config.Signer.AuthSecret,
+		"signer-auth-secret",
+		"",
+		"Secret shared with the external signer. Prefer the SIGNER_AUTH_SECRET env var"+
+			" or the config file, flags can be seen by other users of the machine",
+	)
+	cmd.Flags().StringVar(
+		&config.Signer.TLSCACert,
+		"signer-tls-ca-cert",
+		"",
+		"Path to the PEM encoded CA certificate that signs the external signer's certificate."+
+			" Defaults to the system CAs",
+	)
+	cmd.Flags().StringVar(
+		&config.Signer.TLSClientCert,
+		"signer-tls-client-cert",
+		"",
+		"Path to the PEM encoded client certificate presented to the external signer",
+	)
+	cmd.Flags().StringVar(
+		&config.Signer.TLSClientKey,
+		"signer-tls-client-key",
+		"",
+		"Path to the PEM encoded private key of the client certificate",
+	)
+	cmd.Flags().StringVar(
+		&config.Signer.Timeout,
+		"signer-timeout",
+		"",
+		"How long each request to the external signer can take, e.g. '5s'. Defaults to "+
+			constants.DEFAULT_SIGNER_TIMEOUT.String(),
+	)
+	cmd.Flags().StringVar(
+		&config.Signer.MaxRetries,
+		"signer-max-retries",
+		"",
+		"How many times a request to the external signer failing with a connection or"+
+			" server error, or timing out, is retried. Defaults to "+
+			strconv.Itoa(constants.DEFAULT_SIGNER_MAX_RETRIES),
+	)
+	cmd.Flags().StringVar(
+		&config.Signer.Protocol,
+		"signer-protocol",
+		"",
+		"Version of the protocol spoken with the external signer. Options: v1, v2."+
+			" The unversioned /sign endpoint is used when not set",
+	)
+	cmd.Flags().StringVar(
+		&config.Signer.SignMode,
+		"signer-sign-mode",
+		"",
+		"What is sent to a v2 external signer. Options: transaction, hash."+
+			" Defaults to transaction",
+	)
+	cmd.Flags().BoolVar(
+		&config.Signer.AllowUnverifiedSignatures,
+		"signer-allow-unverified-signatures",
+		false,
+		"Accept signatures other than r and s from a v2 external signer without verifying"+
+			" them, for accounts that don't validate a single key signature",
+	)
 
 	// Config starknet flags
 	cmd.Flags().StringVar(
@@ -169,16 +317,44 @@ func NewCommand() cobra.Command {
 		"",
 		"Staking contract address. Defaults values are provided for Sepolia and Mainnet",
 	)
-	// Disabled for now
-	// cmd.Flags().StringVar(
-	// 	&snConfig.AttestOptions,
-	// 	"attest-fee",
-	// 	"once",
-	// 	"This flag determines the fee to pay for each attest transaction."+
-	// 		" It can be either a positive number or one of the follwing options:\n"+
-	// 		" - \"once\": attest fee is estimated once and successive calls use that value.\n"+
-	// 		" - \"always\": an estimate fee call is done before submitting each attestation.",
-	// )
+	cmd.Flags().StringVar(
+		&snConfig.AttestOptions,
+		"attest-fee",
+		types.AttestFeeAlways,
+		"This flag determines the fee to pay for each attest transaction."+
+			" It can be either one of the following options or fixed resource bounds:\n"+
+			" - \"always\": an estimate fee call is done before submitting each attestation.\n"+
+			" - \"once\": attest fee is estimated once and successive calls use that value.\n"+
+			" - \"l1_gas=<max amount>:<max price per unit>,l1_data_gas=...,l2_gas=...\":"+
+			" every attestation is sent with these resource bounds, no estimation is done.",
+	)
+	cmd.Flags().Float64Var(
+		&snConfig.FeeMultiplier,
+		"fee-multiplier",
+		constants.DEFAULT_FEE_ESTIMATION_MULTIPLIER,
+		"Multiplier applied to the estimated fee of the attest transactions, must be at least 1",
+	)
+	cmd.Flags().StringVar(
+		&snConfig.MaxAttestFee,
+		"max-attest-fee",
+		"",
+		"Maximum fee an attest transaction can pay, either in fri or in STRK with the"+
+			" 'strk' suffix, e.g. '0.5strk'. No limit by default",
+	)
+	cmd.Flags().StringVar(
+		&snConfig.DailyFeeBudget,
+		"daily-fee-budget",
+		"",
+		"Maximum fee the attest transactions sent in the last 24 hours can pay, either in fri"+
+			" or in STRK with the 'strk' suffix. No limit by default",
+	)
+	cmd.Flags().BoolVar(
+		&snConfig.WaitForLowerFee,
+		"wait-for-lower-fee",
+		false,
+		"When the attest fee is above the limits, retry on the next blocks of the attestation"+
+			" window instead of skipping the attestation for the current epoch",
+	)
 	// Other flags
 	cmd.Flags().StringVar(
 		&maxRetriesF,
@@ -187,6 +363,35 @@ func NewCommand() cobra.Command {
 		"How many times to retry to get information required for attestation."+
 			" It can be either a positive integer or the key word 'infinite'",
 	)
+	cmd.Flags().DurationVar(
+		&pollInterval,
+		"provider-poll-interval",
+		2*time.Second,
+		"How often to poll the http provider for new blocks when no websocket connection is available",
+	)
+	cmd.Flags().Uint64Var(
+		&snConfig.ReplaceAfterBlocks,
+		"replace-after-blocks",
+		constants.DEFAULT_REPLACE_AFTER_BLOCKS,
+		"How many blocks an attest transaction can wait to be included before it is replaced"+
+			" by one with the same nonce and a higher tip. Set to 0 to never replace it",
+	)
+	cmd.Flags().StringVar(
+		&journalDir,
+		"journal-dir",
+		"",
+		"Directory where the state of the current attestation is saved, so it can be"+
+			" resumed after a restart instead of attesting again."+
+			" Defaults to starknet-staking-v2/journal in the user state directory"+
+			" ($XDG_STATE_HOME or ~/.local/state)",
+	)
+	cmd.Flags().BoolVar(
+		&noJournal,
+		"no-journal",
+		false,
+		"Don't save the state of the current attestation. After a restart during the"+
+			" attestation window, an attest transaction not included yet is sent again",
+	)
 	cmd.Flags().StringVar(
 		&logLevelF, "log-level", utils.INFO.String(), "Options: trace, debug, info, warn, error.",
 	)
@@ -40,6 +40,73 @@ func TestNewCommand(t *testing.T) {
 		require.ErrorContains(t, err, "private key")
 	})
 
+	t.Run("PreRunE returns an error: fallback provider verification fails", func(t *testing.T) {
+		command := main.NewCommand()
+		command.SetArgs([]string{
+			"--provider-http", "http://localhost:1234",
+			"--provider-fallback", "http://localhost:2234,ws://localhost:2235",
+			"--provider-fallback", ",ws://localhost:3235",
+			"--signer-op-address", "0x456",
+			"--signer-priv-key", "0x123",
+		})
+
+		err := command.ExecuteContext(t.Context())
+		require.ErrorContains(t, err, "fallback provider 2: http provider url")
+	})
+
+	t.Run("PreRunE returns an error: attest fee verification fails", func(t *testing.T) {
+		command := main.NewCommand()
+		command.SetArgs([]string{
+			"--provider-http", "http://localhost:1234",
+			"--signer-op-address", "0x456",
+			"--signer-priv-key", "0x123",
+			"--attest-fee", "l1_gas=0x100:0x200",
+		})
+
+		err := command.ExecuteContext(t.Context())
+		require.ErrorContains(t, err, "missing resources: l1_data_gas, l2_gas")
+	})
+
+	t.Run("PreRunE returns an error: fee cap verification fails", func(t *testing.T) {
+		command := main.NewCommand()
+		command.SetArgs([]string{
+			"--provider-http", "http://localhost:1234",
+			"--signer-op-address", "0x456",
+			"--signer-priv-key", "0x123",
+			"--daily-fee-budget", "ten strk",
+		})
+
+		err := command.ExecuteContext(t.Context())
+		require.ErrorContains(t, err, "invalid daily fee budget")
+	})
+
+	t.Run("PreRunE returns an error: poll interval verification fails", func(t *testing.T) {
+		command := main.NewCommand()
+		command.SetArgs([]string{
+			"--provider-http", "http://localhost:1234",
+			"--signer-op-address", "0x456",
+			"--signer-priv-key", "0x123",
+			"--provider-poll-interval", "0s",
+		})
+
+		err := command.ExecuteContext(t.Context())
+		require.ErrorContains(t, err, "the provider poll interval must be positive, got 0s")
+	})
+
+	t.Run("PreRunE returns an error: journal directory with the journal disabled", func(t *testing.T) {
+		command := main.NewCommand()
+		command.SetArgs([]string{
+			"--provider-http", "http://localhost:1234",
+			"--signer-op-address", "0x456",
+			"--signer-priv-key", "0x123",
+			"--journal-dir", t.TempDir(),
+			"--no-journal",
+		})
+
+		err := command.ExecuteContext(t.Context())
+		require.EqualError(t, err, "--journal-dir cannot be used with --no-journal")
+	})
+
 	t.Run("Full command setup works with config file", func(t *testing.T) {
 		command := main.NewCommand()
 
@@ -73,18 +73,18 @@ func (mr *MockSignerMockRecorder) BlockWithTxHashes(ctx, blockID any) *gomock.Ca
 }
 
 // BuildAndSendInvokeTxn mocks base method.
-func (m *MockSigner) BuildAndSendInvokeTxn(ctx context.Context, functionCalls []rpc.InvokeFunctionCall, multiplier float64) (*rpc.AddInvokeTransactionResponse, error) {
+func (m *MockSigner) BuildAndSendInvokeTxn(ctx context.Context, functionCalls []rpc.InvokeFunctionCall, resourceBounds *rpc.ResourceBoundsMapping) (*rpc.AddInvokeTransactionResponse, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "BuildAndSendInvokeTxn", ctx, functionCalls, multiplier)
+	ret := m.ctrl.Call(m, "BuildAndSendInvokeTxn", ctx, functionCalls, resourceBounds)
 	ret0, _ := ret[0].(*rpc.AddInvokeTransactionResponse)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // BuildAndSendInvokeTxn indicates an expected call of BuildAndSendInvokeTxn.
-func (mr *MockSignerMockRecorder) BuildAndSendInvokeTxn(ctx, functionCalls, multiplier any) *gomock.Call {
+func (mr *MockSignerMockRecorder) BuildAndSendInvokeTxn(ctx, functionCalls, resourceBounds any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BuildAndSendInvokeTxn", reflect.TypeOf((*MockSigner)(nil).BuildAndSendInvokeTxn), ctx, functionCalls, multiplier)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BuildAndSendInvokeTxn", reflect.TypeOf((*MockSigner)(nil).BuildAndSendInvokeTxn), ctx, functionCalls, resourceBounds)
 }
 
 // Call mocks base method.
@@ -102,6 +102,21 @@ func (mr *MockSignerMockRecorder) Call(ctx, call, blockId any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Call", reflect.TypeOf((*MockSigner)(nil).Call), ctx, call, blockId)
 }
 
+// EstimateInvokeTxnFee mocks base method.
+func (m *MockSigner) EstimateInvokeTxnFee(ctx context.Context, functionCalls []rpc.InvokeFunctionCall, multiplier float64) (rpc.ResourceBoundsMapping, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EstimateInvokeTxnFee", ctx, functionCalls, multiplier)
+	ret0, _ := ret[0].(rpc.ResourceBoundsMapping)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// EstimateInvokeTxnFee indicates an expected call of EstimateInvokeTxnFee.
+func (mr *MockSignerMockRecorder) EstimateInvokeTxnFee(ctx, functionCalls, multiplier any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EstimateInvokeTxnFee", reflect.TypeOf((*MockSigner)(nil).EstimateInvokeTxnFee), ctx, functionCalls, multiplier)
+}
+
 // GetTransactionStatus mocks base method.
 func (m *MockSigner) GetTransactionStatus(ctx context.Context, transactionHash *felt.Felt) (*rpc.TxnStatusResult, error) {
 	m.ctrl.T.Helper()
@@ -117,6 +132,22 @@ func (mr *MockSignerMockRecorder) GetTransactionStatus(ctx, transactionHash any)
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTransactionStatus", reflect.TypeOf((*MockSigner)(nil).GetTransactionStatus), ctx, transactionHash)
 }
 
+// ReplaceInvokeTxn mocks base method.
+func (m *MockSigner) ReplaceInvokeTxn(ctx context.Context, transactionHash *felt.Felt, bumpPercentage uint64, maxFee *felt.Felt) (*rpc.AddInvokeTransactionResponse, *felt.Felt, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReplaceInvokeTxn", ctx, transactionHash, bumpPercentage, maxFee)
+	ret0, _ := ret[0].(*rpc.AddInvokeTransactionResponse)
+	ret1, _ := ret[1].(*felt.Felt)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// ReplaceInvokeTxn indicates an expected call of ReplaceInvokeTxn.
+func (mr *MockSignerMockRecorder) ReplaceInvokeTxn(ctx, transactionHash, bumpPercentage, maxFee any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReplaceInvokeTxn", reflect.TypeOf((*MockSigner)(nil).ReplaceInvokeTxn), ctx, transactionHash, bumpPercentage, maxFee)
+}
+
 // ValidationContracts mocks base method.
 func (m *MockSigner) ValidationContracts() *signer.ValidationContracts {
 	m.ctrl.T.Helper()
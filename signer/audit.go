@@ -0,0 +1,245 @@
+package signer
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/NethermindEth/juno/core/felt"
+	"github.com/cockroachdb/errors"
+)
+
+// Decision taken by the signer on a request
+type AuditDecision string
+
+const (
+	AuditSigned         AuditDecision = "signed"
+	AuditRejected       AuditDecision = "rejected"
+	AuditUnauthorized   AuditDecision = "unauthorized"
+	AuditInvalidRequest AuditDecision = "invalid_request"
+	AuditFailed         AuditDecision = "failed"
+)
+
+// Previous hash of the first entry in the audit log
+var auditGenesisHash = strings.Repeat("0", 64)
+
+// How long unauthorized and invalid requests are counted before their summary is written.
+// Anyone reaching the signer can send them, so they never cost a write each
+const auditSummaryInterval = time.Minute
+
+// A request received by the signer and what was done with it. Fields that couldn't be
+// known, e.g. the transaction of an unauthorized request, are left empty
+type AuditEntry struct {
+	Time            time.Time     `json:"time"`
+	CallerAddress   string        `json:"callerAddress"`
+	ChainId         *felt.Felt    `json:"chainId,omitempty"`
+	SenderAddress   *felt.Felt    `json:"senderAddress,omitempty"`
+	Nonce           *felt.Felt    `json:"nonce,omitempty"`
+	Calls           []Call        `json:"calls,omitempty"`
+	TransactionHash *felt.Felt    `json:"transactionHash,omitempty"`
+	Decision        AuditDecision `json:"decision"`
+	Reason          string        `json:"reason,omitempty"`
+	Signature       []*felt.Felt  `json:"signature,omitempty"`
+	// Number of requests a summary entry stands for. Its time is the one of the first
+	// request, and its caller address and reason the ones of the last request
+	Count int `json:"count,omitempty"`
+	// Hash of the previous entry, and of this entry along with the previous hash.
+	// Changing, removing or reordering entries breaks the chain
+	PrevHash string `json:"prevHash"`
+	Hash     string `json:"hash"`
+}
+
+// Returns the hash of the entry, which covers every field but the hash itself
+func (e *AuditEntry) computeHash() (string, error) {
+	unhashed := *e
+	unhashed.Hash = ""
+	data, err := json.Marshal(&unhashed)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// Append only log with one JSON line per entry, each one chained to the previous by its hash
+type AuditLog struct {
+	mu       sync.Mutex
+	file     *os.File
+	lastHash string
+	// Summaries of the requests counted since the first of them, one per decision
+	summaries       []*AuditEntry
+	summaryInterval time.Duration
+}
+
+// Opens the audit log at `path`, creating it if it doesn't exist. The existing entries
+// are verified first, so new entries are never chained to a tampered log
+func OpenAuditLog(path string) (*AuditLog, error) {
+	lastHash := auditGenesisHash
+	if _, err := os.Stat(path); err == nil {
+		result, err := VerifyAuditLog(path)
+		if err != nil {
+			return nil, err
+		}
+		if result.LastHash != "" {
+			lastHash = result.LastHash
+		}
+	}
+
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, errors.Errorf("cannot open audit log: %w", err)
+	}
+	return &AuditLog{file: file, lastHash: lastHash, summaryInterval: auditSummaryInterval}, nil
+}
+
+// Chains the entry to the last one and writes it to disk before returning, along with
+// the pending summaries
+func (l *AuditLog) Append(entry *AuditEntry) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if err := l.writeSummaries(); err != nil {
+		return err
+	}
+	if err := l.write(entry); err != nil {
+		return err
+	}
+	return l.sync()
+}
+
+// Counts the entry in the summary of its decision instead of writing it. The summaries are
+// written once the first request they count is older than the summary interval, or along
+// with the next appended entry
+func (l *AuditLog) Summarize(entry *AuditEntry) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	receivedAt := entry.Time.UTC()
+	var summary *AuditEntry
+	for _, pending := range l.summaries {
+		if pending.Decision == entry.Decision {
+			summary = pending
+			break
+		}
+	}
+	if summary == nil {
+		summary = &AuditEntry{Time: receivedAt, Decision: entry.Decision}
+		l.summaries = append(l.summaries, summary)
+	}
+	summary.CallerAddress = entry.CallerAddress
+	summary.Reason = entry.Reason
+	summary.Count++
+
+	if receivedAt.Sub(l.summaries[0].Time) < l.summaryInterval {
+		return nil
+	}
+	if err := l.writeSummaries(); err != nil {
+		return err
+	}
+	return l.sync()
+}
+
+// Writes the pending summaries and closes the log
+func (l *AuditLog) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	err := l.writeSummaries()
+	if err == nil {
+		err = l.sync()
+	}
+	return errors.CombineErrors(err, l.file.Close())
+}
+
+// Writes the pending summaries without syncing them, dropping each one once written
+func (l *AuditLog) writeSummaries() error {
+	for len(l.summaries) > 0 {
+		if err := l.write(l.summaries[0]); err != nil {
+			return err
+		}
+		l.summaries = l.summaries[1:]
+	}
+	return nil
+}
+
+// Chains the entry to the last one and writes it without syncing the file
+func (l *AuditLog) write(entry *AuditEntry) error {
+	entry.Time = entry.Time.UTC()
+	entry.PrevHash = l.lastHash
+	hash, err := entry.computeHash()
+	if err != nil {
+		return err
+	}
+	entry.Hash = hash
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	if _, err := l.file.Write(append(line, '\n')); err != nil {
+		return errors.Errorf("cannot write audit log: %w", err)
+	}
+
+	l.lastHash = hash
+	return nil
+}
+
+func (l *AuditLog) sync() error {
+	if err := l.file.Sync(); err != nil {
+		return errors.Errorf("cannot write audit log: %w", err)
+	}
+	return nil
+}
+
+type AuditLogVerification struct {
+	Entries  int
+	LastHash string
+}
+
+// Checks every entry of the audit log is chained to the previous one and wasn't modified.
+// The error points to the first line that breaks the chain
+func VerifyAuditLog(path string) (AuditLogVerification, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return AuditLogVerification{}, errors.Errorf("cannot open audit log: %w", err)
+	}
+	defer func() { _ = file.Close() }()
+
+	var result AuditLogVerification
+	prevHash := auditGenesisHash
+	scanner := bufio.NewScanner(file)
+	// Entries with long calldata don't fit in the default buffer
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		var entry AuditEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return result, errors.Errorf("audit log line %d is not a valid entry: %w", line, err)
+		}
+		if entry.PrevHash != prevHash {
+			return result, errors.Errorf(
+				"audit log line %d is not chained to the previous entry,"+
+					" entries were removed or reordered", line,
+			)
+		}
+		hash, err := entry.computeHash()
+		if err != nil {
+			return result, err
+		}
+		if hash != entry.Hash {
+			return result, errors.Errorf("audit log line %d was modified", line)
+		}
+
+		prevHash = entry.Hash
+		result.Entries++
+		result.LastHash = entry.Hash
+	}
+	if err := scanner.Err(); err != nil {
+		return result, errors.Errorf("cannot read audit log: %w", err)
+	}
+	return result, nil
+}
@@ -0,0 +1,309 @@
+package signer
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/NethermindEth/juno/core/felt"
+	"github.com/NethermindEth/juno/utils"
+	snUtils "github.com/NethermindEth/starknet.go/utils"
+	"github.com/stretchr/testify/require"
+)
+
+func writeAuditLog(t *testing.T, path string, decisions ...AuditDecision) {
+	t.Helper()
+
+	auditLog, err := OpenAuditLog(path)
+	require.NoError(t, err)
+	for i, decision := range decisions {
+		require.NoError(t, auditLog.Append(&AuditEntry{
+			Time:          time.Now(),
+			CallerAddress: "127.0.0.1:1234",
+			Nonce:         new(felt.Felt).SetUint64(uint64(i)),
+			Decision:      decision,
+		}))
+	}
+	require.NoError(t, auditLog.Close())
+}
+
+func readAuditLines(t *testing.T, path string) []string {
+	t.Helper()
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	return strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+}
+
+func writeAuditLines(t *testing.T, path string, lines []string) {
+	t.Helper()
+
+	require.NoError(t, os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0o600))
+}
+
+func TestAuditLog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	writeAuditLog(t, path, AuditSigned, AuditRejected, AuditSigned)
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+	result, err := VerifyAuditLog(path)
+	require.NoError(t, err)
+	require.Equal(t, 3, result.Entries)
+
+	lines := readAuditLines(t, path)
+	require.Len(t, lines, 3)
+	var first AuditEntry
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &first))
+	require.Equal(t, auditGenesisHash, first.PrevHash)
+	require.Equal(t, AuditSigned, first.Decision)
+
+	// Reopening the log keeps chaining to the last entry
+	writeAuditLog(t, path, AuditUnauthorized)
+	reopened, err := VerifyAuditLog(path)
+	require.NoError(t, err)
+	require.Equal(t, 4, reopened.Entries)
+
+	var last AuditEntry
+	require.NoError(t, json.Unmarshal([]byte(readAuditLines(t, path)[3]), &last))
+	require.Equal(t, result.LastHash, last.PrevHash)
+	require.Equal(t, reopened.LastHash, last.Hash)
+
+	t.Run("Empty log", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "audit.log")
+		writeAuditLog(t, path)
+
+		result, err := VerifyAuditLog(path)
+		require.NoError(t, err)
+		require.Zero(t, result.Entries)
+	})
+}
+
+func TestAuditLogTampering(t *testing.T) {
+	original := filepath.Join(t.TempDir(), "audit.log")
+	writeAuditLog(t, original, AuditSigned, AuditRejected, AuditSigned)
+	lines := readAuditLines(t, original)
+
+	tests := []struct {
+		name        string
+		lines       []string
+		entries     int
+		expectedErr string
+	}{
+		{
+			name: "Modified entry",
+			lines: []string{
+				lines[0],
+				strings.Replace(lines[1], `"rejected"`, `"signed"`, 1),
+				lines[2],
+			},
+			entries:     1,
+			expectedErr: "audit log line 2 was modified",
+		},
+		{
+			name:    "Removed entry",
+			lines:   []string{lines[0], lines[2]},
+			entries: 1,
+			expectedErr: "audit log line 2 is not chained to the previous entry," +
+				" entries were removed or reordered",
+		},
+		{
+			name:    "Removed first entry",
+			lines:   lines[1:],
+			entries: 0,
+			expectedErr: "audit log line 1 is not chained to the previous entry," +
+				" entries were removed or reordered",
+		},
+		{
+			name:    "Reordered entries",
+			lines:   []string{lines[0], lines[2], lines[1]},
+			entries: 1,
+			expectedErr: "audit log line 2 is not chained to the previous entry," +
+				" entries were removed or reordered",
+		},
+		{
+			name:        "Malformed entry",
+			lines:       []string{lines[0], "not json"},
+			entries:     1,
+			expectedErr: "audit log line 2 is not a valid entry",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "audit.log")
+			writeAuditLines(t, path, test.lines)
+
+			result, err := VerifyAuditLog(path)
+			require.ErrorContains(t, err, test.expectedErr)
+			require.Equal(t, test.entries, result.Entries)
+
+			// New entries are never chained to a tampered log
+			auditLog, err := OpenAuditLog(path)
+			require.Nil(t, auditLog)
+			require.ErrorContains(t, err, test.expectedErr)
+		})
+	}
+}
+
+func TestHandlerWritesAuditLog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	auditLog, err := OpenAuditLog(path)
+	require.NoError(t, err)
+
+	attestContract := feltFromString(t, "0xa77e57")
+	auth, err := NewAuth(string(AuthBearer), "secret")
+	require.NoError(t, err)
+	signer, err := New(
+		memoryKeyBackend(t, "0x123"),
+		auth,
+		DefaultPolicy(attestContract),
+		auditLog,
+		utils.NewNopZapLogger(),
+	)
+	require.NoError(t, err)
+
+	sender := feltFromString(t, "0x456")
+	chainId := new(felt.Felt).SetBytes([]byte("SN_SEPOLIA"))
+	send := func(t *testing.T, txn *Request, authorized bool) *httptest.ResponseRecorder {
+		t.Helper()
+
+		body, err := json.Marshal(txn)
+		require.NoError(t, err)
+
+		req := httptest.NewRequest(http.MethodPost, SIGN_ENDPOINT, bytes.NewReader(body))
+		if authorized {
+			req.Header.Set("Authorization", "Bearer secret")
+		}
+		recorder := httptest.NewRecorder()
+		signer.handler(recorder, req)
+		return recorder
+	}
+
+	attest := &Request{InvokeTxnV3: invokeTxn(t, sender, attestCall(attestContract)), ChainId: chainId}
+	transfer := &Request{
+		InvokeTxnV3: invokeTxn(t, sender, transferCall(feltFromString(t, "0xbad"))),
+		ChainId:     chainId,
+	}
+	require.Equal(t, http.StatusOK, send(t, attest, true).Code)
+	require.Equal(t, http.StatusForbidden, send(t, transfer, true).Code)
+	require.Equal(t, http.StatusUnauthorized, send(t, attest, false).Code)
+
+	// The unauthorized request is only summarized, which is written when closing the log
+	result, err := VerifyAuditLog(path)
+	require.NoError(t, err)
+	require.Equal(t, 2, result.Entries)
+	require.NoError(t, auditLog.Close())
+	result, err = VerifyAuditLog(path)
+	require.NoError(t, err)
+	require.Equal(t, 3, result.Entries)
+
+	entries := make([]AuditEntry, 3)
+	for i, line := range readAuditLines(t, path) {
+		require.NoError(t, json.Unmarshal([]byte(line), &entries[i]))
+	}
+
+	signed := entries[0]
+	require.Equal(t, AuditSigned, signed.Decision)
+	require.NotEmpty(t, signed.CallerAddress)
+	require.Equal(t, chainId, signed.ChainId)
+	require.Equal(t, sender, signed.SenderAddress)
+	require.Equal(t, attest.Nonce, signed.Nonce)
+	require.Equal(t, []Call{{
+		Contract: attestContract,
+		Selector: snUtils.GetSelectorFromNameFelt(ATTEST_ENTRY_POINT),
+		Calldata: []*felt.Felt{new(felt.Felt).SetUint64(0xb10c)},
+	}}, signed.Calls)
+	require.NotNil(t, signed.TransactionHash)
+	require.Len(t, signed.Signature, 2)
+
+	rejected := entries[1]
+	require.Equal(t, AuditRejected, rejected.Decision)
+	require.Contains(t, rejected.Reason, RULE_ALLOWED_CALLS)
+	require.Nil(t, rejected.Signature)
+
+	unauthorized := entries[2]
+	require.Equal(t, AuditUnauthorized, unauthorized.Decision)
+	require.Equal(t, 1, unauthorized.Count)
+	require.Nil(t, unauthorized.SenderAddress)
+	require.Nil(t, unauthorized.Signature)
+}
+
+func TestAuditLogSummaries(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	auditLog, err := OpenAuditLog(path)
+	require.NoError(t, err)
+
+	start := time.Now()
+	summarize := func(t *testing.T, decision AuditDecision, after time.Duration, caller string) {
+		t.Helper()
+
+		require.NoError(t, auditLog.Summarize(&AuditEntry{
+			Time:          start.Add(after),
+			CallerAddress: caller,
+			Decision:      decision,
+			Reason:        "reason from " + caller,
+		}))
+	}
+	readEntries := func(t *testing.T) []AuditEntry {
+		t.Helper()
+
+		result, err := VerifyAuditLog(path)
+		require.NoError(t, err)
+		if result.Entries == 0 {
+			return nil
+		}
+		lines := readAuditLines(t, path)
+		entries := make([]AuditEntry, len(lines))
+		for i, line := range lines {
+			require.NoError(t, json.Unmarshal([]byte(line), &entries[i]))
+		}
+		return entries
+	}
+
+	// Requests within the interval are only counted
+	summarize(t, AuditUnauthorized, 0, "10.0.0.1:1")
+	summarize(t, AuditUnauthorized, time.Second, "10.0.0.2:1")
+	summarize(t, AuditInvalidRequest, 2*time.Second, "10.0.0.3:1")
+	summarize(t, AuditUnauthorized, 3*time.Second, "10.0.0.4:1")
+	require.Empty(t, readEntries(t))
+
+	// Appending an entry writes the summaries before it
+	require.NoError(t, auditLog.Append(&AuditEntry{Time: start, Decision: AuditSigned}))
+	entries := readEntries(t)
+	require.Len(t, entries, 3)
+	require.Equal(t, AuditUnauthorized, entries[0].Decision)
+	require.Equal(t, 3, entries[0].Count)
+	require.True(t, start.Equal(entries[0].Time))
+	require.Equal(t, "10.0.0.4:1", entries[0].CallerAddress)
+	require.Equal(t, "reason from 10.0.0.4:1", entries[0].Reason)
+	require.Equal(t, AuditInvalidRequest, entries[1].Decision)
+	require.Equal(t, 1, entries[1].Count)
+	require.Equal(t, AuditSigned, entries[2].Decision)
+	require.Zero(t, entries[2].Count)
+
+	// Summaries are written once the first request they count is older than the interval
+	summarize(t, AuditUnauthorized, time.Hour, "10.0.0.5:1")
+	summarize(t, AuditUnauthorized, time.Hour+auditSummaryInterval-time.Second, "10.0.0.6:1")
+	require.Len(t, readEntries(t), 3)
+	summarize(t, AuditUnauthorized, time.Hour+auditSummaryInterval, "10.0.0.7:1")
+	entries = readEntries(t)
+	require.Len(t, entries, 4)
+	require.Equal(t, 3, entries[3].Count)
+
+	// Closing the log writes the pending summaries
+	summarize(t, AuditInvalidRequest, 2*time.Hour, "10.0.0.8:1")
+	require.NoError(t, auditLog.Close())
+	entries = readEntries(t)
+	require.Len(t, entries, 5)
+	require.Equal(t, AuditInvalidRequest, entries[4].Decision)
+	require.Equal(t, 1, entries[4].Count)
+}
@@ -0,0 +1,155 @@
+package signer
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cockroachdb/errors"
+)
+
+const (
+	TIMESTAMP_HEADER = "X-Signer-Timestamp"
+	SIGNATURE_HEADER = "X-Signer-Signature"
+)
+
+// How far the timestamp of an HMAC authenticated request can be from the signer's clock
+const MAX_REQUEST_CLOCK_SKEW = 30 * time.Second
+
+type AuthScheme string
+
+const (
+	// No authentication, only meant for local setups
+	AuthNone AuthScheme = ""
+	// The shared secret is sent as a bearer token in the `Authorization` header
+	AuthBearer AuthScheme = "bearer"
+	// The request body is signed along with a timestamp, the method and the path using
+	// HMAC-SHA256 and the shared secret, which is never sent. A request can't be replayed,
+	// nor sent to another endpoint
+	AuthHMAC AuthScheme = "hmac"
+)
+
+func AuthSchemeFromString(s string) (AuthScheme, error) {
+	switch scheme := AuthScheme(strings.ToLower(strings.TrimSpace(s))); scheme {
+	case AuthNone, AuthBearer, AuthHMAC:
+		return scheme, nil
+	default:
+		return AuthNone, errors.Errorf(
+			"unknown auth scheme `%s`, expected either `%s` or `%s`", s, AuthBearer, AuthHMAC,
+		)
+	}
+}
+
+// Shared secret authentication between the validator and the remote signer
+type Auth struct {
+	Scheme AuthScheme
+	Secret string
+}
+
+func NewAuth(scheme string, secret string) (Auth, error) {
+	authScheme, err := AuthSchemeFromString(scheme)
+	if err != nil {
+		return Auth{}, err
+	}
+	if authScheme != AuthNone && secret == "" {
+		return Auth{}, errors.Errorf("auth scheme `%s` requires a secret", authScheme)
+	}
+	return Auth{Scheme: authScheme, Secret: secret}, nil
+}
+
+// Adds the credentials to a request with the given body
+func (a *Auth) Authenticate(req *http.Request, body []byte, now time.Time) {
+	switch a.Scheme {
+	case AuthBearer:
+		req.Header.Set("Authorization", "Bearer "+a.Secret)
+	case AuthHMAC:
+		timestamp := strconv.FormatInt(now.Unix(), 10)
+		req.Header.Set(TIMESTAMP_HEADER, timestamp)
+		req.Header.Set(SIGNATURE_HEADER, hmacSignature(a.Secret, timestamp, req, body))
+	}
+}
+
+// Signature of the timestamp, the method, the escaped path and the body of the request,
+// separated by new lines, which none of the first three can contain
+func hmacSignature(secret string, timestamp string, req *http.Request, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "\n" + req.Method + "\n" + req.URL.EscapedPath() + "\n"))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Checks the credentials of the requests received by the signer. HMAC signatures are
+// remembered while their timestamp is valid, so the same request is only accepted once
+type authVerifier struct {
+	auth Auth
+	mu   sync.Mutex
+	// Signature of the accepted requests and when they stop being valid
+	seen map[string]time.Time
+}
+
+func newAuthVerifier(auth Auth) *authVerifier {
+	return &authVerifier{auth: auth, seen: make(map[string]time.Time)}
+}
+
+func (v *authVerifier) verify(req *http.Request, body []byte, now time.Time) error {
+	switch v.auth.Scheme {
+	case AuthNone:
+		return nil
+	case AuthBearer:
+		token, found := strings.CutPrefix(req.Header.Get("Authorization"), "Bearer ")
+		if !found {
+			return errors.New("missing bearer token")
+		}
+		if subtle.ConstantTimeCompare([]byte(token), []byte(v.auth.Secret)) != 1 {
+			return errors.New("invalid bearer token")
+		}
+		return nil
+	case AuthHMAC:
+		return v.verifyHMAC(req, body, now)
+	default:
+		return errors.Errorf("unknown auth scheme `%s`", v.auth.Scheme)
+	}
+}
+
+func (v *authVerifier) verifyHMAC(req *http.Request, body []byte, now time.Time) error {
+	timestampStr := req.Header.Get(TIMESTAMP_HEADER)
+	signature := req.Header.Get(SIGNATURE_HEADER)
+	if timestampStr == "" || signature == "" {
+		return errors.Errorf("missing %s or %s header", TIMESTAMP_HEADER, SIGNATURE_HEADER)
+	}
+
+	timestamp, err := strconv.ParseInt(timestampStr, 10, 64)
+	if err != nil {
+		return errors.Errorf("invalid timestamp `%s`", timestampStr)
+	}
+	requestTime := time.Unix(timestamp, 0)
+	if requestTime.Before(now.Add(-MAX_REQUEST_CLOCK_SKEW)) ||
+		requestTime.After(now.Add(MAX_REQUEST_CLOCK_SKEW)) {
+		return errors.Errorf("timestamp %d is too far from the signer's clock", timestamp)
+	}
+
+	expected := hmacSignature(v.auth.Secret, timestampStr, req, body)
+	if !hmac.Equal([]byte(signature), []byte(expected)) {
+		return errors.New("invalid signature")
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	for seenSignature, expiry := range v.seen {
+		if now.After(expiry) {
+			delete(v.seen, seenSignature)
+		}
+	}
+	if _, replayed := v.seen[signature]; replayed {
+		return errors.New("request was already received")
+	}
+	v.seen[signature] = requestTime.Add(MAX_REQUEST_CLOCK_SKEW)
+	return nil
+}
@@ -0,0 +1,150 @@
+package signer
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/NethermindEth/juno/utils"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewAuth(t *testing.T) {
+	auth, err := NewAuth("", "")
+	require.NoError(t, err)
+	require.Equal(t, Auth{}, auth)
+
+	auth, err = NewAuth("HMAC", "secret")
+	require.NoError(t, err)
+	require.Equal(t, Auth{Scheme: AuthHMAC, Secret: "secret"}, auth)
+
+	auth, err = NewAuth("bearer", "")
+	require.Zero(t, auth)
+	require.EqualError(t, err, "auth scheme `bearer` requires a secret")
+
+	auth, err = NewAuth("basic", "secret")
+	require.Zero(t, auth)
+	require.EqualError(t, err, "unknown auth scheme `basic`, expected either `bearer` or `hmac`")
+}
+
+func TestAuthVerify(t *testing.T) {
+	body := []byte(`{"transaction": {}}`)
+	now := time.Now()
+
+	newRequest := func(t *testing.T, auth Auth, body []byte, at time.Time) *http.Request {
+		t.Helper()
+
+		req := httptest.NewRequest(http.MethodPost, SIGN_V1_ENDPOINT, bytes.NewReader(body))
+		auth.Authenticate(req, body, at)
+		return req
+	}
+
+	t.Run("Bearer token", func(t *testing.T) {
+		auth := Auth{Scheme: AuthBearer, Secret: "secret"}
+		verifier := newAuthVerifier(auth)
+
+		require.NoError(t, verifier.verify(newRequest(t, auth, body, now), body, now))
+
+		wrongAuth := Auth{Scheme: AuthBearer, Secret: "other secret"}
+		err := verifier.verify(newRequest(t, wrongAuth, body, now), body, now)
+		require.EqualError(t, err, "invalid bearer token")
+
+		err = verifier.verify(newRequest(t, Auth{}, body, now), body, now)
+		require.EqualError(t, err, "missing bearer token")
+	})
+
+	t.Run("HMAC signature", func(t *testing.T) {
+		auth := Auth{Scheme: AuthHMAC, Secret: "secret"}
+		verifier := newAuthVerifier(auth)
+
+		require.NoError(t, verifier.verify(newRequest(t, auth, body, now), body, now))
+	})
+
+	t.Run("HMAC signature of a different body", func(t *testing.T) {
+		auth := Auth{Scheme: AuthHMAC, Secret: "secret"}
+		verifier := newAuthVerifier(auth)
+
+		req := newRequest(t, auth, []byte(`{"transaction": {"nonce": "0x1"}}`), now)
+		require.EqualError(t, verifier.verify(req, body, now), "invalid signature")
+	})
+
+	t.Run("HMAC signature for another endpoint", func(t *testing.T) {
+		auth := Auth{Scheme: AuthHMAC, Secret: "secret"}
+		verifier := newAuthVerifier(auth)
+
+		// Signed for the v1 endpoint but sent to the v2 one, or with another method
+		req := newRequest(t, auth, body, now)
+		req.URL.Path = SIGN_V2_ENDPOINT
+		require.EqualError(t, verifier.verify(req, body, now), "invalid signature")
+
+		req = newRequest(t, auth, body, now)
+		req.Method = http.MethodPut
+		require.EqualError(t, verifier.verify(req, body, now), "invalid signature")
+	})
+
+	t.Run("HMAC signature with a different secret", func(t *testing.T) {
+		verifier := newAuthVerifier(Auth{Scheme: AuthHMAC, Secret: "secret"})
+
+		req := newRequest(t, Auth{Scheme: AuthHMAC, Secret: "other secret"}, body, now)
+		require.EqualError(t, verifier.verify(req, body, now), "invalid signature")
+	})
+
+	t.Run("HMAC signature missing", func(t *testing.T) {
+		verifier := newAuthVerifier(Auth{Scheme: AuthHMAC, Secret: "secret"})
+
+		req := newRequest(t, Auth{}, body, now)
+		require.ErrorContains(t, verifier.verify(req, body, now), "missing")
+	})
+
+	t.Run("HMAC signature is too old or from the future", func(t *testing.T) {
+		auth := Auth{Scheme: AuthHMAC, Secret: "secret"}
+		verifier := newAuthVerifier(auth)
+
+		for _, at := range []time.Time{
+			now.Add(-MAX_REQUEST_CLOCK_SKEW - time.Second),
+			now.Add(MAX_REQUEST_CLOCK_SKEW + time.Second),
+		} {
+			err := verifier.verify(newRequest(t, auth, body, at), body, now)
+			require.EqualError(
+				t,
+				err,
+				"timestamp "+strconv.FormatInt(at.Unix(), 10)+" is too far from the signer's clock",
+			)
+		}
+	})
+
+	t.Run("HMAC signed request can't be replayed", func(t *testing.T) {
+		auth := Auth{Scheme: AuthHMAC, Secret: "secret"}
+		verifier := newAuthVerifier(auth)
+
+		req := newRequest(t, auth, body, now)
+		require.NoError(t, verifier.verify(req, body, now))
+		require.EqualError(
+			t, verifier.verify(req, body, now.Add(time.Second)), "request was already received",
+		)
+
+		// Once the timestamp is no longer valid, the request is rejected because of it
+		later := now.Add(2*MAX_REQUEST_CLOCK_SKEW + time.Second)
+		require.ErrorContains(t, verifier.verify(req, body, later), "too far from the signer's clock")
+	})
+}
+
+func TestHandlerRejectsUnauthorizedRequests(t *testing.T) {
+	auth := Auth{Scheme: AuthHMAC, Secret: "secret"}
+	signer, err := New(memoryKeyBackend(t, "0x123"), auth, Policy{}, nil, utils.NewNopZapLogger())
+	require.NoError(t, err)
+
+	body := []byte(`{"transaction": {}, "chain_id": "0x1"}`)
+	req := httptest.NewRequest(http.MethodPost, SIGN_ENDPOINT, bytes.NewReader(body))
+	wrongAuth := Auth{Scheme: AuthHMAC, Secret: "wrong secret"}
+	wrongAuth.Authenticate(req, body, time.Now())
+
+	recorder := httptest.NewRecorder()
+	signer.handler(recorder, req)
+
+	require.Equal(t, http.StatusUnauthorized, recorder.Code)
+	require.Contains(t, recorder.Body.String(), "Unauthorized: invalid signature")
+}
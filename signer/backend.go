@@ -0,0 +1,199 @@
+package signer
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/NethermindEth/juno/core/felt"
+	"github.com/NethermindEth/starknet.go/account"
+	"github.com/NethermindEth/starknet.go/curve"
+	"github.com/cockroachdb/errors"
+)
+
+// Names of the key backends the signer can use
+const (
+	KEY_BACKEND_MEMORY   = "memory"
+	KEY_BACKEND_KEYSTORE = "keystore"
+	KEY_BACKEND_TRANSIT  = "transit"
+)
+
+// Holds the private key the signer signs with, so it can live outside of the process
+type KeyBackend interface {
+	// Returns the signature of `hash`, the ECDSA `r` and `s` values unless the key is held
+	// for an account validating signatures differently
+	SignHash(ctx context.Context, hash *felt.Felt) ([]*felt.Felt, error)
+	// Returns the public key of the private key
+	PublicKey(ctx context.Context) (*felt.Felt, error)
+}
+
+var (
+	_ KeyBackend = (*MemoryKeyBackend)(nil)
+	_ KeyBackend = (*TransitKeyBackend)(nil)
+)
+
+// Keeps the private key in the process memory
+type MemoryKeyBackend struct {
+	keyStore  *account.MemKeystore
+	publicKey *big.Int
+}
+
+func NewMemoryKeyBackend(privateKey string) (*MemoryKeyBackend, error) {
+	privKey, ok := new(big.Int).SetString(privateKey, 0)
+	if !ok {
+		return nil, errors.Errorf("Cannot turn private key %s into a big int", privateKey)
+	}
+
+	publicKey, _, err := curve.Curve.PrivateToPoint(privKey)
+	if err != nil {
+		return nil, errors.New("Cannot derive public key from private key")
+	}
+
+	return &MemoryKeyBackend{
+		keyStore:  account.SetNewMemKeystore(publicKey.String(), privKey),
+		publicKey: publicKey,
+	}, nil
+}
+
+// Decrypts the keystore at `path` and keeps its private key in memory
+func NewKeystoreKeyBackend(path string, password string) (*MemoryKeyBackend, error) {
+	privateKey, err := DecryptKeystoreFile(path, password)
+	if err != nil {
+		return nil, err
+	}
+	return NewMemoryKeyBackend(privateKey)
+}
+
+func (b *MemoryKeyBackend) SignHash(ctx context.Context, hash *felt.Felt) ([]*felt.Felt, error) {
+	r, s, err := b.keyStore.Sign(ctx, b.publicKey.String(), hash.BigInt(new(big.Int)))
+	if err != nil {
+		return nil, err
+	}
+	return []*felt.Felt{new(felt.Felt).SetBigInt(r), new(felt.Felt).SetBigInt(s)}, nil
+}
+
+func (b *MemoryKeyBackend) PublicKey(context.Context) (*felt.Felt, error) {
+	return new(felt.Felt).SetBigInt(b.publicKey), nil
+}
+
+// Time limit of each request to the transit service
+const transitRequestTimeout = 10 * time.Second
+
+// Key held by a remote key management service, modelled after the transit engine of
+// secret stores: the private key never leaves the service, which signs the hashes it's sent.
+// The service must answer:
+//   - `GET <url>/v1/transit/keys/<key name>` with `{"data": {"public_key": "0x..."}}`
+//   - `POST <url>/v1/transit/sign/<key name>`, with body `{"hash": "0x..."}`, with
+//     `{"data": {"signature": ["0x<r>", "0x<s>"]}}`, or any other number of values for
+//     accounts validating signatures differently
+//
+// Requests carry the token as a bearer token
+type TransitKeyBackend struct {
+	url        string
+	keyName    string
+	token      string
+	httpClient *http.Client
+}
+
+type transitPublicKeyResponse struct {
+	Data struct {
+		PublicKey *felt.Felt `json:"public_key"`
+	} `json:"data"`
+}
+
+type transitSignRequest struct {
+	Hash *felt.Felt `json:"hash"`
+}
+
+type transitSignResponse struct {
+	Data struct {
+		Signature []*felt.Felt `json:"signature"`
+	} `json:"data"`
+}
+
+func NewTransitKeyBackend(serviceUrl string, keyName string, token string) (*TransitKeyBackend, error) {
+	if serviceUrl == "" {
+		return nil, errors.New("the transit service url is not set")
+	}
+	if keyName == "" {
+		return nil, errors.New("the transit key name is not set")
+	}
+	return &TransitKeyBackend{
+		url:        strings.TrimSuffix(serviceUrl, "/"),
+		keyName:    keyName,
+		token:      token,
+		httpClient: &http.Client{Timeout: transitRequestTimeout},
+	}, nil
+}
+
+func (b *TransitKeyBackend) SignHash(ctx context.Context, hash *felt.Felt) ([]*felt.Felt, error) {
+	body, err := json.Marshal(transitSignRequest{Hash: hash})
+	if err != nil {
+		return nil, err
+	}
+
+	var resp transitSignResponse
+	if err := b.do(ctx, http.MethodPost, "/v1/transit/sign/", body, &resp); err != nil {
+		return nil, errors.Errorf("cannot sign with the transit service: %w", err)
+	}
+	signature := resp.Data.Signature
+	if len(signature) == 0 {
+		return nil, errors.New("transit service answered without a signature")
+	}
+	if slices.Contains(signature, nil) {
+		return nil, errors.New("transit service answered with an empty signature value")
+	}
+	return signature, nil
+}
+
+func (b *TransitKeyBackend) PublicKey(ctx context.Context) (*felt.Felt, error) {
+	var resp transitPublicKeyResponse
+	if err := b.do(ctx, http.MethodGet, "/v1/transit/keys/", nil, &resp); err != nil {
+		return nil, errors.Errorf("cannot get the public key from the transit service: %w", err)
+	}
+	if resp.Data.PublicKey == nil {
+		return nil, errors.New("transit service answered without a public key")
+	}
+	return resp.Data.PublicKey, nil
+}
+
+// Sends a request about the backend key and decodes the successful response into `result`
+func (b *TransitKeyBackend) do(
+	ctx context.Context, method string, path string, body []byte, result any,
+) error {
+	req, err := http.NewRequestWithContext(
+		ctx, method, b.url+path+url.PathEscape(b.keyName), bytes.NewReader(body),
+	)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if b.token != "" {
+		req.Header.Set("Authorization", "Bearer "+b.token)
+	}
+
+	resp, err := b.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("error %d: %s", resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+	return json.Unmarshal(respBody, result)
+}
@@ -0,0 +1,212 @@
+package signer
+
+import (
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/NethermindEth/juno/core/felt"
+	"github.com/NethermindEth/starknet.go/curve"
+	"github.com/stretchr/testify/require"
+)
+
+func memoryKeyBackend(t *testing.T, privateKey string) *MemoryKeyBackend {
+	t.Helper()
+
+	keys, err := NewMemoryKeyBackend(privateKey)
+	require.NoError(t, err)
+	return keys
+}
+
+// Checks the backend signs with the key whose public key it reports
+func requireSignsWithItsKey(t *testing.T, keys KeyBackend) {
+	t.Helper()
+
+	publicKey, err := keys.PublicKey(t.Context())
+	require.NoError(t, err)
+
+	hash := feltFromString(t, "0xabcdef")
+	signature, err := keys.SignHash(t.Context(), hash)
+	require.NoError(t, err)
+
+	publicKeyY := curve.Curve.GetYCoordinate(publicKey.BigInt(new(big.Int)))
+	require.True(t, curve.Curve.Verify(
+		hash.BigInt(new(big.Int)),
+		signature[0].BigInt(new(big.Int)),
+		signature[1].BigInt(new(big.Int)),
+		publicKey.BigInt(new(big.Int)),
+		publicKeyY,
+	))
+}
+
+func TestMemoryKeyBackend(t *testing.T) {
+	keys := memoryKeyBackend(t, "0x123")
+
+	publicKey, err := keys.PublicKey(t.Context())
+	require.NoError(t, err)
+	require.Equal(
+		t,
+		feltFromString(t, "0x566d69d8c99f62bc71118399bab25c1f03719463eab8d6a444cd11ece131616"),
+		publicKey,
+	)
+	requireSignsWithItsKey(t, keys)
+
+	_, err = NewMemoryKeyBackend("not a key")
+	require.EqualError(t, err, "Cannot turn private key not a key into a big int")
+}
+
+func TestKeystoreKeyBackend(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keystore.json")
+	keystore, err := EncryptKey(big.NewInt(0x123), "password", KDF_SCRYPT, lightScryptParams)
+	require.NoError(t, err)
+	require.NoError(t, keystore.Save(path))
+
+	keys, err := NewKeystoreKeyBackend(path, "password")
+	require.NoError(t, err)
+	publicKey, err := keys.PublicKey(t.Context())
+	require.NoError(t, err)
+	require.Equal(t, keystore.PublicKey, publicKey.String())
+	requireSignsWithItsKey(t, keys)
+
+	_, err = NewKeystoreKeyBackend(path, "wrong password")
+	require.ErrorIs(t, err, ErrWrongPassword)
+}
+
+// Stands in for a transit service holding the key of `keys` under `keyName`
+func transitStandIn(
+	t *testing.T, keys KeyBackend, keyName string, token string,
+) *httptest.Server {
+	t.Helper()
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer "+token {
+			http.Error(w, "permission denied", http.StatusForbidden)
+			return
+		}
+
+		var resp any
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/transit/keys/"+keyName:
+			publicKey, err := keys.PublicKey(r.Context())
+			require.NoError(t, err)
+			var keyResp transitPublicKeyResponse
+			keyResp.Data.PublicKey = publicKey
+			resp = keyResp
+		case r.Method == http.MethodPost && r.URL.Path == "/v1/transit/sign/"+keyName:
+			var req transitSignRequest
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			signature, err := keys.SignHash(r.Context(), req.Hash)
+			require.NoError(t, err)
+			var signResp transitSignResponse
+			signResp.Data.Signature = signature
+			resp = signResp
+		default:
+			http.Error(w, "no handler for route", http.StatusNotFound)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		require.NoError(t, json.NewEncoder(w).Encode(resp))
+	}))
+}
+
+func TestTransitKeyBackend(t *testing.T) {
+	standIn := transitStandIn(t, memoryKeyBackend(t, "0x123"), "validator", "token")
+	defer standIn.Close()
+
+	t.Run("Sign with the remote key", func(t *testing.T) {
+		keys, err := NewTransitKeyBackend(standIn.URL+"/", "validator", "token")
+		require.NoError(t, err)
+
+		publicKey, err := keys.PublicKey(t.Context())
+		require.NoError(t, err)
+		require.Equal(t, memoryKeyBackend(t, "0x123").publicKey, publicKey.BigInt(new(big.Int)))
+		requireSignsWithItsKey(t, keys)
+	})
+
+	t.Run("Errors from the service", func(t *testing.T) {
+		keys, err := NewTransitKeyBackend(standIn.URL, "validator", "wrong token")
+		require.NoError(t, err)
+		_, err = keys.PublicKey(t.Context())
+		require.EqualError(
+			t,
+			err,
+			"cannot get the public key from the transit service: error 403: permission denied",
+		)
+
+		keys, err = NewTransitKeyBackend(standIn.URL, "other", "token")
+		require.NoError(t, err)
+		_, err = keys.SignHash(t.Context(), new(felt.Felt).SetUint64(1))
+		require.EqualError(
+			t, err, "cannot sign with the transit service: error 404: no handler for route",
+		)
+	})
+
+	t.Run("Signature response", func(t *testing.T) {
+		tests := []struct {
+			name        string
+			response    string
+			signature   []*felt.Felt
+			expectedErr string
+		}{
+			{
+				name:     "Signature of other length",
+				response: `{"data": {"signature": ["0x1", "0x2", "0x3"]}}`,
+				signature: []*felt.Felt{
+					new(felt.Felt).SetUint64(1),
+					new(felt.Felt).SetUint64(2),
+					new(felt.Felt).SetUint64(3),
+				},
+			},
+			{
+				name:        "No signature",
+				response:    `{"data": {"signature": []}}`,
+				expectedErr: "transit service answered without a signature",
+			},
+			{
+				name:        "Empty value",
+				response:    `{"data": {"signature": ["0x1", null]}}`,
+				expectedErr: "transit service answered with an empty signature value",
+			},
+		}
+
+		for _, test := range tests {
+			t.Run(test.name, func(t *testing.T) {
+				server := httptest.NewServer(
+					http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+						_, err := w.Write([]byte(test.response))
+						require.NoError(t, err)
+					}),
+				)
+				defer server.Close()
+
+				keys, err := NewTransitKeyBackend(server.URL, "validator", "")
+				require.NoError(t, err)
+				signature, err := keys.SignHash(t.Context(), new(felt.Felt).SetUint64(1))
+				if test.expectedErr != "" {
+					require.EqualError(t, err, test.expectedErr)
+					return
+				}
+				require.NoError(t, err)
+				require.Equal(t, test.signature, signature)
+			})
+		}
+	})
+
+	t.Run("Missing configuration", func(t *testing.T) {
+		_, err := NewTransitKeyBackend("", "validator", "token")
+		require.EqualError(t, err, "the transit service url is not set")
+		_, err = NewTransitKeyBackend(standIn.URL, "", "token")
+		require.EqualError(t, err, "the transit key name is not set")
+	})
+
+	t.Run("Key names are escaped", func(t *testing.T) {
+		keys, err := NewTransitKeyBackend(standIn.URL, "../sign/validator", "token")
+		require.NoError(t, err)
+		_, err = keys.PublicKey(t.Context())
+		require.ErrorContains(t, err, "error 404")
+	})
+}
@@ -0,0 +1,213 @@
+package signer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/NethermindEth/juno/core/felt"
+	"github.com/cockroachdb/errors"
+)
+
+// Env vars read by default for the private key of the memory backend and the token of the
+// transit backend
+const (
+	DEFAULT_PRIVATE_KEY_ENV   = "SIGNER_PRIVATE_KEY"
+	DEFAULT_TRANSIT_TOKEN_ENV = "SIGNER_TRANSIT_TOKEN"
+)
+
+// Where a private key is held and how to reach it. Secrets are never part of it,
+// they are read from env vars or files
+type KeyConfig struct {
+	// One of the `KEY_BACKEND_*` names
+	Backend string `json:"backend"`
+	// Env var with the private key of the memory backend
+	PrivateKeyEnv string `json:"privateKeyEnv,omitempty"`
+	// Keystore of the keystore backend and the file with its password, which is prompted
+	// when not set
+	Keystore     string `json:"keystore,omitempty"`
+	PasswordFile string `json:"passwordFile,omitempty"`
+	// Service and key name of the transit backend, and the env var with its token
+	TransitURL      string `json:"transitUrl,omitempty"`
+	TransitKey      string `json:"transitKey,omitempty"`
+	TransitTokenEnv string `json:"transitTokenEnv,omitempty"`
+}
+
+// Creates the backend holding the key, reading its secrets
+func (c *KeyConfig) NewBackend() (KeyBackend, error) {
+	switch c.Backend {
+	case KEY_BACKEND_MEMORY:
+		privateKeyEnv := c.PrivateKeyEnv
+		if privateKeyEnv == "" {
+			privateKeyEnv = DEFAULT_PRIVATE_KEY_ENV
+		}
+		privateKey := os.Getenv(privateKeyEnv)
+		if privateKey == "" {
+			return nil, errors.Errorf(
+				"couldn't read %s env var. Please make sure it is set before running this program",
+				privateKeyEnv,
+			)
+		}
+		return NewMemoryKeyBackend(privateKey)
+	case KEY_BACKEND_KEYSTORE:
+		if c.Keystore == "" {
+			return nil, errors.New("the keystore key backend requires a keystore")
+		}
+		password, err := ReadPassword(
+			c.PasswordFile, fmt.Sprintf("Password of keystore %s: ", c.Keystore),
+		)
+		if err != nil {
+			return nil, err
+		}
+		return NewKeystoreKeyBackend(c.Keystore, password)
+	case KEY_BACKEND_TRANSIT:
+		tokenEnv := c.TransitTokenEnv
+		if tokenEnv == "" {
+			tokenEnv = DEFAULT_TRANSIT_TOKEN_ENV
+		}
+		return NewTransitKeyBackend(c.TransitURL, c.TransitKey, os.Getenv(tokenEnv))
+	default:
+		return nil, errors.Errorf(
+			"unknown key backend `%s`, expected one of `%s`, `%s` or `%s`",
+			c.Backend,
+			KEY_BACKEND_MEMORY,
+			KEY_BACKEND_KEYSTORE,
+			KEY_BACKEND_TRANSIT,
+		)
+	}
+}
+
+// Key of an account the signer signs for, as written in the keys file
+type AccountKeyConfig struct {
+	Address *felt.Felt `json:"address"`
+	KeyConfig
+}
+
+// Reads the keys file, a JSON array with the address of each account and where its
+// key is held
+func AccountKeysFromFile(path string) ([]AccountKeyConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Errorf("cannot read keys file: %w", err)
+	}
+
+	var accounts []AccountKeyConfig
+	if err := json.Unmarshal(data, &accounts); err != nil {
+		return nil, errors.Errorf("invalid keys file: %w", err)
+	}
+	if len(accounts) == 0 {
+		return nil, errors.New("the keys file has no keys")
+	}
+	for i := range accounts {
+		if accounts[i].Address == nil {
+			return nil, errors.Errorf("key %d of the keys file has no address", i+1)
+		}
+	}
+	return accounts, nil
+}
+
+// Key the signer signs the transactions of an account with
+type AccountKey struct {
+	Address *felt.Felt
+	Keys    KeyBackend
+}
+
+type loadedKey struct {
+	keys      KeyBackend
+	publicKey *felt.Felt
+}
+
+// Keys of a signer. With a default key it signs for every sender, otherwise only
+// for the accounts it has a key of
+type keySet struct {
+	defaultKey *loadedKey
+	accounts   map[felt.Felt]*loadedKey
+}
+
+func loadKey(keys KeyBackend) (*loadedKey, error) {
+	publicKey, err := keys.PublicKey(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	return &loadedKey{keys: keys, publicKey: publicKey}, nil
+}
+
+func newAccountKeySet(accountKeys []AccountKey) (keySet, error) {
+	if len(accountKeys) == 0 {
+		return keySet{}, errors.New("no account key given to the signer")
+	}
+
+	accounts := make(map[felt.Felt]*loadedKey, len(accountKeys))
+	for i := range accountKeys {
+		address := accountKeys[i].Address
+		if _, ok := accounts[*address]; ok {
+			return keySet{}, errors.Errorf("more than one key for account %s", address)
+		}
+		key, err := loadKey(accountKeys[i].Keys)
+		if err != nil {
+			return keySet{}, errors.Errorf("cannot load the key of account %s: %w", address, err)
+		}
+		accounts[*address] = key
+	}
+	return keySet{accounts: accounts}, nil
+}
+
+// Returns the key signing the transactions of `sender`, nil if there is none
+func (k *keySet) forSender(sender *felt.Felt) *loadedKey {
+	if k.defaultKey != nil {
+		return k.defaultKey
+	}
+	if sender == nil {
+		return nil
+	}
+	return k.accounts[*sender]
+}
+
+// Checks every key backend is reachable and still holds the key loaded at startup
+func (k *keySet) check(ctx context.Context) error {
+	if k.defaultKey != nil {
+		return k.defaultKey.check(ctx)
+	}
+	for address, key := range k.accounts {
+		if err := key.check(ctx); err != nil {
+			return errors.Errorf("key of account %s: %w", &address, err)
+		}
+	}
+	return nil
+}
+
+func (k *loadedKey) check(ctx context.Context) error {
+	publicKey, err := k.keys.PublicKey(ctx)
+	if err != nil {
+		return errors.Errorf("key backend unavailable: %w", err)
+	}
+	if !publicKey.Equal(k.publicKey) {
+		return errors.Errorf(
+			"key backend holds public key %s instead of %s", publicKey, k.publicKey,
+		)
+	}
+	return nil
+}
+
+// Information about a key of the signer that can be shared
+type KeyInfo struct {
+	// Account the key signs for, nil when it signs for every sender
+	Address   *felt.Felt `json:"address"`
+	PublicKey *felt.Felt `json:"publicKey"`
+}
+
+// Returns the keys sorted by account address
+func (k *keySet) info() []KeyInfo {
+	if k.defaultKey != nil {
+		return []KeyInfo{{PublicKey: k.defaultKey.publicKey}}
+	}
+
+	keys := make([]KeyInfo, 0, len(k.accounts))
+	for address, key := range k.accounts {
+		keys = append(keys, KeyInfo{Address: &address, PublicKey: key.publicKey})
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].Address.Cmp(keys[j].Address) < 0 })
+	return keys
+}
@@ -0,0 +1,269 @@
+package signer
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/NethermindEth/juno/core/felt"
+	"github.com/NethermindEth/juno/utils"
+	"github.com/NethermindEth/starknet.go/curve"
+	"github.com/NethermindEth/starknet.go/hash"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccountKeysFromFile(t *testing.T) {
+	write := func(t *testing.T, data string) string {
+		t.Helper()
+
+		path := filepath.Join(t.TempDir(), "keys.json")
+		require.NoError(t, os.WriteFile(path, []byte(data), 0o600))
+		return path
+	}
+
+	t.Run("Keys of each account", func(t *testing.T) {
+		path := write(t, `[
+			{"address": "0xa", "backend": "memory", "privateKeyEnv": "STAKER_A_KEY"},
+			{"address": "0xb", "backend": "transit", "transitUrl": "https://vault", "transitKey": "b"}
+		]`)
+
+		accounts, err := AccountKeysFromFile(path)
+		require.NoError(t, err)
+		require.Equal(t, []AccountKeyConfig{
+			{
+				Address:   feltFromString(t, "0xa"),
+				KeyConfig: KeyConfig{Backend: KEY_BACKEND_MEMORY, PrivateKeyEnv: "STAKER_A_KEY"},
+			},
+			{
+				Address: feltFromString(t, "0xb"),
+				KeyConfig: KeyConfig{
+					Backend: KEY_BACKEND_TRANSIT, TransitURL: "https://vault", TransitKey: "b",
+				},
+			},
+		}, accounts)
+	})
+
+	t.Run("Invalid files", func(t *testing.T) {
+		_, err := AccountKeysFromFile(filepath.Join(t.TempDir(), "missing.json"))
+		require.ErrorContains(t, err, "cannot read keys file")
+
+		_, err = AccountKeysFromFile(write(t, `{"address": "0xa"}`))
+		require.ErrorContains(t, err, "invalid keys file")
+
+		_, err = AccountKeysFromFile(write(t, `[]`))
+		require.EqualError(t, err, "the keys file has no keys")
+
+		_, err = AccountKeysFromFile(write(t, `[{"address": "0xa"}, {"backend": "memory"}]`))
+		require.EqualError(t, err, "key 2 of the keys file has no address")
+	})
+}
+
+func TestKeyConfigNewBackend(t *testing.T) {
+	t.Run("Memory backend reads the key from its env var", func(t *testing.T) {
+		t.Setenv("STAKER_A_KEY", "0x123")
+		keyConfig := KeyConfig{Backend: KEY_BACKEND_MEMORY, PrivateKeyEnv: "STAKER_A_KEY"}
+
+		keys, err := keyConfig.NewBackend()
+		require.NoError(t, err)
+		requireSignsWithItsKey(t, keys)
+
+		t.Setenv(DEFAULT_PRIVATE_KEY_ENV, "")
+		keyConfig.PrivateKeyEnv = ""
+		_, err = keyConfig.NewBackend()
+		require.ErrorContains(t, err, "couldn't read SIGNER_PRIVATE_KEY env var")
+	})
+
+	t.Run("Keystore backend", func(t *testing.T) {
+		dir := t.TempDir()
+		keystorePath := filepath.Join(dir, "keystore.json")
+		keystore, err := EncryptKey(big.NewInt(0x123), "password", KDF_SCRYPT, lightScryptParams)
+		require.NoError(t, err)
+		require.NoError(t, keystore.Save(keystorePath))
+		passwordFile := filepath.Join(dir, "password")
+		require.NoError(t, os.WriteFile(passwordFile, []byte("password\n"), 0o600))
+
+		keyConfig := KeyConfig{
+			Backend: KEY_BACKEND_KEYSTORE, Keystore: keystorePath, PasswordFile: passwordFile,
+		}
+		keys, err := keyConfig.NewBackend()
+		require.NoError(t, err)
+		requireSignsWithItsKey(t, keys)
+
+		_, err = (&KeyConfig{Backend: KEY_BACKEND_KEYSTORE}).NewBackend()
+		require.EqualError(t, err, "the keystore key backend requires a keystore")
+	})
+
+	t.Run("Unknown backend", func(t *testing.T) {
+		_, err := (&KeyConfig{Backend: "hsm"}).NewBackend()
+		require.EqualError(
+			t,
+			err,
+			"unknown key backend `hsm`, expected one of `memory`, `keystore` or `transit`",
+		)
+	})
+}
+
+func TestMultiKeySigner(t *testing.T) {
+	attestContract := feltFromString(t, "0xa77e57")
+	stakerA := feltFromString(t, "0xa")
+	stakerB := feltFromString(t, "0xb")
+	keysA := memoryKeyBackend(t, "0x123")
+	keysB := memoryKeyBackend(t, "0x456")
+
+	signer, err := NewMultiKey(
+		[]AccountKey{{Address: stakerB, Keys: keysB}, {Address: stakerA, Keys: keysA}},
+		Auth{},
+		DefaultPolicy(attestContract),
+		nil,
+		utils.NewNopZapLogger(),
+	)
+	require.NoError(t, err)
+	require.Nil(t, signer.PublicKey())
+
+	publicKeyOf := func(t *testing.T, keys KeyBackend) *felt.Felt {
+		t.Helper()
+
+		publicKey, err := keys.PublicKey(t.Context())
+		require.NoError(t, err)
+		return publicKey
+	}
+
+	t.Run("Each sender is signed with its key", func(t *testing.T) {
+		for sender, keys := range map[*felt.Felt]KeyBackend{stakerA: keysA, stakerB: keysB} {
+			req := Request{
+				InvokeTxnV3: invokeTxn(t, sender, attestCall(attestContract)),
+				ChainId:     new(felt.Felt).SetBytes([]byte("SN_SEPOLIA")),
+			}
+			body, err := json.Marshal(req)
+			require.NoError(t, err)
+
+			recorder := httptest.NewRecorder()
+			signer.handler(
+				recorder, httptest.NewRequest(http.MethodPost, SIGN_ENDPOINT, bytes.NewReader(body)),
+			)
+			require.Equal(t, http.StatusOK, recorder.Code)
+
+			var resp Response
+			require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &resp))
+			txHash, err := hash.TransactionHashInvokeV3(req.InvokeTxnV3, req.ChainId)
+			require.NoError(t, err)
+			publicKey := publicKeyOf(t, keys).BigInt(new(big.Int))
+			require.True(t, curve.Curve.Verify(
+				txHash.BigInt(new(big.Int)),
+				resp.Signature[0].BigInt(new(big.Int)),
+				resp.Signature[1].BigInt(new(big.Int)),
+				publicKey,
+				curve.Curve.GetYCoordinate(publicKey),
+			))
+		}
+	})
+
+	t.Run("Unknown senders are refused", func(t *testing.T) {
+		body, err := json.Marshal(Request{
+			InvokeTxnV3: invokeTxn(t, feltFromString(t, "0xc"), attestCall(attestContract)),
+			ChainId:     new(felt.Felt).SetBytes([]byte("SN_SEPOLIA")),
+		})
+		require.NoError(t, err)
+
+		recorder := httptest.NewRecorder()
+		signer.handler(
+			recorder, httptest.NewRequest(http.MethodPost, SIGN_ENDPOINT, bytes.NewReader(body)),
+		)
+		require.Equal(t, http.StatusForbidden, recorder.Code)
+		require.Contains(t, recorder.Body.String(), "no key for sender address 0xc")
+	})
+
+	t.Run("Only the configured accounts are labelled in the metrics", func(t *testing.T) {
+		require.Equal(t, "0xa", signer.senderLabel(stakerA))
+		require.Equal(t, "0xb", signer.senderLabel(stakerB))
+		require.Equal(t, unknownSenderLabel, signer.senderLabel(feltFromString(t, "0xc")))
+		require.Equal(t, unknownSenderLabel, signer.senderLabel(nil))
+	})
+
+	t.Run("Public key of each account", func(t *testing.T) {
+		get := func(t *testing.T, target string) *httptest.ResponseRecorder {
+			t.Helper()
+
+			recorder := httptest.NewRecorder()
+			signer.publicKeyHandler(
+				recorder, httptest.NewRequest(http.MethodGet, target, http.NoBody),
+			)
+			return recorder
+		}
+
+		recorder := get(t, PUBLIC_KEY_ENDPOINT+"?address=0xb")
+		require.Equal(t, http.StatusOK, recorder.Code)
+		var resp PublicKeyResponse
+		require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &resp))
+		require.Equal(t, publicKeyOf(t, keysB), resp.PublicKey)
+
+		require.Equal(t, http.StatusBadRequest, get(t, PUBLIC_KEY_ENDPOINT).Code)
+		require.Equal(t, http.StatusBadRequest, get(t, PUBLIC_KEY_ENDPOINT+"?address=xyz").Code)
+		require.Equal(t, http.StatusNotFound, get(t, PUBLIC_KEY_ENDPOINT+"?address=0xc").Code)
+	})
+
+	t.Run("Keys are listed by account", func(t *testing.T) {
+		require.Equal(t, []KeyInfo{
+			{Address: stakerA, PublicKey: publicKeyOf(t, keysA)},
+			{Address: stakerB, PublicKey: publicKeyOf(t, keysB)},
+		}, signer.Keys())
+	})
+
+	t.Run("One key per account", func(t *testing.T) {
+		_, err := NewMultiKey(
+			[]AccountKey{{Address: stakerA, Keys: keysA}, {Address: stakerA, Keys: keysB}},
+			Auth{},
+			Policy{},
+			nil,
+			utils.NewNopZapLogger(),
+		)
+		require.EqualError(t, err, "more than one key for account 0xa")
+
+		_, err = NewMultiKey(nil, Auth{}, Policy{}, nil, utils.NewNopZapLogger())
+		require.EqualError(t, err, "no account key given to the signer")
+	})
+}
+
+func TestAdminKeysHandler(t *testing.T) {
+	keys := memoryKeyBackend(t, "0x123")
+	signer, err := NewMultiKey(
+		[]AccountKey{{Address: feltFromString(t, "0xa"), Keys: keys}},
+		Auth{},
+		Policy{},
+		nil,
+		utils.NewNopZapLogger(),
+	)
+	require.NoError(t, err)
+	require.ErrorContains(t, signer.EnableAdmin(""), "invalid admin credentials")
+	require.NoError(t, signer.EnableAdmin("admin secret"))
+
+	get := func(t *testing.T, token string) *httptest.ResponseRecorder {
+		t.Helper()
+
+		req := httptest.NewRequest(http.MethodGet, ADMIN_KEYS_ENDPOINT, http.NoBody)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		recorder := httptest.NewRecorder()
+		signer.adminKeysHandler(recorder, req)
+		return recorder
+	}
+
+	require.Equal(t, http.StatusUnauthorized, get(t, "").Code)
+	require.Equal(t, http.StatusUnauthorized, get(t, "wrong secret").Code)
+
+	recorder := get(t, "admin secret")
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.Equal(t, "application/json", recorder.Header().Get("Content-Type"))
+
+	var resp KeysResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &resp))
+	publicKey, err := keys.PublicKey(t.Context())
+	require.NoError(t, err)
+	require.Equal(t, []KeyInfo{{Address: feltFromString(t, "0xa"), PublicKey: publicKey}}, resp.Keys)
+}
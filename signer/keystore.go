@@ -0,0 +1,338 @@
+package signer
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+
+	"github.com/NethermindEth/starknet.go/curve"
+	"github.com/cockroachdb/errors"
+	"golang.org/x/crypto/pbkdf2"
+	"golang.org/x/crypto/scrypt"
+	"golang.org/x/crypto/sha3"
+	"golang.org/x/term"
+)
+
+const (
+	KEYSTORE_VERSION = 3
+	KEYSTORE_CIPHER  = "aes-128-ctr"
+
+	KDF_SCRYPT = "scrypt"
+	KDF_PBKDF2 = "pbkdf2"
+
+	pbkdf2PRF = "hmac-sha256"
+)
+
+// Returned when the keystore can't be decrypted with the given password
+var ErrWrongPassword = errors.New("wrong keystore password")
+
+// Cost of the key derivation functions used for new keystores
+var (
+	DefaultScryptParams = KDFParams{DKLen: 32, N: 1 << 18, R: 8, P: 1}
+	DefaultPBKDF2Params = KDFParams{DKLen: 32, C: 1 << 18, PRF: pbkdf2PRF}
+)
+
+// Password protected private key, stored in the same format as Ethereum V3 keystores.
+// The public key is kept in plain text so the keystore can be identified without the password
+type Keystore struct {
+	Version   int            `json:"version"`
+	Id        string         `json:"id"`
+	PublicKey string         `json:"publicKey"`
+	Crypto    KeystoreCrypto `json:"crypto"`
+}
+
+type KeystoreCrypto struct {
+	Cipher       string       `json:"cipher"`
+	CipherText   string       `json:"ciphertext"`
+	CipherParams CipherParams `json:"cipherparams"`
+	KDF          string       `json:"kdf"`
+	KDFParams    KDFParams    `json:"kdfparams"`
+	MAC          string       `json:"mac"`
+}
+
+type CipherParams struct {
+	IV string `json:"iv"`
+}
+
+// Parameters of the key derivation function. N, R and P are only used by scrypt,
+// C and PRF only by pbkdf2
+type KDFParams struct {
+	DKLen int    `json:"dklen"`
+	Salt  string `json:"salt"`
+	N     int    `json:"n,omitempty"`
+	R     int    `json:"r,omitempty"`
+	P     int    `json:"p,omitempty"`
+	C     int    `json:"c,omitempty"`
+	PRF   string `json:"prf,omitempty"`
+}
+
+// Returns the default parameters of the given key derivation function
+func KDFParamsFromName(kdf string) (KDFParams, error) {
+	switch kdf {
+	case KDF_SCRYPT:
+		return DefaultScryptParams, nil
+	case KDF_PBKDF2:
+		return DefaultPBKDF2Params, nil
+	default:
+		return KDFParams{}, errors.Errorf(
+			"unknown key derivation function `%s`, expected either `%s` or `%s`",
+			kdf, KDF_SCRYPT, KDF_PBKDF2,
+		)
+	}
+}
+
+// Generates a random private key, lower than the order of the Stark curve
+func NewPrivateKey() (*big.Int, error) {
+	max := new(big.Int).Sub(curve.Curve.N, big.NewInt(1))
+	privateKey, err := rand.Int(rand.Reader, max)
+	if err != nil {
+		return nil, errors.Errorf("cannot generate private key: %w", err)
+	}
+	// Zero is not a valid private key
+	return privateKey.Add(privateKey, big.NewInt(1)), nil
+}
+
+// Encrypts the private key with a key derived from the password. A random salt is
+// used when `params` doesn't set one
+func EncryptKey(
+	privateKey *big.Int, password string, kdf string, params KDFParams,
+) (Keystore, error) {
+	publicKey, _, err := curve.Curve.PrivateToPoint(privateKey)
+	if err != nil {
+		return Keystore{}, errors.New("cannot derive public key from private key")
+	}
+
+	if params.Salt == "" {
+		salt, err := randomBytes(32)
+		if err != nil {
+			return Keystore{}, err
+		}
+		params.Salt = hex.EncodeToString(salt)
+	}
+	derivedKey, err := deriveKey(password, kdf, &params)
+	if err != nil {
+		return Keystore{}, err
+	}
+
+	iv, err := randomBytes(aes.BlockSize)
+	if err != nil {
+		return Keystore{}, err
+	}
+	cipherText, err := aesCTR(derivedKey[:16], iv, privateKey.FillBytes(make([]byte, 32)))
+	if err != nil {
+		return Keystore{}, err
+	}
+
+	id, err := randomBytes(16)
+	if err != nil {
+		return Keystore{}, err
+	}
+	// Version 4 UUID
+	id[6] = (id[6] & 0x0f) | 0x40
+	id[8] = (id[8] & 0x3f) | 0x80
+
+	return Keystore{
+		Version:   KEYSTORE_VERSION,
+		Id:        fmt.Sprintf("%x-%x-%x-%x-%x", id[0:4], id[4:6], id[6:8], id[8:10], id[10:]),
+		PublicKey: "0x" + publicKey.Text(16),
+		Crypto: KeystoreCrypto{
+			Cipher:       KEYSTORE_CIPHER,
+			CipherText:   hex.EncodeToString(cipherText),
+			CipherParams: CipherParams{IV: hex.EncodeToString(iv)},
+			KDF:          kdf,
+			KDFParams:    params,
+			MAC:          hex.EncodeToString(keystoreMAC(derivedKey, cipherText)),
+		},
+	}, nil
+}
+
+// Decrypts the private key, returns `ErrWrongPassword` if the password is not the right one
+func (k *Keystore) Decrypt(password string) (*big.Int, error) {
+	if k.Version != KEYSTORE_VERSION {
+		return nil, errors.Errorf("unsupported keystore version %d", k.Version)
+	}
+	if k.Crypto.Cipher != KEYSTORE_CIPHER {
+		return nil, errors.Errorf("unsupported keystore cipher `%s`", k.Crypto.Cipher)
+	}
+
+	cipherText, err := hex.DecodeString(k.Crypto.CipherText)
+	if err != nil {
+		return nil, errors.Errorf("invalid keystore ciphertext: %w", err)
+	}
+	iv, err := hex.DecodeString(k.Crypto.CipherParams.IV)
+	if err != nil || len(iv) != aes.BlockSize {
+		return nil, errors.New("invalid keystore iv")
+	}
+	mac, err := hex.DecodeString(k.Crypto.MAC)
+	if err != nil {
+		return nil, errors.Errorf("invalid keystore mac: %w", err)
+	}
+
+	derivedKey, err := deriveKey(password, k.Crypto.KDF, &k.Crypto.KDFParams)
+	if err != nil {
+		return nil, err
+	}
+	if subtle.ConstantTimeCompare(mac, keystoreMAC(derivedKey, cipherText)) != 1 {
+		return nil, ErrWrongPassword
+	}
+
+	plainText, err := aesCTR(derivedKey[:16], iv, cipherText)
+	if err != nil {
+		return nil, err
+	}
+	privateKey := new(big.Int).SetBytes(plainText)
+
+	publicKey, _, err := curve.Curve.PrivateToPoint(privateKey)
+	if err != nil || "0x"+publicKey.Text(16) != k.PublicKey {
+		return nil, errors.New("decrypted private key doesn't match the keystore public key")
+	}
+	return privateKey, nil
+}
+
+func KeystoreFromFile(path string) (Keystore, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Keystore{}, errors.Errorf("cannot read keystore: %w", err)
+	}
+	var keystore Keystore
+	if err := json.Unmarshal(data, &keystore); err != nil {
+		return Keystore{}, errors.Errorf("invalid keystore %s: %w", path, err)
+	}
+	return keystore, nil
+}
+
+// Writes the keystore to a new file only readable by its owner. It fails if the file
+// already exists so no key is overwritten by mistake
+func (k *Keystore) Save(path string) error {
+	data, err := json.MarshalIndent(k, "", "  ")
+	if err != nil {
+		return err
+	}
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return errors.Errorf("cannot create keystore: %w", err)
+	}
+	if _, err := file.Write(data); err != nil {
+		_ = file.Close()
+		return errors.Errorf("cannot write keystore: %w", err)
+	}
+	return file.Close()
+}
+
+// Reads and decrypts the keystore at `path`, returning the private key as a hex string
+func DecryptKeystoreFile(path string, password string) (string, error) {
+	keystore, err := KeystoreFromFile(path)
+	if err != nil {
+		return "", err
+	}
+	privateKey, err := keystore.Decrypt(password)
+	if err != nil {
+		return "", errors.Errorf("cannot decrypt keystore %s: %w", path, err)
+	}
+	return "0x" + privateKey.Text(16), nil
+}
+
+// Reads the password from `passwordFile`, ignoring its trailing new line. If no file is
+// given, the password is asked in the terminal with the given prompt
+func ReadPassword(passwordFile string, prompt string) (string, error) {
+	if passwordFile != "" {
+		data, err := os.ReadFile(passwordFile)
+		if err != nil {
+			return "", errors.Errorf("cannot read password file: %w", err)
+		}
+		return strings.TrimRight(string(data), "\r\n"), nil
+	}
+
+	stdin := int(os.Stdin.Fd())
+	if !term.IsTerminal(stdin) {
+		return "", errors.New(
+			"no password file given and not running in a terminal to prompt for it",
+		)
+	}
+	fmt.Fprint(os.Stderr, prompt)
+	password, err := term.ReadPassword(stdin)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", errors.Errorf("cannot read password: %w", err)
+	}
+	return string(password), nil
+}
+
+// Like `ReadPassword`, but the password is asked twice when prompted to avoid typos
+func ReadNewPassword(passwordFile string) (string, error) {
+	password, err := ReadPassword(passwordFile, "New keystore password: ")
+	if err != nil || passwordFile != "" {
+		return password, err
+	}
+	confirmation, err := ReadPassword("", "Repeat the password: ")
+	if err != nil {
+		return "", err
+	}
+	if password != confirmation {
+		return "", errors.New("passwords don't match")
+	}
+	return password, nil
+}
+
+func deriveKey(password string, kdf string, params *KDFParams) ([]byte, error) {
+	salt, err := hex.DecodeString(params.Salt)
+	if err != nil {
+		return nil, errors.Errorf("invalid keystore salt: %w", err)
+	}
+	// The first half of the key encrypts the private key and the second one authenticates it
+	if params.DKLen < 32 {
+		return nil, errors.Errorf("derived key length must be at least 32, got %d", params.DKLen)
+	}
+
+	switch kdf {
+	case KDF_SCRYPT:
+		key, err := scrypt.Key([]byte(password), salt, params.N, params.R, params.P, params.DKLen)
+		if err != nil {
+			return nil, errors.Errorf("invalid scrypt parameters: %w", err)
+		}
+		return key, nil
+	case KDF_PBKDF2:
+		if params.PRF != pbkdf2PRF {
+			return nil, errors.Errorf("unsupported pbkdf2 prf `%s`", params.PRF)
+		}
+		if params.C <= 0 {
+			return nil, errors.Errorf("invalid pbkdf2 iteration count %d", params.C)
+		}
+		return pbkdf2.Key([]byte(password), salt, params.C, params.DKLen, sha256.New), nil
+	default:
+		return nil, errors.Errorf("unsupported key derivation function `%s`", kdf)
+	}
+}
+
+func keystoreMAC(derivedKey []byte, cipherText []byte) []byte {
+	hash := sha3.NewLegacyKeccak256()
+	hash.Write(derivedKey[16:32])
+	hash.Write(cipherText)
+	return hash.Sum(nil)
+}
+
+func aesCTR(key, iv, input []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	output := make([]byte, len(input))
+	cipher.NewCTR(block, iv).XORKeyStream(output, input)
+	return output, nil
+}
+
+func randomBytes(n int) ([]byte, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return nil, errors.Errorf("cannot read random bytes: %w", err)
+	}
+	return b, nil
+}
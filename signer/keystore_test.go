@@ -0,0 +1,141 @@
+package signer
+
+import (
+	"encoding/hex"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/NethermindEth/starknet.go/curve"
+	"github.com/stretchr/testify/require"
+)
+
+// Cheap parameters so tests don't spend seconds deriving keys
+var (
+	lightScryptParams = KDFParams{DKLen: 32, N: 1 << 10, R: 8, P: 1}
+	lightPBKDF2Params = KDFParams{DKLen: 32, C: 1 << 10, PRF: pbkdf2PRF}
+)
+
+func TestKeystore(t *testing.T) {
+	privateKey, err := NewPrivateKey()
+	require.NoError(t, err)
+	publicKey, _, err := curve.Curve.PrivateToPoint(privateKey)
+	require.NoError(t, err)
+
+	for kdf, params := range map[string]KDFParams{
+		KDF_SCRYPT: lightScryptParams,
+		KDF_PBKDF2: lightPBKDF2Params,
+	} {
+		t.Run(kdf, func(t *testing.T) {
+			keystore, err := EncryptKey(privateKey, "password", kdf, params)
+			require.NoError(t, err)
+
+			require.Equal(t, KEYSTORE_VERSION, keystore.Version)
+			require.Equal(t, "0x"+publicKey.Text(16), keystore.PublicKey)
+			require.Equal(t, kdf, keystore.Crypto.KDF)
+			require.Len(t, keystore.Crypto.KDFParams.Salt, 64)
+			require.Regexp(t, "^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-", keystore.Id)
+
+			decrypted, err := keystore.Decrypt("password")
+			require.NoError(t, err)
+			require.Equal(t, privateKey, decrypted)
+
+			decrypted, err = keystore.Decrypt("wrong password")
+			require.Nil(t, decrypted)
+			require.ErrorIs(t, err, ErrWrongPassword)
+		})
+	}
+
+	t.Run("Private key is encrypted", func(t *testing.T) {
+		keystore, err := EncryptKey(big.NewInt(0x123), "password", KDF_SCRYPT, lightScryptParams)
+		require.NoError(t, err)
+		plainText := hex.EncodeToString(big.NewInt(0x123).FillBytes(make([]byte, 32)))
+		require.Len(t, keystore.Crypto.CipherText, len(plainText))
+		require.NotEqual(t, plainText, keystore.Crypto.CipherText)
+	})
+
+	t.Run("Tampered keystore", func(t *testing.T) {
+		keystore, err := EncryptKey(privateKey, "password", KDF_SCRYPT, lightScryptParams)
+		require.NoError(t, err)
+
+		tampered := keystore
+		tampered.Crypto.CipherText = "00" + keystore.Crypto.CipherText[2:]
+		if tampered.Crypto.CipherText == keystore.Crypto.CipherText {
+			tampered.Crypto.CipherText = "11" + keystore.Crypto.CipherText[2:]
+		}
+		_, err = tampered.Decrypt("password")
+		require.ErrorIs(t, err, ErrWrongPassword)
+
+		tampered = keystore
+		tampered.PublicKey = "0x123"
+		_, err = tampered.Decrypt("password")
+		require.EqualError(t, err, "decrypted private key doesn't match the keystore public key")
+
+		tampered = keystore
+		tampered.Crypto.KDF = "argon2"
+		_, err = tampered.Decrypt("password")
+		require.EqualError(t, err, "unsupported key derivation function `argon2`")
+
+		tampered = keystore
+		tampered.Version = 4
+		_, err = tampered.Decrypt("password")
+		require.EqualError(t, err, "unsupported keystore version 4")
+	})
+
+	t.Run("Unknown key derivation function", func(t *testing.T) {
+		params, err := KDFParamsFromName("argon2")
+		require.Zero(t, params)
+		require.EqualError(
+			t, err, "unknown key derivation function `argon2`, expected either `scrypt` or `pbkdf2`",
+		)
+	})
+}
+
+func TestKeystoreFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "keystore.json")
+
+	keystore, err := EncryptKey(big.NewInt(0x123), "password", KDF_SCRYPT, lightScryptParams)
+	require.NoError(t, err)
+	require.NoError(t, keystore.Save(path))
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+	// An existing keystore is never overwritten
+	require.ErrorContains(t, keystore.Save(path), "cannot create keystore")
+
+	loaded, err := KeystoreFromFile(path)
+	require.NoError(t, err)
+	require.Equal(t, keystore, loaded)
+
+	passwordFile := filepath.Join(dir, "password")
+	require.NoError(t, os.WriteFile(passwordFile, []byte("password\n"), 0o600))
+	password, err := ReadPassword(passwordFile, "")
+	require.NoError(t, err)
+	require.Equal(t, "password", password)
+
+	privateKey, err := DecryptKeystoreFile(path, password)
+	require.NoError(t, err)
+	require.Equal(t, "0x123", privateKey)
+
+	privateKey, err = DecryptKeystoreFile(path, "wrong password")
+	require.Empty(t, privateKey)
+	require.ErrorIs(t, err, ErrWrongPassword)
+
+	_, err = KeystoreFromFile(filepath.Join(dir, "missing.json"))
+	require.ErrorContains(t, err, "cannot read keystore")
+}
+
+func TestNewPrivateKey(t *testing.T) {
+	privateKey, err := NewPrivateKey()
+	require.NoError(t, err)
+	require.Positive(t, privateKey.Sign())
+	require.Negative(t, privateKey.Cmp(curve.Curve.N))
+
+	other, err := NewPrivateKey()
+	require.NoError(t, err)
+	require.NotEqual(t, privateKey, other)
+}
@@ -0,0 +1,75 @@
+package signer
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Label of the senders the signer has no key for nor allows in its policy, so requests
+// from arbitrary senders don't create a label each
+const unknownSenderLabel = "unknown"
+
+// Metrics of the requests received by the signer, kept in a registry of its own
+type Metrics struct {
+	registry          *prometheus.Registry
+	requestCount      *prometheus.CounterVec
+	requestLatency    *prometheus.HistogramVec
+	keyBackendLatency *prometheus.HistogramVec
+}
+
+// NewMetrics creates and registers all the signer metrics
+func NewMetrics() *Metrics {
+	registry := prometheus.NewRegistry()
+
+	m := &Metrics{
+		registry: registry,
+		requestCount: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "signer_request_count",
+				Help: "The total number of signing requests since signer startup, by decision and sender",
+			},
+			[]string{"endpoint", "decision", "sender"},
+		),
+		requestLatency: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name:    "signer_request_latency_seconds",
+				Help:    "The duration (in seconds) of the signing requests, by decision",
+				Buckets: []float64{0.001, 0.005, 0.01, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10},
+			},
+			[]string{"endpoint", "decision"},
+		),
+		keyBackendLatency: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name:    "signer_key_backend_latency_seconds",
+				Help:    "The duration (in seconds) of the signatures made by the key backend, by sender",
+				Buckets: []float64{0.001, 0.005, 0.01, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10},
+			},
+			[]string{"sender"},
+		),
+	}
+
+	registry.MustRegister(m.requestCount, m.requestLatency, m.keyBackendLatency)
+
+	return m
+}
+
+// Handler serves the metrics in the Prometheus text format
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// RecordRequest increments the request counter and records how long the request took
+func (m *Metrics) RecordRequest(
+	endpoint string, decision AuditDecision, sender string, latency time.Duration,
+) {
+	m.requestCount.WithLabelValues(endpoint, string(decision), sender).Inc()
+	m.requestLatency.WithLabelValues(endpoint, string(decision)).Observe(latency.Seconds())
+}
+
+// ObserveKeyBackendLatency records how long the key backend took to sign for a sender
+func (m *Metrics) ObserveKeyBackendLatency(sender string, latency time.Duration) {
+	m.keyBackendLatency.WithLabelValues(sender).Observe(latency.Seconds())
+}
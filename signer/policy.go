@@ -0,0 +1,396 @@
+package signer
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+
+	"github.com/NethermindEth/juno/core/felt"
+	"github.com/NethermindEth/starknet.go/rpc"
+	"github.com/NethermindEth/starknet.go/utils"
+	"github.com/cockroachdb/errors"
+)
+
+// Name of the policy rules, returned to the client when a transaction breaks them
+const (
+	RULE_SENDER_ADDRESS      = "sender_address"
+	RULE_ALLOWED_CALLS       = "allowed_calls"
+	RULE_MAX_CALLDATA_LENGTH = "max_calldata_length"
+	RULE_MAX_RESOURCE_BOUNDS = "max_resource_bounds"
+	RULE_MAX_TIP             = "max_tip"
+	RULE_CHAIN_ID            = "chain_id"
+)
+
+// Entry point called by the validator on the attestation contract
+const ATTEST_ENTRY_POINT = "attest"
+
+// A contract and the entry point of it that can be called
+type AllowedCall struct {
+	Contract *felt.Felt
+	Selector *felt.Felt
+}
+
+// Rules a transaction must follow to be signed. Empty or zero rules are not enforced
+type Policy struct {
+	// Accounts whose transactions can be signed
+	SenderAddresses []*felt.Felt
+	// Calls the transaction can make
+	AllowedCalls []AllowedCall
+	// Max number of felts in the transaction calldata
+	MaxCalldataLength int
+	// Max amount and price per unit of each resource. Empty values are not limited
+	MaxResourceBounds *rpc.ResourceBoundsMapping
+	// Max tip paid to the sequencer. Not limited when empty
+	MaxTip rpc.U64
+	// Chains the transaction can be sent to
+	ChainIds []*felt.Felt
+}
+
+// Only allows calling `attest` on the attestation contract
+func DefaultPolicy(attestContract *felt.Felt) Policy {
+	return Policy{
+		AllowedCalls: []AllowedCall{{
+			Contract: attestContract,
+			Selector: utils.GetSelectorFromNameFelt(ATTEST_ENTRY_POINT),
+		}},
+	}
+}
+
+// Format of the policy file. Selectors can be given either by the entry point name or
+// as a hex value, and chain ids either by their name, e.g. SN_SEPOLIA, or as a hex value
+type policyFile struct {
+	SenderAddresses []*felt.Felt `json:"senderAddresses"`
+	AllowedCalls    []struct {
+		Contract *felt.Felt `json:"contract"`
+		Selector string     `json:"selector"`
+	} `json:"allowedCalls"`
+	MaxCalldataLength int                        `json:"maxCalldataLength"`
+	MaxResourceBounds *rpc.ResourceBoundsMapping `json:"maxResourceBounds"`
+	MaxTip            rpc.U64                    `json:"maxTip"`
+	ChainIds          []string                   `json:"chainIds"`
+}
+
+// Reads the policy from a JSON file. If it doesn't set the allowed calls, only `attest`
+// on the attestation contract is allowed, as in the default policy, so `attestContract`
+// is then required
+func PolicyFromFile(path string, attestContract *felt.Felt) (Policy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Policy{}, errors.Errorf("cannot read policy file: %w", err)
+	}
+	return PolicyFromData(data, attestContract)
+}
+
+func PolicyFromData(data []byte, attestContract *felt.Felt) (Policy, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	// A mistyped rule would otherwise be silently ignored
+	decoder.DisallowUnknownFields()
+
+	var file policyFile
+	if err := decoder.Decode(&file); err != nil {
+		return Policy{}, errors.Errorf("invalid policy: %w", err)
+	}
+
+	policy := DefaultPolicy(attestContract)
+	policy.SenderAddresses = file.SenderAddresses
+	policy.MaxCalldataLength = file.MaxCalldataLength
+	policy.MaxResourceBounds = file.MaxResourceBounds
+	policy.MaxTip = file.MaxTip
+
+	if len(file.AllowedCalls) == 0 && attestContract == nil {
+		return Policy{}, errors.New(
+			"invalid policy: no allowed calls set and no attestation contract given",
+		)
+	}
+	if len(file.AllowedCalls) != 0 {
+		policy.AllowedCalls = make([]AllowedCall, len(file.AllowedCalls))
+		for i, call := range file.AllowedCalls {
+			if call.Selector == "" {
+				return Policy{}, errors.New(
+					"invalid policy: allowed calls need a contract and a selector",
+				)
+			}
+			selector, err := selectorFromString(call.Selector)
+			if err != nil {
+				return Policy{}, errors.Errorf("invalid policy: %w", err)
+			}
+			policy.AllowedCalls[i] = AllowedCall{Contract: call.Contract, Selector: selector}
+		}
+	}
+
+	for _, chainId := range file.ChainIds {
+		value, err := chainIdFromString(chainId)
+		if err != nil {
+			return Policy{}, errors.Errorf("invalid policy: %w", err)
+		}
+		policy.ChainIds = append(policy.ChainIds, value)
+	}
+
+	if err := policy.Check(); err != nil {
+		return Policy{}, err
+	}
+	return policy, nil
+}
+
+// Checks the rules can be enforced
+func (p *Policy) Check() error {
+	for i := range p.AllowedCalls {
+		if p.AllowedCalls[i].Contract == nil || p.AllowedCalls[i].Selector == nil {
+			return errors.New("invalid policy: allowed calls need a contract and a selector")
+		}
+	}
+	if p.MaxCalldataLength < 0 {
+		return errors.New("invalid policy: max calldata length can't be negative")
+	}
+	if p.MaxResourceBounds != nil {
+		// Checking the caps against themselves validates their values
+		if _, err := exceededResourceBound(p.MaxResourceBounds, p.MaxResourceBounds); err != nil {
+			return errors.Errorf("invalid policy: max resource bounds: %w", err)
+		}
+	}
+	if p.MaxTip != "" {
+		if _, err := p.MaxTip.ToUint64(); err != nil {
+			return errors.Errorf("invalid policy: invalid max tip `%s`", p.MaxTip)
+		}
+	}
+	return nil
+}
+
+// Selectors starting with 0x are hex values, anything else is an entry point name
+func selectorFromString(s string) (*felt.Felt, error) {
+	if strings.HasPrefix(s, "0x") {
+		selector, err := new(felt.Felt).SetString(s)
+		if err != nil {
+			return nil, errors.Errorf("invalid selector `%s`", s)
+		}
+		return selector, nil
+	}
+	return utils.GetSelectorFromNameFelt(s), nil
+}
+
+func chainIdFromString(s string) (*felt.Felt, error) {
+	if strings.HasPrefix(s, "0x") {
+		chainId, err := new(felt.Felt).SetString(s)
+		if err != nil {
+			return nil, errors.Errorf("invalid chain id `%s`", s)
+		}
+		return chainId, nil
+	}
+	if s == "" || len(s) > 31 {
+		return nil, errors.Errorf("invalid chain id `%s`", s)
+	}
+	return new(felt.Felt).SetBytes([]byte(s)), nil
+}
+
+// Returned when a transaction breaks one of the policy rules
+type PolicyViolation struct {
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+func (v *PolicyViolation) Error() string {
+	return fmt.Sprintf("transaction violates the %s rule: %s", v.Rule, v.Message)
+}
+
+// Body of the response to a transaction rejected by the policy
+type PolicyViolationResponse struct {
+	Error string `json:"error"`
+	PolicyViolation
+}
+
+// Returns the first rule the transaction breaks, nil if it follows all of them
+func (p *Policy) Evaluate(txn *rpc.InvokeTxnV3, chainId *felt.Felt) *PolicyViolation {
+	if len(p.ChainIds) != 0 && !containsFelt(p.ChainIds, chainId) {
+		return &PolicyViolation{
+			Rule:    RULE_CHAIN_ID,
+			Message: fmt.Sprintf("chain id %s is not allowed", chainId),
+		}
+	}
+
+	if len(p.SenderAddresses) != 0 &&
+		(txn.SenderAddress == nil || !containsFelt(p.SenderAddresses, txn.SenderAddress)) {
+		return &PolicyViolation{
+			Rule:    RULE_SENDER_ADDRESS,
+			Message: fmt.Sprintf("sender address %s is not allowed", txn.SenderAddress),
+		}
+	}
+
+	if p.MaxCalldataLength != 0 && len(txn.Calldata) > p.MaxCalldataLength {
+		return &PolicyViolation{
+			Rule: RULE_MAX_CALLDATA_LENGTH,
+			Message: fmt.Sprintf(
+				"calldata length %d is above the max of %d", len(txn.Calldata), p.MaxCalldataLength,
+			),
+		}
+	}
+
+	if p.MaxResourceBounds != nil {
+		exceeded, err := exceededResourceBound(&txn.ResourceBounds, p.MaxResourceBounds)
+		if err != nil {
+			return &PolicyViolation{Rule: RULE_MAX_RESOURCE_BOUNDS, Message: err.Error()}
+		}
+		if exceeded != "" {
+			return &PolicyViolation{Rule: RULE_MAX_RESOURCE_BOUNDS, Message: exceeded}
+		}
+	}
+
+	if p.MaxTip != "" {
+		if violation := p.evaluateTip(txn.Tip); violation != nil {
+			return violation
+		}
+	}
+
+	if len(p.AllowedCalls) != 0 {
+		calls, err := decodeCalls(txn.Calldata)
+		if err != nil {
+			return &PolicyViolation{Rule: RULE_ALLOWED_CALLS, Message: err.Error()}
+		}
+		for _, call := range calls {
+			if !p.allowsCall(&call) {
+				return &PolicyViolation{
+					Rule: RULE_ALLOWED_CALLS,
+					Message: fmt.Sprintf(
+						"call to selector %s of contract %s is not allowed", call.Selector, call.Contract,
+					),
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+func (p *Policy) evaluateTip(tip rpc.U64) *PolicyViolation {
+	// Checked to be valid when loading the policy
+	maxTip, _ := p.MaxTip.ToUint64()
+	value, err := tip.ToUint64()
+	if err != nil {
+		return &PolicyViolation{Rule: RULE_MAX_TIP, Message: fmt.Sprintf("invalid tip `%s`", tip)}
+	}
+	if value > maxTip {
+		return &PolicyViolation{
+			Rule:    RULE_MAX_TIP,
+			Message: fmt.Sprintf("tip %s is above the max of %s", tip, p.MaxTip),
+		}
+	}
+	return nil
+}
+
+func (p *Policy) allowsCall(call *Call) bool {
+	for i := range p.AllowedCalls {
+		if p.AllowedCalls[i].Contract.Equal(call.Contract) &&
+			p.AllowedCalls[i].Selector.Equal(call.Selector) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsFelt(values []*felt.Felt, value *felt.Felt) bool {
+	for _, v := range values {
+		if v.Equal(value) {
+			return true
+		}
+	}
+	return false
+}
+
+// A call made by a transaction
+type Call struct {
+	Contract *felt.Felt   `json:"contract"`
+	Selector *felt.Felt   `json:"selector"`
+	Calldata []*felt.Felt `json:"calldata"`
+}
+
+// Decodes the calls in a Cairo 1 account calldata, which is the number of calls
+// followed by each call's contract, selector, calldata length and calldata
+func decodeCalls(calldata []*felt.Felt) ([]Call, error) {
+	malformed := errors.New("calldata is not a valid list of calls")
+	if len(calldata) == 0 {
+		return nil, malformed
+	}
+
+	callsCount, ok := feltToLength(calldata[0], len(calldata))
+	if !ok || callsCount == 0 {
+		return nil, malformed
+	}
+	calls := make([]Call, 0, callsCount)
+	next := 1
+	for range callsCount {
+		if next+3 > len(calldata) {
+			return nil, malformed
+		}
+		length, ok := feltToLength(calldata[next+2], len(calldata)-next-3)
+		if !ok {
+			return nil, malformed
+		}
+		calls = append(calls, Call{
+			Contract: calldata[next],
+			Selector: calldata[next+1],
+			Calldata: calldata[next+3 : next+3+length],
+		})
+		next += 3 + length
+	}
+	if next != len(calldata) {
+		return nil, malformed
+	}
+	return calls, nil
+}
+
+// Converts a felt to a length, which can be at most `maxLength`
+func feltToLength(value *felt.Felt, maxLength int) (int, bool) {
+	length := value.BigInt(new(big.Int))
+	if !length.IsInt64() || length.Int64() > int64(maxLength) {
+		return 0, false
+	}
+	return int(length.Int64()), true
+}
+
+// Returns which resource bound is above its cap, empty when none is
+func exceededResourceBound(
+	resourceBounds, caps *rpc.ResourceBoundsMapping,
+) (string, error) {
+	resources := []struct {
+		name              string
+		bounds, boundsCap *rpc.ResourceBounds
+	}{
+		{"l1_gas", &resourceBounds.L1Gas, &caps.L1Gas},
+		{"l1_data_gas", &resourceBounds.L1DataGas, &caps.L1DataGas},
+		{"l2_gas", &resourceBounds.L2Gas, &caps.L2Gas},
+	}
+	for _, resource := range resources {
+		values := []struct {
+			name          string
+			value, capStr string
+		}{
+			{"max amount", string(resource.bounds.MaxAmount), string(resource.boundsCap.MaxAmount)},
+			{
+				"max price per unit",
+				string(resource.bounds.MaxPricePerUnit),
+				string(resource.boundsCap.MaxPricePerUnit),
+			},
+		}
+		for _, v := range values {
+			if v.capStr == "" {
+				continue
+			}
+			capValue, ok := new(big.Int).SetString(v.capStr, 0)
+			if !ok {
+				return "", errors.Errorf("invalid %s %s cap `%s`", resource.name, v.name, v.capStr)
+			}
+			value, ok := new(big.Int).SetString(v.value, 0)
+			if !ok {
+				return "", errors.Errorf("invalid %s %s `%s`", resource.name, v.name, v.value)
+			}
+			if value.Cmp(capValue) > 0 {
+				return fmt.Sprintf(
+					"%s %s %s is above the cap of %s", resource.name, v.name, v.value, v.capStr,
+				), nil
+			}
+		}
+	}
+	return "", nil
+}
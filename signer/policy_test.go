@@ -0,0 +1,309 @@
+package signer
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/NethermindEth/juno/core/felt"
+	"github.com/NethermindEth/juno/utils"
+	"github.com/NethermindEth/starknet.go/account"
+	"github.com/NethermindEth/starknet.go/rpc"
+	snUtils "github.com/NethermindEth/starknet.go/utils"
+	"github.com/stretchr/testify/require"
+)
+
+func feltFromString(t *testing.T, s string) *felt.Felt {
+	t.Helper()
+
+	value, err := new(felt.Felt).SetString(s)
+	require.NoError(t, err)
+	return value
+}
+
+// Builds an invoke transaction from `sender` making the given calls
+func invokeTxn(
+	t *testing.T, sender *felt.Felt, calls ...rpc.InvokeFunctionCall,
+) *rpc.InvokeTxnV3 {
+	t.Helper()
+
+	calldata := account.FmtCallDataCairo2(snUtils.InvokeFuncCallsToFunctionCalls(calls))
+	return &snUtils.BuildInvokeTxn(
+		sender,
+		new(felt.Felt).SetUint64(1),
+		calldata,
+		rpc.ResourceBoundsMapping{
+			L1Gas:     rpc.ResourceBounds{MaxAmount: "0x0", MaxPricePerUnit: "0x10"},
+			L1DataGas: rpc.ResourceBounds{MaxAmount: "0x80", MaxPricePerUnit: "0x20"},
+			L2Gas:     rpc.ResourceBounds{MaxAmount: "0x100", MaxPricePerUnit: "0x30"},
+		},
+	).InvokeTxnV3
+}
+
+func attestCall(attestContract *felt.Felt) rpc.InvokeFunctionCall {
+	return rpc.InvokeFunctionCall{
+		ContractAddress: attestContract,
+		FunctionName:    ATTEST_ENTRY_POINT,
+		CallData:        []*felt.Felt{new(felt.Felt).SetUint64(0xb10c)},
+	}
+}
+
+func transferCall(to *felt.Felt) rpc.InvokeFunctionCall {
+	return rpc.InvokeFunctionCall{
+		ContractAddress: new(felt.Felt).SetUint64(0x5742),
+		FunctionName:    "transfer",
+		CallData:        []*felt.Felt{to, new(felt.Felt).SetUint64(1000), new(felt.Felt)},
+	}
+}
+
+func TestDefaultPolicy(t *testing.T) {
+	attestContract := feltFromString(t, "0xa77e57")
+	sender := feltFromString(t, "0x123")
+	chainId := new(felt.Felt).SetBytes([]byte("SN_SEPOLIA"))
+	policy := DefaultPolicy(attestContract)
+
+	require.Nil(t, policy.Evaluate(invokeTxn(t, sender, attestCall(attestContract)), chainId))
+
+	t.Run("Attest on another contract", func(t *testing.T) {
+		txn := invokeTxn(t, sender, attestCall(feltFromString(t, "0xbad")))
+		violation := policy.Evaluate(txn, chainId)
+		require.NotNil(t, violation)
+		require.Equal(t, RULE_ALLOWED_CALLS, violation.Rule)
+	})
+
+	t.Run("Transfer draining the account", func(t *testing.T) {
+		txn := invokeTxn(t, sender, transferCall(feltFromString(t, "0xbad")))
+		violation := policy.Evaluate(txn, chainId)
+		require.NotNil(t, violation)
+		require.Equal(t, RULE_ALLOWED_CALLS, violation.Rule)
+		require.Contains(t, violation.Message, "of contract 0x5742 is not allowed")
+	})
+
+	t.Run("Transfer hidden after an attest", func(t *testing.T) {
+		txn := invokeTxn(
+			t, sender, attestCall(attestContract), transferCall(feltFromString(t, "0xbad")),
+		)
+		violation := policy.Evaluate(txn, chainId)
+		require.NotNil(t, violation)
+		require.Equal(t, RULE_ALLOWED_CALLS, violation.Rule)
+	})
+
+	t.Run("Malformed calldata", func(t *testing.T) {
+		malformed := [][]*felt.Felt{
+			{},
+			{new(felt.Felt).SetUint64(0)},
+			{new(felt.Felt).SetUint64(2), attestContract, new(felt.Felt), new(felt.Felt)},
+			{new(felt.Felt).SetUint64(1), attestContract, new(felt.Felt), new(felt.Felt).SetUint64(5)},
+			// Extra felts after the last call
+			append(invokeTxn(t, sender, attestCall(attestContract)).Calldata, new(felt.Felt)),
+		}
+		for _, calldata := range malformed {
+			txn := invokeTxn(t, sender, attestCall(attestContract))
+			txn.Calldata = calldata
+
+			violation := policy.Evaluate(txn, chainId)
+			require.Equal(t, &PolicyViolation{
+				Rule:    RULE_ALLOWED_CALLS,
+				Message: "calldata is not a valid list of calls",
+			}, violation)
+		}
+	})
+}
+
+func TestPolicyFromData(t *testing.T) {
+	attestContract := feltFromString(t, "0xa77e57")
+	sender := feltFromString(t, "0x123")
+	sepolia := new(felt.Felt).SetBytes([]byte("SN_SEPOLIA"))
+
+	policy, err := PolicyFromData([]byte(`{
+		"senderAddresses": ["0x123"],
+		"maxCalldataLength": 6,
+		"maxResourceBounds": {
+			"l1_gas": {"max_amount": "0x0", "max_price_per_unit": ""},
+			"l1_data_gas": {"max_amount": "0x100", "max_price_per_unit": "0x20"},
+			"l2_gas": {"max_amount": "", "max_price_per_unit": "0x30"}
+		},
+		"maxTip": "0x10",
+		"chainIds": ["SN_SEPOLIA", "0x1"]
+	}`), attestContract)
+	require.NoError(t, err)
+
+	// Allowed calls default to attest on the attestation contract
+	require.Equal(t, DefaultPolicy(attestContract).AllowedCalls, policy.AllowedCalls)
+	require.Nil(t, policy.Evaluate(invokeTxn(t, sender, attestCall(attestContract)), sepolia))
+	require.Nil(t, policy.Evaluate(
+		invokeTxn(t, sender, attestCall(attestContract)), new(felt.Felt).SetUint64(1),
+	))
+
+	tests := []struct {
+		description string
+		update      func(txn *rpc.InvokeTxnV3, chainId **felt.Felt)
+		violation   PolicyViolation
+	}{
+		{
+			description: "Chain id not allowed",
+			update: func(_ *rpc.InvokeTxnV3, chainId **felt.Felt) {
+				*chainId = new(felt.Felt).SetBytes([]byte("SN_MAIN"))
+			},
+			violation: PolicyViolation{
+				Rule:    RULE_CHAIN_ID,
+				Message: "chain id 0x534e5f4d41494e is not allowed",
+			},
+		},
+		{
+			description: "Sender address not allowed",
+			update: func(txn *rpc.InvokeTxnV3, _ **felt.Felt) {
+				txn.SenderAddress = new(felt.Felt).SetUint64(0x456)
+			},
+			violation: PolicyViolation{
+				Rule:    RULE_SENDER_ADDRESS,
+				Message: "sender address 0x456 is not allowed",
+			},
+		},
+		{
+			description: "Calldata too long",
+			update: func(txn *rpc.InvokeTxnV3, _ **felt.Felt) {
+				txn.Calldata = append(txn.Calldata, new(felt.Felt), new(felt.Felt))
+			},
+			violation: PolicyViolation{
+				Rule:    RULE_MAX_CALLDATA_LENGTH,
+				Message: "calldata length 7 is above the max of 6",
+			},
+		},
+		{
+			description: "Resource bound above its cap",
+			update: func(txn *rpc.InvokeTxnV3, _ **felt.Felt) {
+				txn.ResourceBounds.L2Gas.MaxPricePerUnit = "0x31"
+			},
+			violation: PolicyViolation{
+				Rule:    RULE_MAX_RESOURCE_BOUNDS,
+				Message: "l2_gas max price per unit 0x31 is above the cap of 0x30",
+			},
+		},
+		{
+			description: "Invalid resource bound",
+			update: func(txn *rpc.InvokeTxnV3, _ **felt.Felt) {
+				txn.ResourceBounds.L1DataGas.MaxAmount = "lots"
+			},
+			violation: PolicyViolation{
+				Rule:    RULE_MAX_RESOURCE_BOUNDS,
+				Message: "invalid l1_data_gas max amount `lots`",
+			},
+		},
+		{
+			description: "Tip above its max",
+			update: func(txn *rpc.InvokeTxnV3, _ **felt.Felt) {
+				txn.Tip = "0x11"
+			},
+			violation: PolicyViolation{
+				Rule:    RULE_MAX_TIP,
+				Message: "tip 0x11 is above the max of 0x10",
+			},
+		},
+		{
+			description: "Invalid tip",
+			update: func(txn *rpc.InvokeTxnV3, _ **felt.Felt) {
+				txn.Tip = "lots"
+			},
+			violation: PolicyViolation{
+				Rule:    RULE_MAX_TIP,
+				Message: "invalid tip `lots`",
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			txn := invokeTxn(t, sender, attestCall(attestContract))
+			chainId := sepolia
+			test.update(txn, &chainId)
+
+			require.Equal(t, &test.violation, policy.Evaluate(txn, chainId))
+		})
+	}
+
+	t.Run("Allowed calls by name or selector", func(t *testing.T) {
+		policy, err := PolicyFromData([]byte(`{
+			"allowedCalls": [
+				{"contract": "0x5742", "selector": "transfer"},
+				{"contract": "0xa77e57", "selector": "`+
+			snUtils.GetSelectorFromNameFelt(ATTEST_ENTRY_POINT).String()+`"}
+			]
+		}`), feltFromString(t, "0x1"))
+		require.NoError(t, err)
+
+		txn := invokeTxn(t, sender, attestCall(attestContract), transferCall(sender))
+		require.Nil(t, policy.Evaluate(txn, sepolia))
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		invalid := map[string]string{
+			`{"maxCalldataLen": 5}`:                                           "unknown field \"maxCalldataLen\"",
+			`{"maxCalldataLength": -1}`:                                       "max calldata length can't be negative",
+			`{"allowedCalls": [{"contract": "0x1"}]}`:                         "need a contract and a selector",
+			`{"chainIds": ["0xnothex"]}`:                                      "invalid chain id `0xnothex`",
+			`{"maxTip": "lots"}`:                                              "invalid max tip `lots`",
+			`{"allowedCalls": [{"contract": "0x1", "selector": "0xattest"}]}`: "invalid selector `0xattest`",
+			`{"senderAddresses": ["not an address"]}`:                         "invalid policy",
+			`{"maxResourceBounds": {"l1_gas": {"max_amount": "ten"}}}`:        "invalid l1_gas max amount cap `ten`",
+		}
+		for data, expectedErr := range invalid {
+			policy, err := PolicyFromData([]byte(data), attestContract)
+			require.Zero(t, policy, data)
+			require.ErrorContains(t, err, expectedErr, data)
+		}
+
+		// Without the attestation contract the allowed calls can't default to attest
+		policy, err := PolicyFromData([]byte(`{"maxCalldataLength": 5}`), nil)
+		require.Zero(t, policy)
+		require.EqualError(
+			t, err, "invalid policy: no allowed calls set and no attestation contract given",
+		)
+	})
+}
+
+func TestHandlerRejectsPolicyViolations(t *testing.T) {
+	attestContract := feltFromString(t, "0xa77e57")
+	signer, err := New(
+		memoryKeyBackend(t, "0x123"),
+		Auth{},
+		DefaultPolicy(attestContract),
+		nil,
+		utils.NewNopZapLogger(),
+	)
+	require.NoError(t, err)
+
+	send := func(t *testing.T, call rpc.InvokeFunctionCall) *httptest.ResponseRecorder {
+		t.Helper()
+
+		body, err := json.Marshal(Request{
+			InvokeTxnV3: invokeTxn(t, feltFromString(t, "0x123"), call),
+			ChainId:     new(felt.Felt).SetBytes([]byte("SN_SEPOLIA")),
+		})
+		require.NoError(t, err)
+
+		recorder := httptest.NewRecorder()
+		signer.handler(
+			recorder, httptest.NewRequest(http.MethodPost, SIGN_ENDPOINT, bytes.NewReader(body)),
+		)
+		return recorder
+	}
+
+	recorder := send(t, transferCall(feltFromString(t, "0xbad")))
+	require.Equal(t, http.StatusForbidden, recorder.Code)
+	require.Equal(t, "application/json", recorder.Header().Get("Content-Type"))
+
+	var resp PolicyViolationResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &resp))
+	require.Equal(t, "transaction rejected by the signer policy", resp.Error)
+	require.Equal(t, RULE_ALLOWED_CALLS, resp.Rule)
+	require.Contains(t, resp.Message, "is not allowed")
+
+	recorder = send(t, attestCall(attestContract))
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var signResp Response
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &signResp))
+	require.NotNil(t, signResp.Signature[0])
+}
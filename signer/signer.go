@@ -2,32 +2,103 @@ package signer
 
 import (
 	"context"
+	"crypto/tls"
 	"encoding/json"
 	"fmt"
 	"io"
-	"math/big"
+	"net"
 	"net/http"
+	"time"
 
 	"github.com/NethermindEth/juno/core/felt"
 	"github.com/NethermindEth/juno/utils"
-	"github.com/NethermindEth/starknet.go/account"
-	"github.com/NethermindEth/starknet.go/curve"
 	"github.com/NethermindEth/starknet.go/hash"
 	"github.com/NethermindEth/starknet.go/rpc"
 	"github.com/cockroachdb/errors"
 )
 
-const SIGN_ENDPOINT = "/sign"
+const (
+	// Unversioned signing endpoint, kept for the signers and validators predating the
+	// versioned ones. It follows the v1 protocol
+	SIGN_ENDPOINT       = "/sign"
+	SIGN_V1_ENDPOINT    = "/v1/sign"
+	SIGN_V2_ENDPOINT    = "/v2/sign"
+	PUBLIC_KEY_ENDPOINT = "/public_key"
+	ADMIN_KEYS_ENDPOINT = "/admin/keys"
+	HEALTH_ENDPOINT     = "/health"
+	READY_ENDPOINT      = "/ready"
+	METRICS_ENDPOINT    = "/metrics"
+)
+
+// Limits of the http server of the signer. The write timeout leaves room for key backends
+// signing remotely
+const (
+	SERVER_READ_HEADER_TIMEOUT = 5 * time.Second
+	SERVER_READ_TIMEOUT        = 10 * time.Second
+	SERVER_WRITE_TIMEOUT       = 30 * time.Second
+	SERVER_IDLE_TIMEOUT        = 2 * time.Minute
+	// Largest request body accepted, far above the size of an attest transaction
+	MAX_REQUEST_BODY_SIZE = 1 << 20
+)
 
+// Versions of the signing protocol
+const (
+	PROTOCOL_V1 = "v1"
+	PROTOCOL_V2 = "v2"
+)
+
+// What a v2 signing request asks to sign
+const (
+	// The transaction, which the signer hashes and checks against its policy
+	SIGN_MODE_TRANSACTION = "transaction"
+	// A hash computed by the client, for signers that don't understand transactions
+	SIGN_MODE_HASH = "hash"
+)
+
+// Query parameter of the public key endpoint with the account whose key is requested
+const PUBLIC_KEY_ADDRESS_PARAM = "address"
+
+// Body of a `POST` request at `<address>/v1/sign` or `<address>/sign`
 type Request struct {
 	*rpc.InvokeTxnV3 `json:"transaction"`
 	ChainId          *felt.Felt `json:"chain_id"`
 }
 
+// Answer to a v1 signing request, the ECDSA `r` and `s` signature values
 type Response struct {
 	Signature [2]*felt.Felt `json:"signature"`
 }
 
+// Body of a `POST` request at `<address>/v2/sign`
+type RequestV2 struct {
+	// One of the `SIGN_MODE_*` values, defaults to `transaction`
+	Mode string `json:"mode,omitempty"`
+	// Transaction to sign and the chain it's sent to, in `transaction` mode
+	Transaction *rpc.InvokeTxnV3 `json:"transaction,omitempty"`
+	ChainId     *felt.Felt       `json:"chain_id,omitempty"`
+	// Hash to sign and the account it's signed for, in `hash` mode
+	Hash          *felt.Felt `json:"hash,omitempty"`
+	SenderAddress *felt.Felt `json:"sender_address,omitempty"`
+}
+
+// Answer to a v2 signing request with the signed hash. The signature can have any length,
+// as accounts other than the standard ones expect signatures other than `r` and `s`.
+// A v1 response decodes into it
+type ResponseV2 struct {
+	Hash      *felt.Felt   `json:"hash,omitempty"`
+	Signature []*felt.Felt `json:"signature"`
+}
+
+// Answer to a `GET` request at `<address>/public_key`
+type PublicKeyResponse struct {
+	PublicKey *felt.Felt `json:"public_key"`
+}
+
+// Answer to a `GET` request at `<address>/admin/keys`
+type KeysResponse struct {
+	Keys []KeyInfo `json:"keys"`
+}
+
 func (r *Response) String() string {
 	return fmt.Sprintf(
 		`{r: %s, s: %s}`,
@@ -37,69 +108,195 @@ func (r *Response) String() string {
 }
 
 type Signer struct {
-	logger    *utils.ZapLogger
-	keyStore  *account.MemKeystore
-	publicKey string
+	logger *utils.ZapLogger
+	keys   keySet
+	auth   *authVerifier
+	policy Policy
+	// Records every request, disabled when nil
+	auditLog *AuditLog
+	// Credentials of the admin endpoints, which are disabled when nil
+	adminAuth *authVerifier
+	// Whether bare hashes are signed, which bypasses the policy
+	hashSigning bool
+	// Serves the requests once listening, shared by the copies of the signer
+	server  *http.Server
+	metrics *Metrics
 }
 
-func New(privateKey string, logger *utils.ZapLogger) (Signer, error) {
-	privKey, ok := new(big.Int).SetString(privateKey, 0)
-	if !ok {
-		return Signer{}, errors.Errorf("Cannot turn private key %s into a big int", privateKey)
+// Creates a signer signing the transactions of every sender with the key held by `keys`,
+// whose public key is read once here
+func New(
+	keys KeyBackend, auth Auth, policy Policy, auditLog *AuditLog, logger *utils.ZapLogger,
+) (Signer, error) {
+	key, err := loadKey(keys)
+	if err != nil {
+		return Signer{}, err
 	}
 
-	publicKey, _, err := curve.Curve.PrivateToPoint(privKey)
+	return Signer{
+		logger:   logger,
+		keys:     keySet{defaultKey: key},
+		auth:     newAuthVerifier(auth),
+		policy:   policy,
+		auditLog: auditLog,
+		server:   newServer(),
+		metrics:  NewMetrics(),
+	}, nil
+}
+
+// Creates a signer signing the transactions of each account with its own key.
+// Transactions from any other sender are rejected
+func NewMultiKey(
+	accountKeys []AccountKey,
+	auth Auth,
+	policy Policy,
+	auditLog *AuditLog,
+	logger *utils.ZapLogger,
+) (Signer, error) {
+	keys, err := newAccountKeySet(accountKeys)
 	if err != nil {
-		return Signer{}, errors.New("Cannot derive public key from private key")
+		return Signer{}, err
 	}
 
-	publicKeyStr := publicKey.String()
-	ks := account.SetNewMemKeystore(publicKeyStr, privKey)
-
 	return Signer{
-		logger:    logger,
-		keyStore:  ks,
-		publicKey: publicKeyStr,
+		logger:   logger,
+		keys:     keys,
+		auth:     newAuthVerifier(auth),
+		policy:   policy,
+		auditLog: auditLog,
+		server:   newServer(),
+		metrics:  NewMetrics(),
 	}, nil
 }
 
-// Listen for requests of the type `POST` at `<address>/sign`. The request
-// should include the hash of the transaction being signed.
-func (s *Signer) Listen(address string) error {
-	http.HandleFunc(SIGN_ENDPOINT, s.handler)
+// Serves the keys of the signer at `<address>/admin/keys` to the requests carrying
+// `secret` as bearer token. Admin endpoints are disabled until this is called
+func (s *Signer) EnableAdmin(secret string) error {
+	auth, err := NewAuth(string(AuthBearer), secret)
+	if err != nil {
+		return errors.Errorf("invalid admin credentials: %w", err)
+	}
+	s.adminAuth = newAuthVerifier(auth)
+	return nil
+}
+
+// Signs the hashes sent in `hash` mode to `<address>/v2/sign`. The policy can't be applied
+// to them, so they are rejected until this is called
+func (s *Signer) EnableHashSigning() {
+	s.hashSigning = true
+}
+
+func newServer() *http.Server {
+	return &http.Server{
+		ReadHeaderTimeout: SERVER_READ_HEADER_TIMEOUT,
+		ReadTimeout:       SERVER_READ_TIMEOUT,
+		WriteTimeout:      SERVER_WRITE_TIMEOUT,
+		IdleTimeout:       SERVER_IDLE_TIMEOUT,
+	}
+}
+
+// Returns the handler of the signer endpoints, so the signer can be served by other
+// servers. Request bodies larger than `MAX_REQUEST_BODY_SIZE` are refused
+func (s *Signer) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc(SIGN_ENDPOINT, s.handler)
+	mux.HandleFunc(SIGN_V1_ENDPOINT, s.handler)
+	mux.HandleFunc(SIGN_V2_ENDPOINT, s.handlerV2)
+	mux.HandleFunc(PUBLIC_KEY_ENDPOINT, s.publicKeyHandler)
+	mux.HandleFunc(HEALTH_ENDPOINT, s.healthHandler)
+	mux.HandleFunc(READY_ENDPOINT, s.readyHandler)
+	mux.Handle(METRICS_ENDPOINT, s.metrics.Handler())
+	if s.adminAuth != nil {
+		mux.HandleFunc(ADMIN_KEYS_ENDPOINT, s.adminKeysHandler)
+	}
+	return http.MaxBytesHandler(mux, MAX_REQUEST_BODY_SIZE)
+}
+
+// Listen for requests of the type `POST` at `<address>/v1/sign` and `<address>/v2/sign`,
+// and at `<address>/sign` for v1 clients predating the versioned endpoints. The request
+// should include the transaction being signed, or its hash in v2 `hash` mode, and, unless
+// the signer was created without authentication, the credentials described by its `Auth`.
+// Transactions that break the signer's policy, or whose sender the signer has no key of,
+// are rejected with a 403.
+// The public key is served to anyone at `<address>/public_key`, as are the liveness and
+// readiness checks at `<address>/health` and `<address>/ready`, and the Prometheus metrics
+// at `<address>/metrics`.
+// If `tlsConfig` is not nil, requests are served over TLS.
+// It blocks until the server fails or is shut down, in which case it returns nil
+func (s *Signer) Listen(address string, tlsConfig *tls.Config) error {
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return err
+	}
+	return s.Serve(listener, tlsConfig)
+}
 
-	s.logger.Infof("Server running at %s", address)
+// Same as `Listen`, accepting the connections of `listener`
+func (s *Signer) Serve(listener net.Listener, tlsConfig *tls.Config) error {
+	s.server.Handler = s.Handler()
 
-	return http.ListenAndServe(address, nil)
+	var err error
+	if tlsConfig == nil {
+		s.logger.Infof("Server running at %s", listener.Addr())
+		err = s.server.Serve(listener)
+	} else {
+		s.server.TLSConfig = tlsConfig
+		s.logger.Infow(
+			"Server running with TLS",
+			"address", listener.Addr(),
+			"client certificates required", tlsConfig.ClientAuth == tls.RequireAndVerifyClientCert,
+		)
+		// The certificates are already part of the TLS config
+		err = s.server.ServeTLS(listener, "", "")
+	}
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
+
+// Stops accepting requests and waits for the ones being signed to be answered,
+// until `ctx` is done
+func (s *Signer) Shutdown(ctx context.Context) error {
+	s.logger.Info("Shutting down the server")
+	return s.server.Shutdown(ctx)
 }
 
-// Decodes the request and returns ECDSA `r` and `s` signature values via http
+// Decodes a v1 request and returns ECDSA `r` and `s` signature values via http.
+// Every request is recorded in the audit log along with the signer's decision
 func (s *Signer) handler(w http.ResponseWriter, r *http.Request) {
 	s.logger.Debug("Receiving http request")
 
 	defer func() { _ = r.Body.Close() }()
 
-	body, err := io.ReadAll(r.Body)
-	if err != nil {
-		http.Error(w, "Failed to read request body: "+err.Error(), http.StatusBadRequest)
+	entry := AuditEntry{Time: time.Now(), CallerAddress: r.RemoteAddr}
+	defer s.recordRequest(r.URL.Path, &entry)
+
+	body, ok := s.readRequest(w, r, &entry)
+	if !ok {
 		return
 	}
 
 	var req Request
 	if err := json.Unmarshal(body, &req); err != nil {
+		_ = s.audit(&entry, AuditInvalidRequest, err.Error())
 		http.Error(w, "Failed to decode request body: "+err.Error(), http.StatusBadRequest)
 		return
 	}
+	if req.InvokeTxnV3 == nil || req.ChainId == nil {
+		_ = s.audit(&entry, AuditInvalidRequest, "missing transaction or chain id")
+		http.Error(w, "Missing transaction or chain id", http.StatusBadRequest)
+		return
+	}
 
-	signature, err := s.hashAndSign(req.InvokeTxnV3, req.ChainId)
-	if err != nil {
-		http.Error(w, "Failed to sign tx: "+err.Error(), http.StatusInternalServerError)
+	_, signature, ok := s.signTransaction(w, r, &entry, req.InvokeTxnV3, req.ChainId, true)
+	if !ok {
 		return
 	}
 
-	resp := Response{Signature: signature}
+	resp := Response{Signature: [2]*felt.Felt{signature[0], signature[1]}}
 	w.Header().Set("Content-Type", "application/json")
-	err = json.NewEncoder(w).Encode(resp)
+	err := json.NewEncoder(w).Encode(resp)
 	if err != nil {
 		s.logger.Errorf("Error encoding response %s: %s", resp, err)
 		return
@@ -108,26 +305,401 @@ func (s *Signer) handler(w http.ResponseWriter, r *http.Request) {
 	s.logger.Debugw("Answered http request", "response", resp)
 }
 
-// Given a transaction hash returns the ECDSA `r` and `s` signature values
-func (s *Signer) hashAndSign(invokeTxnV3 *rpc.InvokeTxnV3, chainId *felt.Felt) ([2]*felt.Felt, error) {
+// Decodes a v2 request and returns the signed hash along with its signature via http.
+// Every request is recorded in the audit log along with the signer's decision
+func (s *Signer) handlerV2(w http.ResponseWriter, r *http.Request) {
+	s.logger.Debug("Receiving http request")
+
+	defer func() { _ = r.Body.Close() }()
+
+	entry := AuditEntry{Time: time.Now(), CallerAddress: r.RemoteAddr}
+	defer s.recordRequest(r.URL.Path, &entry)
+
+	body, ok := s.readRequest(w, r, &entry)
+	if !ok {
+		return
+	}
+
+	var req RequestV2
+	if err := json.Unmarshal(body, &req); err != nil {
+		_ = s.audit(&entry, AuditInvalidRequest, err.Error())
+		http.Error(w, "Failed to decode request body: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var txHash *felt.Felt
+	var signature []*felt.Felt
+	switch req.Mode {
+	case "", SIGN_MODE_TRANSACTION:
+		if req.Transaction == nil || req.ChainId == nil {
+			_ = s.audit(&entry, AuditInvalidRequest, "missing transaction or chain id")
+			http.Error(w, "Missing transaction or chain id", http.StatusBadRequest)
+			return
+		}
+		txHash, signature, ok = s.signTransaction(
+			w, r, &entry, req.Transaction, req.ChainId, false,
+		)
+	case SIGN_MODE_HASH:
+		if req.Hash == nil {
+			_ = s.audit(&entry, AuditInvalidRequest, "missing hash")
+			http.Error(w, "Missing hash", http.StatusBadRequest)
+			return
+		}
+		txHash = req.Hash
+		signature, ok = s.signHash(w, r, &entry, req.Hash, req.SenderAddress)
+	default:
+		reason := fmt.Sprintf(
+			"unknown sign mode `%s`, expected either `%s` or `%s`",
+			req.Mode, SIGN_MODE_TRANSACTION, SIGN_MODE_HASH,
+		)
+		_ = s.audit(&entry, AuditInvalidRequest, reason)
+		http.Error(w, "Invalid request: "+reason, http.StatusBadRequest)
+		return
+	}
+	if !ok {
+		return
+	}
+
+	resp := ResponseV2{Hash: txHash, Signature: signature}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		s.logger.Errorf("Error encoding response %v: %s", resp, err)
+		return
+	}
+
+	s.logger.Debugw("Answered http request", "response", resp)
+}
+
+// Reads the body of a signing request and checks its credentials. When they can't be
+// verified, the error is answered and false returned
+func (s *Signer) readRequest(
+	w http.ResponseWriter, r *http.Request, entry *AuditEntry,
+) ([]byte, bool) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		_ = s.audit(entry, AuditInvalidRequest, err.Error())
+		status := http.StatusBadRequest
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			status = http.StatusRequestEntityTooLarge
+		}
+		http.Error(w, "Failed to read request body: "+err.Error(), status)
+		return nil, false
+	}
+
+	if err := s.auth.verify(r, body, time.Now()); err != nil {
+		s.logger.Warnw("Rejected unauthorized request", "remote address", r.RemoteAddr, "error", err)
+		_ = s.audit(entry, AuditUnauthorized, err.Error())
+		http.Error(w, "Unauthorized: "+err.Error(), http.StatusUnauthorized)
+		return nil, false
+	}
+	return body, true
+}
+
+// Signs the transaction if its sender has a key and it follows the policy. Otherwise,
+// or if signing fails, the error is answered and false returned. With `rsOnly`, only
+// signatures made of the `r` and `s` values are handed out, as v1 answers can't hold others
+func (s *Signer) signTransaction(
+	w http.ResponseWriter,
+	r *http.Request,
+	entry *AuditEntry,
+	txn *rpc.InvokeTxnV3,
+	chainId *felt.Felt,
+	rsOnly bool,
+) (*felt.Felt, []*felt.Felt, bool) {
+	entry.ChainId = chainId
+	entry.SenderAddress = txn.SenderAddress
+	entry.Nonce = txn.Nonce
+	// Calldata that can't be decoded is reported by the policy when calls are restricted
+	entry.Calls, _ = decodeCalls(txn.Calldata)
+
+	key, ok := s.senderKey(w, entry, txn.SenderAddress)
+	if !ok {
+		return nil, nil, false
+	}
+
+	if violation := s.policy.Evaluate(txn, chainId); violation != nil {
+		s.logger.Warnw(
+			"Rejected transaction violating the policy",
+			"rule", violation.Rule,
+			"reason", violation.Message,
+			"sender address", txn.SenderAddress,
+			"chain id", chainId,
+		)
+		_ = s.audit(entry, AuditRejected, violation.Error())
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusForbidden)
+		resp := PolicyViolationResponse{
+			Error:           "transaction rejected by the signer policy",
+			PolicyViolation: *violation,
+		}
+		if err := json.NewEncoder(w).Encode(resp); err != nil {
+			s.logger.Errorf("Error encoding policy violation %s: %s", violation, err)
+		}
+		return nil, nil, false
+	}
+
+	txHash, signature, err := s.hashAndSign(r.Context(), key, txn, chainId)
+	entry.TransactionHash = txHash
+	if err != nil {
+		_ = s.audit(entry, AuditFailed, err.Error())
+		http.Error(w, "Failed to sign tx: "+err.Error(), http.StatusInternalServerError)
+		return nil, nil, false
+	}
+	if rsOnly && len(signature) != 2 {
+		reason := fmt.Sprintf(
+			"the signature has %d values, only `r` and `s` signatures can be answered by v1",
+			len(signature),
+		)
+		_ = s.audit(entry, AuditFailed, reason)
+		http.Error(w, "Failed to sign tx: "+reason, http.StatusInternalServerError)
+		return nil, nil, false
+	}
+
+	if !s.recordSignature(w, entry, signature) {
+		return nil, nil, false
+	}
+	return txHash, signature, true
+}
+
+// Signs the hash for `sender` if hash signing is enabled and the sender has a key.
+// Otherwise, or if signing fails, the error is answered and false returned
+func (s *Signer) signHash(
+	w http.ResponseWriter,
+	r *http.Request,
+	entry *AuditEntry,
+	txHash *felt.Felt,
+	sender *felt.Felt,
+) ([]*felt.Felt, bool) {
+	entry.SenderAddress = sender
+	entry.TransactionHash = txHash
+
+	if !s.hashSigning {
+		reason := "hash signing is disabled, the policy can't be applied to a bare hash"
+		s.logger.Warnw("Rejected hash signing request", "sender address", sender)
+		_ = s.audit(entry, AuditRejected, reason)
+		http.Error(w, "Forbidden: "+reason, http.StatusForbidden)
+		return nil, false
+	}
+
+	key, ok := s.senderKey(w, entry, sender)
+	if !ok {
+		return nil, false
+	}
+
+	s.logger.Infow("Signing hash", "hash", txHash, "sender address", sender)
+	signature, err := s.signWithKey(r.Context(), key, txHash, sender)
+	if err != nil {
+		_ = s.audit(entry, AuditFailed, err.Error())
+		http.Error(w, "Failed to sign hash: "+err.Error(), http.StatusInternalServerError)
+		return nil, false
+	}
+
+	if !s.recordSignature(w, entry, signature) {
+		return nil, false
+	}
+	return signature, true
+}
+
+// Returns the key signing for `sender`. When there is none, the request is rejected and
+// false returned
+func (s *Signer) senderKey(
+	w http.ResponseWriter, entry *AuditEntry, sender *felt.Felt,
+) (*loadedKey, bool) {
+	key := s.keys.forSender(sender)
+	if key == nil {
+		s.logger.Warnw("Rejected transaction from unknown sender", "sender address", sender)
+		reason := fmt.Sprintf("no key for sender address %s", sender)
+		_ = s.audit(entry, AuditRejected, reason)
+		http.Error(w, "Unknown sender: "+reason, http.StatusForbidden)
+		return nil, false
+	}
+	return key, true
+}
+
+// Records the signature in the audit log. A signature that can't be recorded is never
+// handed out, so the error is answered and false returned
+func (s *Signer) recordSignature(
+	w http.ResponseWriter, entry *AuditEntry, signature []*felt.Felt,
+) bool {
+	entry.Signature = signature
+	if err := s.audit(entry, AuditSigned, ""); err != nil {
+		// The signature is withheld
+		entry.Decision = AuditFailed
+		http.Error(w, "Failed to record signature in the audit log", http.StatusInternalServerError)
+		return false
+	}
+	return true
+}
+
+// Returns the public key the signer signs the transactions of the account in the `address`
+// query parameter with, so the validator can check it controls the account. The parameter
+// is only required when the signer has a key per account.
+// It's public information, so no credentials are required
+func (s *Signer) publicKeyHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var address *felt.Felt
+	if addressStr := r.URL.Query().Get(PUBLIC_KEY_ADDRESS_PARAM); addressStr != "" {
+		var err error
+		address, err = new(felt.Felt).SetString(addressStr)
+		if err != nil {
+			http.Error(w, "Invalid account address: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+	} else if s.keys.defaultKey == nil {
+		http.Error(w, "Missing account address", http.StatusBadRequest)
+		return
+	}
+
+	key := s.keys.forSender(address)
+	if key == nil {
+		http.Error(w, "No key for account "+address.String(), http.StatusNotFound)
+		return
+	}
+
+	resp := PublicKeyResponse{PublicKey: key.publicKey}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		s.logger.Errorf("Error encoding public key %s: %s", resp.PublicKey, err)
+	}
+}
+
+// Lists the keys of the signer along with the account each one signs for
+func (s *Signer) adminKeysHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if err := s.adminAuth.verify(r, nil, time.Now()); err != nil {
+		s.logger.Warnw("Rejected unauthorized admin request", "remote address", r.RemoteAddr, "error", err)
+		http.Error(w, "Unauthorized: "+err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	resp := KeysResponse{Keys: s.Keys()}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		s.logger.Errorf("Error encoding keys: %s", err)
+	}
+}
+
+// Answers whether the signer is running
+func (s *Signer) healthHandler(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write([]byte("OK")); err != nil {
+		s.logger.Errorf("Failed to write health check response: %v", err)
+	}
+}
+
+// Answers whether the signer can sign: its policy is valid and every key backend still
+// holds the key loaded at startup. Otherwise it answers 503 with the reason
+func (s *Signer) readyHandler(w http.ResponseWriter, r *http.Request) {
+	if err := s.Ready(r.Context()); err != nil {
+		http.Error(w, "Not ready: "+err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write([]byte("OK")); err != nil {
+		s.logger.Errorf("Failed to write readiness check response: %v", err)
+	}
+}
+
+// Returns why the signer can't sign, nil when it's ready to
+func (s *Signer) Ready(ctx context.Context) error {
+	if err := s.policy.Check(); err != nil {
+		return err
+	}
+	return s.keys.check(ctx)
+}
+
+// Returns the public key of a signer created with a single key, nil when it has a key
+// per account
+func (s *Signer) PublicKey() *felt.Felt {
+	if s.keys.defaultKey == nil {
+		return nil
+	}
+	return s.keys.defaultKey.publicKey
+}
+
+// Returns the keys of the signer sorted by the account they sign for
+func (s *Signer) Keys() []KeyInfo {
+	return s.keys.info()
+}
+
+// Records the decision taken on a request in the audit log, if there is one. Unauthorized
+// and invalid requests are only counted in a summary, since anyone can send them.
+// Errors are already logged, callers only need them to withhold a signature
+func (s *Signer) audit(entry *AuditEntry, decision AuditDecision, reason string) error {
+	entry.Decision = decision
+	entry.Reason = reason
+	if s.auditLog == nil {
+		return nil
+	}
+
+	write := s.auditLog.Append
+	if decision == AuditUnauthorized || decision == AuditInvalidRequest {
+		write = s.auditLog.Summarize
+	}
+	if err := write(entry); err != nil {
+		s.logger.Errorw("Failed to write audit log", "decision", decision, "error", err)
+		return err
+	}
+	return nil
+}
+
+// Signs the hash with the key of `sender`, measuring how long the key backend takes
+func (s *Signer) signWithKey(
+	ctx context.Context, key *loadedKey, hash *felt.Felt, sender *felt.Felt,
+) ([]*felt.Felt, error) {
+	start := time.Now()
+	defer func() { s.metrics.ObserveKeyBackendLatency(s.senderLabel(sender), time.Since(start)) }()
+
+	return key.keys.SignHash(ctx, hash)
+}
+
+// Records the decision taken on a signing request in the metrics
+func (s *Signer) recordRequest(endpoint string, entry *AuditEntry) {
+	s.metrics.RecordRequest(
+		endpoint, entry.Decision, s.senderLabel(entry.SenderAddress), time.Since(entry.Time),
+	)
+}
+
+// Only the accounts the signer has a key for or its policy allows are labelled with their
+// address in the metrics. Any other sender, or an unknown one, shares the same label
+func (s *Signer) senderLabel(sender *felt.Felt) string {
+	if sender == nil {
+		return unknownSenderLabel
+	}
+	if _, ok := s.keys.accounts[*sender]; ok || containsFelt(s.policy.SenderAddresses, sender) {
+		return sender.String()
+	}
+	return unknownSenderLabel
+}
+
+// Given a transaction returns its hash and its signature
+func (s *Signer) hashAndSign(
+	ctx context.Context, key *loadedKey, invokeTxnV3 *rpc.InvokeTxnV3, chainId *felt.Felt,
+) (*felt.Felt, []*felt.Felt, error) {
 	s.logger.Infow("Signing transaction", "transaction", invokeTxnV3, "chainId", chainId)
 
 	hash, err := hash.TransactionHashInvokeV3(invokeTxnV3, chainId)
 	if err != nil {
-		return [2]*felt.Felt{}, err
+		return nil, nil, err
 	}
 
-	hashBig := hash.BigInt(new(big.Int))
-
-	s1, s2, err := s.keyStore.Sign(context.Background(), s.publicKey, hashBig)
+	signature, err := s.signWithKey(ctx, key, hash, invokeTxnV3.SenderAddress)
 	if err != nil {
-		return [2]*felt.Felt{}, err
+		return hash, nil, err
 	}
 
-	s.logger.Debugw("Signature", "r", s1, "s", s2)
+	s.logger.Debugw("Signature", "signature", signature)
 
-	return [2]*felt.Felt{
-		new(felt.Felt).SetBigInt(s1),
-		new(felt.Felt).SetBigInt(s2),
-	}, nil
+	return hash, signature, nil
 }
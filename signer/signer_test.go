@@ -0,0 +1,446 @@
+package signer
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/NethermindEth/juno/core/felt"
+	"github.com/NethermindEth/juno/utils"
+	"github.com/NethermindEth/starknet.go/hash"
+	"github.com/NethermindEth/starknet.go/rpc"
+	"github.com/cockroachdb/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPublicKeyHandler(t *testing.T) {
+	auth, err := NewAuth(string(AuthBearer), "secret")
+	require.NoError(t, err)
+	signer, err := New(memoryKeyBackend(t, "0x123"), auth, Policy{}, nil, utils.NewNopZapLogger())
+	require.NoError(t, err)
+
+	// This is the public key for private key "0x123"
+	expectedPublicKey := feltFromString(
+		t, "0x566d69d8c99f62bc71118399bab25c1f03719463eab8d6a444cd11ece131616",
+	)
+	require.Equal(t, expectedPublicKey, signer.PublicKey())
+
+	t.Run("Served without credentials", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+		signer.publicKeyHandler(
+			recorder, httptest.NewRequest(http.MethodGet, PUBLIC_KEY_ENDPOINT, http.NoBody),
+		)
+		require.Equal(t, http.StatusOK, recorder.Code)
+		require.Equal(t, "application/json", recorder.Header().Get("Content-Type"))
+
+		var resp PublicKeyResponse
+		require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &resp))
+		require.Equal(t, expectedPublicKey, resp.PublicKey)
+	})
+
+	t.Run("Only GET requests", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+		signer.publicKeyHandler(
+			recorder, httptest.NewRequest(http.MethodPost, PUBLIC_KEY_ENDPOINT, http.NoBody),
+		)
+		require.Equal(t, http.StatusMethodNotAllowed, recorder.Code)
+		require.Equal(t, http.MethodGet, recorder.Header().Get("Allow"))
+	})
+}
+
+func TestHandlerV2(t *testing.T) {
+	attestContract := feltFromString(t, "0xa77e57")
+	keys := memoryKeyBackend(t, "0x123")
+	signer, err := New(keys, Auth{}, DefaultPolicy(attestContract), nil, utils.NewNopZapLogger())
+	require.NoError(t, err)
+
+	send := func(t *testing.T, req RequestV2) *httptest.ResponseRecorder {
+		t.Helper()
+
+		body, err := json.Marshal(req)
+		require.NoError(t, err)
+		recorder := httptest.NewRecorder()
+		signer.handlerV2(
+			recorder, httptest.NewRequest(http.MethodPost, SIGN_V2_ENDPOINT, bytes.NewReader(body)),
+		)
+		return recorder
+	}
+
+	txn := invokeTxn(t, feltFromString(t, "0x123"), attestCall(attestContract))
+	chainId := new(felt.Felt).SetBytes([]byte("SN_SEPOLIA"))
+	txHash, err := hash.TransactionHashInvokeV3(txn, chainId)
+	require.NoError(t, err)
+	expectedSignature, err := keys.SignHash(t.Context(), txHash)
+	require.NoError(t, err)
+
+	t.Run("Transaction mode returns the hash", func(t *testing.T) {
+		recorder := send(t, RequestV2{Transaction: txn, ChainId: chainId})
+		require.Equal(t, http.StatusOK, recorder.Code)
+
+		var resp ResponseV2
+		require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &resp))
+		require.Equal(t, txHash, resp.Hash)
+		require.Equal(t, expectedSignature, resp.Signature)
+
+		recorder = send(t, RequestV2{Mode: SIGN_MODE_TRANSACTION, ChainId: chainId})
+		require.Equal(t, http.StatusBadRequest, recorder.Code)
+	})
+
+	t.Run("Transaction mode applies the policy", func(t *testing.T) {
+		badTxn := invokeTxn(t, feltFromString(t, "0x123"), transferCall(feltFromString(t, "0xbad")))
+		recorder := send(t, RequestV2{Transaction: badTxn, ChainId: chainId})
+		require.Equal(t, http.StatusForbidden, recorder.Code)
+	})
+
+	t.Run("Hash mode is disabled by default", func(t *testing.T) {
+		recorder := send(t, RequestV2{Mode: SIGN_MODE_HASH, Hash: txHash})
+		require.Equal(t, http.StatusForbidden, recorder.Code)
+		require.Contains(t, recorder.Body.String(), "hash signing is disabled")
+	})
+
+	t.Run("Hash mode", func(t *testing.T) {
+		signer.EnableHashSigning()
+		defer func() { signer.hashSigning = false }()
+
+		recorder := send(t, RequestV2{Mode: SIGN_MODE_HASH, Hash: txHash})
+		require.Equal(t, http.StatusOK, recorder.Code)
+
+		var resp ResponseV2
+		require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &resp))
+		require.Equal(t, txHash, resp.Hash)
+		require.Equal(t, expectedSignature, resp.Signature)
+
+		recorder = send(t, RequestV2{Mode: SIGN_MODE_HASH})
+		require.Equal(t, http.StatusBadRequest, recorder.Code)
+	})
+
+	t.Run("Unknown mode", func(t *testing.T) {
+		recorder := send(t, RequestV2{Mode: "message", Hash: txHash})
+		require.Equal(t, http.StatusBadRequest, recorder.Code)
+		require.Contains(t, recorder.Body.String(), "unknown sign mode `message`")
+	})
+
+	t.Run("v1 response decodes as v2", func(t *testing.T) {
+		body, err := json.Marshal(Request{InvokeTxnV3: txn, ChainId: chainId})
+		require.NoError(t, err)
+		recorder := httptest.NewRecorder()
+		signer.handler(
+			recorder, httptest.NewRequest(http.MethodPost, SIGN_V1_ENDPOINT, bytes.NewReader(body)),
+		)
+		require.Equal(t, http.StatusOK, recorder.Code)
+
+		var resp ResponseV2
+		require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &resp))
+		require.Nil(t, resp.Hash)
+		require.Equal(t, expectedSignature, resp.Signature)
+	})
+}
+
+// Key backend of an account expecting a signature of more than the `r` and `s` values
+type multiValueKeyBackend struct {
+	KeyBackend
+}
+
+func (b *multiValueKeyBackend) SignHash(ctx context.Context, hash *felt.Felt) ([]*felt.Felt, error) {
+	signature, err := b.KeyBackend.SignHash(ctx, hash)
+	if err != nil {
+		return nil, err
+	}
+	return append(signature, new(felt.Felt).SetUint64(1)), nil
+}
+
+func TestMultiValueSignatures(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	auditLog, err := OpenAuditLog(path)
+	require.NoError(t, err)
+
+	attestContract := feltFromString(t, "0xa77e57")
+	keys := &multiValueKeyBackend{KeyBackend: memoryKeyBackend(t, "0x123")}
+	signer, err := New(
+		keys, Auth{}, DefaultPolicy(attestContract), auditLog, utils.NewNopZapLogger(),
+	)
+	require.NoError(t, err)
+
+	txn := invokeTxn(t, feltFromString(t, "0x123"), attestCall(attestContract))
+	chainId := new(felt.Felt).SetBytes([]byte("SN_SEPOLIA"))
+	txHash, err := hash.TransactionHashInvokeV3(txn, chainId)
+	require.NoError(t, err)
+	expectedSignature, err := keys.SignHash(t.Context(), txHash)
+	require.NoError(t, err)
+	require.Len(t, expectedSignature, 3)
+
+	// v2 answers every value of the signature
+	body, err := json.Marshal(RequestV2{Transaction: txn, ChainId: chainId})
+	require.NoError(t, err)
+	recorder := httptest.NewRecorder()
+	signer.handlerV2(
+		recorder, httptest.NewRequest(http.MethodPost, SIGN_V2_ENDPOINT, bytes.NewReader(body)),
+	)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	var resp ResponseV2
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &resp))
+	require.Equal(t, expectedSignature, resp.Signature)
+
+	// v1 only holds `r` and `s`, so the signature is withheld rather than cut
+	body, err = json.Marshal(Request{InvokeTxnV3: txn, ChainId: chainId})
+	require.NoError(t, err)
+	recorder = httptest.NewRecorder()
+	signer.handler(
+		recorder, httptest.NewRequest(http.MethodPost, SIGN_V1_ENDPOINT, bytes.NewReader(body)),
+	)
+	require.Equal(t, http.StatusInternalServerError, recorder.Code)
+	require.Contains(t, recorder.Body.String(), "the signature has 3 values")
+
+	require.NoError(t, auditLog.Close())
+	lines := readAuditLines(t, path)
+	require.Len(t, lines, 2)
+	var signed, failed AuditEntry
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &signed))
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &failed))
+	require.Equal(t, AuditSigned, signed.Decision)
+	require.Equal(t, expectedSignature, signed.Signature)
+	require.Equal(t, AuditFailed, failed.Decision)
+	require.Nil(t, failed.Signature)
+}
+
+func TestHandlerRoutes(t *testing.T) {
+	signer, err := New(memoryKeyBackend(t, "0x123"), Auth{}, Policy{}, nil, utils.NewNopZapLogger())
+	require.NoError(t, err)
+
+	get := func(t *testing.T, handler http.Handler, path string) int {
+		t.Helper()
+
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, path, http.NoBody))
+		return recorder.Code
+	}
+
+	handler := signer.Handler()
+	require.Equal(t, http.StatusOK, get(t, handler, PUBLIC_KEY_ENDPOINT))
+	require.Equal(t, http.StatusNotFound, get(t, handler, "/unknown"))
+	require.Equal(t, http.StatusNotFound, get(t, handler, ADMIN_KEYS_ENDPOINT))
+
+	require.NoError(t, signer.EnableAdmin("admin secret"))
+	require.Equal(t, http.StatusUnauthorized, get(t, signer.Handler(), ADMIN_KEYS_ENDPOINT))
+
+	t.Run("Large bodies are refused", func(t *testing.T) {
+		for _, endpoint := range []string{SIGN_ENDPOINT, SIGN_V1_ENDPOINT, SIGN_V2_ENDPOINT} {
+			body := bytes.Repeat([]byte(" "), MAX_REQUEST_BODY_SIZE+1)
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(
+				recorder, httptest.NewRequest(http.MethodPost, endpoint, bytes.NewReader(body)),
+			)
+			require.Equal(t, http.StatusRequestEntityTooLarge, recorder.Code)
+		}
+	})
+}
+
+// Key backend whose signatures wait until `release` is closed, announcing them on `signing`
+type slowKeyBackend struct {
+	KeyBackend
+	signing chan struct{}
+	release chan struct{}
+}
+
+func (b *slowKeyBackend) SignHash(ctx context.Context, hash *felt.Felt) ([]*felt.Felt, error) {
+	b.signing <- struct{}{}
+	<-b.release
+	return b.KeyBackend.SignHash(ctx, hash)
+}
+
+func TestShutdown(t *testing.T) {
+	attestContract := feltFromString(t, "0xa77e57")
+	keys := &slowKeyBackend{
+		KeyBackend: memoryKeyBackend(t, "0x123"),
+		signing:    make(chan struct{}, 1),
+		release:    make(chan struct{}),
+	}
+	signer, err := New(keys, Auth{}, DefaultPolicy(attestContract), nil, utils.NewNopZapLogger())
+	require.NoError(t, err)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	serveErr := make(chan error, 1)
+	go func() { serveErr <- signer.Serve(listener, nil) }()
+
+	body, err := json.Marshal(Request{
+		InvokeTxnV3: invokeTxn(t, feltFromString(t, "0x123"), attestCall(attestContract)),
+		ChainId:     new(felt.Felt).SetBytes([]byte("SN_SEPOLIA")),
+	})
+	require.NoError(t, err)
+	respStatus := make(chan int, 1)
+	go func() {
+		resp, err := http.Post(
+			"http://"+listener.Addr().String()+SIGN_V1_ENDPOINT,
+			"application/json",
+			bytes.NewReader(body),
+		)
+		if err != nil {
+			respStatus <- 0
+			return
+		}
+		_ = resp.Body.Close()
+		respStatus <- resp.StatusCode
+	}()
+	<-keys.signing
+
+	shutdownErr := make(chan error, 1)
+	go func() { shutdownErr <- signer.Shutdown(t.Context()) }()
+
+	select {
+	case <-shutdownErr:
+		require.FailNow(t, "shut down before answering the request being signed")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	close(keys.release)
+	require.Equal(t, http.StatusOK, <-respStatus)
+	require.NoError(t, <-shutdownErr)
+	require.NoError(t, <-serveErr)
+
+	_, err = http.Get("http://" + listener.Addr().String() + PUBLIC_KEY_ENDPOINT)
+	require.Error(t, err)
+}
+
+// Key backend whose public key can be changed or made unavailable
+type flakyKeyBackend struct {
+	KeyBackend
+	publicKey *felt.Felt
+	err       error
+}
+
+func (b *flakyKeyBackend) PublicKey(context.Context) (*felt.Felt, error) {
+	return b.publicKey, b.err
+}
+
+func TestHealthAndReadiness(t *testing.T) {
+	memoryKeys := memoryKeyBackend(t, "0x123")
+	publicKey, err := memoryKeys.PublicKey(t.Context())
+	require.NoError(t, err)
+	keys := &flakyKeyBackend{KeyBackend: memoryKeys, publicKey: publicKey}
+
+	signer, err := New(keys, Auth{}, Policy{}, nil, utils.NewNopZapLogger())
+	require.NoError(t, err)
+	handler := signer.Handler()
+
+	get := func(t *testing.T, path string) *httptest.ResponseRecorder {
+		t.Helper()
+
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, path, http.NoBody))
+		return recorder
+	}
+
+	require.Equal(t, http.StatusOK, get(t, HEALTH_ENDPOINT).Code)
+	require.Equal(t, http.StatusOK, get(t, READY_ENDPOINT).Code)
+
+	t.Run("Key backend unavailable", func(t *testing.T) {
+		keys.err = errors.New("connection refused")
+		defer func() { keys.err = nil }()
+
+		recorder := get(t, READY_ENDPOINT)
+		require.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+		require.Contains(t, recorder.Body.String(), "key backend unavailable: connection refused")
+		require.Equal(t, http.StatusOK, get(t, HEALTH_ENDPOINT).Code)
+	})
+
+	t.Run("Key backend holding another key", func(t *testing.T) {
+		keys.publicKey = new(felt.Felt).SetUint64(0x789)
+		defer func() { keys.publicKey = publicKey }()
+
+		recorder := get(t, READY_ENDPOINT)
+		require.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+		require.Contains(t, recorder.Body.String(), "key backend holds public key 0x789")
+	})
+
+	t.Run("Invalid policy", func(t *testing.T) {
+		signer, err := New(
+			memoryKeys,
+			Auth{},
+			Policy{MaxCalldataLength: -1},
+			nil,
+			utils.NewNopZapLogger(),
+		)
+		require.NoError(t, err)
+		require.EqualError(
+			t, signer.Ready(t.Context()), "invalid policy: max calldata length can't be negative",
+		)
+	})
+}
+
+func TestSignerMetrics(t *testing.T) {
+	attestContract := feltFromString(t, "0xa77e57")
+	auth, err := NewAuth(string(AuthBearer), "secret")
+	require.NoError(t, err)
+	policy := DefaultPolicy(attestContract)
+	policy.SenderAddresses = []*felt.Felt{feltFromString(t, "0xabc")}
+	signer, err := New(
+		memoryKeyBackend(t, "0x123"),
+		auth,
+		policy,
+		nil,
+		utils.NewNopZapLogger(),
+	)
+	require.NoError(t, err)
+	handler := signer.Handler()
+
+	send := func(t *testing.T, sender string, call rpc.InvokeFunctionCall, secret string) {
+		t.Helper()
+
+		body, err := json.Marshal(Request{
+			InvokeTxnV3: invokeTxn(t, feltFromString(t, sender), call),
+			ChainId:     new(felt.Felt).SetBytes([]byte("SN_SEPOLIA")),
+		})
+		require.NoError(t, err)
+		req := httptest.NewRequest(http.MethodPost, SIGN_V1_ENDPOINT, bytes.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+secret)
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	send(t, "0xabc", attestCall(attestContract), "secret")
+	send(t, "0xabc", attestCall(attestContract), "secret")
+	send(t, "0xabc", transferCall(feltFromString(t, "0xbad")), "secret")
+	// Senders the policy doesn't allow don't get a label of their own
+	send(t, "0xdef", attestCall(attestContract), "secret")
+	send(t, "0xfed", attestCall(attestContract), "secret")
+	send(t, "0xabc", attestCall(attestContract), "wrong secret")
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(
+		recorder, httptest.NewRequest(http.MethodGet, METRICS_ENDPOINT, http.NoBody),
+	)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	metrics := recorder.Body.String()
+	require.Contains(
+		t,
+		metrics,
+		`signer_request_count{decision="signed",endpoint="/v1/sign",sender="0xabc"} 2`,
+	)
+	require.Contains(
+		t,
+		metrics,
+		`signer_request_count{decision="rejected",endpoint="/v1/sign",sender="0xabc"} 1`,
+	)
+	require.Contains(
+		t,
+		metrics,
+		`signer_request_count{decision="rejected",endpoint="/v1/sign",sender="unknown"} 2`,
+	)
+	require.Contains(
+		t,
+		metrics,
+		`signer_request_count{decision="unauthorized",endpoint="/v1/sign",sender="unknown"} 1`,
+	)
+	require.NotContains(t, metrics, `sender="0xdef"`)
+	require.Contains(
+		t, metrics, `signer_request_latency_seconds_count{decision="signed",endpoint="/v1/sign"} 2`,
+	)
+	require.Contains(t, metrics, `signer_key_backend_latency_seconds_count{sender="0xabc"} 2`)
+}
@@ -0,0 +1,178 @@
+package signer
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// Writes a PEM encoded certificate and its key to `dir`, signed by the given parent.
+// A nil parent creates a self signed CA
+func writeCert(
+	t *testing.T, dir, name string, parent *x509.Certificate, parentKey *ecdsa.PrivateKey,
+) (*x509.Certificate, *ecdsa.PrivateKey) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		ExtKeyUsage: []x509.ExtKeyUsage{
+			x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth,
+		},
+		KeyUsage: x509.KeyUsageDigitalSignature,
+	}
+	if parent == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+		template.KeyUsage |= x509.KeyUsageCertSign
+		parent, parentKey = template, key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	certPem := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	require.NoError(t, os.WriteFile(filepath.Join(dir, name+".pem"), certPem, 0o600))
+	keyPem := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer})
+	require.NoError(t, os.WriteFile(filepath.Join(dir, name+"-key.pem"), keyPem, 0o600))
+
+	return cert, key
+}
+
+func TestServerTLSConfig(t *testing.T) {
+	dir := t.TempDir()
+	ca, caKey := writeCert(t, dir, "ca", nil, nil)
+	writeCert(t, dir, "server", ca, caKey)
+
+	tlsConfig, err := ServerTLSConfig(filepath.Join(dir, "server.pem"), "", "")
+	require.Nil(t, tlsConfig)
+	require.EqualError(t, err, "both a TLS certificate and key are required")
+
+	tlsConfig, err = ServerTLSConfig(
+		filepath.Join(dir, "server.pem"), filepath.Join(dir, "ca-key.pem"), "",
+	)
+	require.Nil(t, tlsConfig)
+	require.ErrorContains(t, err, "cannot load TLS certificate")
+
+	tlsConfig, err = ServerTLSConfig(
+		filepath.Join(dir, "server.pem"),
+		filepath.Join(dir, "server-key.pem"),
+		filepath.Join(dir, "server-key.pem"),
+	)
+	require.Nil(t, tlsConfig)
+	require.ErrorContains(t, err, "no PEM encoded certificate found")
+}
+
+func TestMutualTLS(t *testing.T) {
+	dir := t.TempDir()
+	ca, caKey := writeCert(t, dir, "ca", nil, nil)
+	writeCert(t, dir, "server", ca, caKey)
+	writeCert(t, dir, "client", ca, caKey)
+	otherCA, otherCAKey := writeCert(t, dir, "other-ca", nil, nil)
+	writeCert(t, dir, "other-client", otherCA, otherCAKey)
+
+	serverTLS, err := ServerTLSConfig(
+		filepath.Join(dir, "server.pem"),
+		filepath.Join(dir, "server-key.pem"),
+		filepath.Join(dir, "ca.pem"),
+	)
+	require.NoError(t, err)
+
+	server := httptest.NewUnstartedServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		}),
+	)
+	server.TLS = serverTLS
+	server.StartTLS()
+	defer server.Close()
+
+	get := func(t *testing.T, caFile, certFile, keyFile string) error {
+		t.Helper()
+
+		clientTLS, err := ClientTLSConfig(caFile, certFile, keyFile)
+		require.NoError(t, err)
+		client := http.Client{Transport: &http.Transport{TLSClientConfig: clientTLS}}
+
+		resp, err := client.Get(server.URL)
+		if err != nil {
+			return err
+		}
+		require.NoError(t, resp.Body.Close())
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		return nil
+	}
+
+	t.Run("Client with a certificate signed by the CA", func(t *testing.T) {
+		err := get(
+			t,
+			filepath.Join(dir, "ca.pem"),
+			filepath.Join(dir, "client.pem"),
+			filepath.Join(dir, "client-key.pem"),
+		)
+		require.NoError(t, err)
+	})
+
+	t.Run("Client without a certificate", func(t *testing.T) {
+		require.Error(t, get(t, filepath.Join(dir, "ca.pem"), "", ""))
+	})
+
+	t.Run("Client with a certificate signed by another CA", func(t *testing.T) {
+		err := get(
+			t,
+			filepath.Join(dir, "ca.pem"),
+			filepath.Join(dir, "other-client.pem"),
+			filepath.Join(dir, "other-client-key.pem"),
+		)
+		require.Error(t, err)
+	})
+
+	t.Run("Client not trusting the server certificate", func(t *testing.T) {
+		err := get(
+			t,
+			filepath.Join(dir, "other-ca.pem"),
+			filepath.Join(dir, "client.pem"),
+			filepath.Join(dir, "client-key.pem"),
+		)
+		require.ErrorContains(t, err, "certificate signed by unknown authority")
+	})
+}
+
+func TestClientTLSConfig(t *testing.T) {
+	tlsConfig, err := ClientTLSConfig("", "client.pem", "")
+	require.Nil(t, tlsConfig)
+	require.EqualError(t, err, "the TLS client certificate and key must be set together")
+
+	tlsConfig, err = ClientTLSConfig("missing-ca.pem", "", "")
+	require.Nil(t, tlsConfig)
+	require.ErrorContains(t, err, "cannot load CA")
+
+	tlsConfig, err = ClientTLSConfig("", "", "")
+	require.NoError(t, err)
+	require.Nil(t, tlsConfig.RootCAs)
+	require.Empty(t, tlsConfig.Certificates)
+}
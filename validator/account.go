@@ -0,0 +1,130 @@
+package validator
+
+import (
+	"sync/atomic"
+
+	"github.com/NethermindEth/juno/utils"
+	"github.com/NethermindEth/starknet-staking-v2/validator/config"
+	"github.com/NethermindEth/starknet-staking-v2/validator/metrics"
+	signerP "github.com/NethermindEth/starknet-staking-v2/validator/signer"
+	"github.com/NethermindEth/starknet-staking-v2/validator/types"
+	"github.com/NethermindEth/starknet.go/rpc"
+	"github.com/sourcegraph/conc"
+)
+
+// How many block headers can be waiting to be processed by an account.
+// When full, new headers are skipped and later backfilled by the account
+const accountHeadersFeedSize = 16
+
+// An operational account attesting independently from the others. It has its own
+// signer, dispatcher, logger and metrics, and is fed the block headers shared by all accounts
+type AttestingAccount struct {
+	signer        signerP.Signer
+	dispatcher    EventDispatcher[signerP.Signer]
+	logger        *utils.ZapLogger
+	metricsServer *metrics.Metrics
+	headersFeed   chan *rpc.BlockHeader
+	stopped       atomic.Bool
+}
+
+func NewAttestingAccount(
+	provider rpc.RpcProvider,
+	signerConfig *config.Signer,
+	snConfig *config.StarknetConfig,
+	attestFee *AttestFee,
+	feeCap *FeeCap,
+	journalDir string,
+	logger *utils.ZapLogger,
+	metricsServer *metrics.Metrics,
+) (*AttestingAccount, error) {
+	accountLogger := &utils.ZapLogger{
+		SugaredLogger: logger.With("address", signerConfig.OperationalAddress),
+	}
+
+	operationalAddress := types.AddressFromString(signerConfig.OperationalAddress)
+	accountMetrics := metricsServer.ForAccount(operationalAddress.String())
+
+	var signer signerP.Signer
+	if signerConfig.External() {
+		externalSigner, err := signerP.NewExternalSigner(
+			provider, accountLogger, signerConfig, &snConfig.ContractAddresses, accountMetrics,
+		)
+		if err != nil {
+			return nil, err
+		}
+		signer = &externalSigner
+	} else {
+		internalSigner, err := signerP.NewInternalSigner(
+			provider, accountLogger, signerConfig, &snConfig.ContractAddresses,
+		)
+		if err != nil {
+			return nil, err
+		}
+		signer = &internalSigner
+	}
+
+	dispatcher := NewEventDispatcher[signerP.Signer]()
+	dispatcher.ReplaceAfterBlocks = snConfig.ReplaceAfterBlocks
+	// Each account keeps its own copy, as the fee might be estimated once per account
+	// and the fee budget is spent by each account separately
+	dispatcher.AttestFee = *attestFee
+	dispatcher.FeeCap = *feeCap
+	dispatcher.WaitForLowerFee = snConfig.WaitForLowerFee
+	if journalDir != "" {
+		if err := setupJournal(&dispatcher, journalDir, signer.Address(), accountLogger); err != nil {
+			return nil, err
+		}
+	}
+
+	return &AttestingAccount{
+		signer:        signer,
+		dispatcher:    dispatcher,
+		logger:        accountLogger,
+		metricsServer: accountMetrics,
+		headersFeed:   make(chan *rpc.BlockHeader, accountHeadersFeedSize),
+	}, nil
+}
+
+func (a *AttestingAccount) Address() *Address {
+	return a.signer.Address()
+}
+
+// Processes the block headers fed to the account until the feed is closed
+// or an error happens. Once it returns, the account no longer accepts headers
+func (a *AttestingAccount) Run(maxRetries types.Retries) error {
+	defer a.stopped.Store(true)
+
+	wg := conc.NewWaitGroup()
+	wg.Go(func() { a.dispatcher.Dispatch(a.signer, a.logger, a.metricsServer) })
+	defer wg.Wait()
+	defer close(a.dispatcher.AttestRequired)
+
+	err := ProcessBlockHeaders(
+		a.headersFeed, a.signer, a.logger, &a.dispatcher, maxRetries, a.metricsServer,
+	)
+	if err != nil {
+		a.logger.Errorw("Account stopped attesting", "error", err)
+	}
+	return err
+}
+
+// Hands the block header to the account without waiting for it to be processed.
+// If the account is busy the header is skipped, it'll be backfilled with the next one
+func (a *AttestingAccount) Feed(blockHeader *rpc.BlockHeader) {
+	if a.stopped.Load() {
+		return
+	}
+
+	select {
+	case a.headersFeed <- blockHeader:
+	default:
+		a.logger.Debugw(
+			"Account is busy, skipping block header", "block number", blockHeader.Number,
+		)
+	}
+}
+
+// Signals the account there are no more block headers to process
+func (a *AttestingAccount) CloseFeed() {
+	close(a.headersFeed)
+}
@@ -7,6 +7,7 @@ import (
 
 	"github.com/NethermindEth/juno/utils"
 	"github.com/NethermindEth/starknet-staking-v2/validator/config"
+	"github.com/NethermindEth/starknet-staking-v2/validator/constants"
 	"github.com/NethermindEth/starknet-staking-v2/validator/metrics"
 	signerP "github.com/NethermindEth/starknet-staking-v2/validator/signer"
 	"github.com/NethermindEth/starknet-staking-v2/validator/types"
@@ -17,6 +18,14 @@ import (
 
 const Version = "0.2.0"
 
+// A websocket subscription that lasted at least this long is not considered
+// to be failing, even if it eventually errored
+const wsHealthyDuration = time.Minute
+
+// If no block header is received for this long, the header subscription is
+// considered stalled and moved to another provider
+const headerStallTimeout = time.Minute
+
 // Main execution loop of the program. Listens to the blockchain and sends
 // attest invoke when it's the right time
 func Attest(
@@ -24,89 +33,223 @@ func Attest(
 	config *config.Config,
 	snConfig *config.StarknetConfig,
 	maxRetries types.Retries,
+	pollInterval time.Duration,
+	journalDir string,
 	logger utils.ZapLogger,
 	metricsServer *metrics.Metrics,
 ) error {
-	provider, err := NewProvider(config.Provider.Http, &logger)
+	providers, err := NewProviderPool(config.Providers(), &logger, metricsServer)
 	if err != nil {
 		return err
 	}
 
-	// Ignoring from now, until Starknet.go allow us to have a fixed Starknet option
-	_, _ = types.AttestFeeFromString(snConfig.AttestOptions)
-	// if err != nil {
-	// 	// do nothing for now
-	// 	// return err
-	// }
-
-	var signer signerP.Signer
-	if config.Signer.External() {
-		externalSigner, err := signerP.NewExternalSigner(
-			provider, &logger, &config.Signer, &snConfig.ContractAddresses,
-		)
+	attestFee, err := types.AttestFeeFromString(snConfig.AttestOptions, snConfig.FeeMultiplier)
+	if err != nil {
+		return err
+	}
+	feeCap, err := types.FeeCapFromStrings(snConfig.MaxAttestFee, snConfig.DailyFeeBudget)
+	if err != nil {
+		return err
+	}
+	// Fixed resource bounds above the cap would never be sent
+	if resourceBounds := attestFee.ResourceBounds(); resourceBounds != nil {
+		maxFee, err := types.MaxFee(resourceBounds, types.AttestTip)
 		if err != nil {
 			return err
 		}
-		signer = &externalSigner
-	} else {
-		internalSigner, err := signerP.NewInternalSigner(
-			provider, &logger, &config.Signer, &snConfig.ContractAddresses,
+		if err := feeCap.Check(time.Now(), maxFee, nil); err != nil {
+			return errors.Errorf("attest fee resource bounds are not valid: %w", err)
+		}
+	}
+
+	// A failure setting up one account doesn't prevent the others from attesting
+	signers := config.AllSigners()
+	accounts := make([]*AttestingAccount, 0, len(signers))
+	var setupErrs []error
+	for i := range signers {
+		account, err := NewAttestingAccount(
+			providers, &signers[i], snConfig, &attestFee, &feeCap, journalDir, &logger, metricsServer,
 		)
 		if err != nil {
-			return err
+			logger.Errorw(
+				"Failed to set up account",
+				"address", signers[i].OperationalAddress,
+				"error", err,
+			)
+			setupErrs = append(setupErrs, err)
+			continue
 		}
-		signer = &internalSigner
+		accounts = append(accounts, account)
+	}
+	if len(accounts) == 0 {
+		return errors.Join(setupErrs...)
 	}
 
-	dispatcher := NewEventDispatcher[signerP.Signer]()
 	wg := conc.NewWaitGroup()
-	wg.Go(func() { dispatcher.Dispatch(signer, &logger, metricsServer) })
 	defer wg.Wait()
-	defer close(dispatcher.AttestRequired)
 
-	return RunBlockHeaderWatcher(ctx, config, &logger, signer, &dispatcher, maxRetries, wg, metricsServer)
+	healthCtx, stopHealthChecks := context.WithCancel(ctx)
+	wg.Go(func() { providers.Run(healthCtx) })
+	defer stopHealthChecks()
+
+	return RunBlockHeaderWatcher(ctx, providers, &logger, pollInterval, accounts, maxRetries, wg)
 }
 
-func RunBlockHeaderWatcher[Account signerP.Signer](
+// Attaches a journal to the dispatcher, resuming the attest saved in it if any
+func setupJournal[S signerP.Signer](
+	dispatcher *EventDispatcher[S],
+	journalDir string,
+	operationalAddress *Address,
+	logger *utils.ZapLogger,
+) error {
+	journal, err := NewAttestJournal(journalDir, operationalAddress)
+	if err != nil {
+		return err
+	}
+
+	entry, err := journal.Load()
+	if err != nil {
+		return err
+	}
+	if entry != nil {
+		logger.Infow(
+			"Resuming attest from journal",
+			"epoch id", entry.EpochId,
+			"target block", entry.TargetBlock,
+			"block hash", entry.BlockHash.String(),
+			"transaction hash", entry.TransactionHash.String(),
+			"status", entry.Status,
+		)
+		dispatcher.Resume(entry)
+	}
+
+	dispatcher.Journal = journal
+	return nil
+}
+
+// Receives the block headers from the active provider and feeds them to every account.
+// Returns once all accounts have stopped
+func RunBlockHeaderWatcher(
 	ctx context.Context,
-	config *config.Config,
+	providers *ProviderPool,
 	logger *utils.ZapLogger,
-	signer Account,
-	dispatcher *EventDispatcher[Account],
+	pollInterval time.Duration,
+	accounts []*AttestingAccount,
 	maxRetries types.Retries,
 	wg *conc.WaitGroup,
-	metricsServer *metrics.Metrics,
 ) error {
-	cleanUp := func(wsProvider *rpc.WsProvider, headersFeed chan *rpc.BlockHeader) {
-		wsProvider.Close()
-		close(headersFeed)
+	// Accounts keep running across re-subscriptions so that they remember
+	// the last block seen and can backfill any missed headers
+	accountStopped := make(chan error, len(accounts))
+	for _, account := range accounts {
+		wg.Go(func() { accountStopped <- account.Run(maxRetries) })
 	}
+	defer func() {
+		for _, account := range accounts {
+			account.CloseFeed()
+		}
+	}()
 
-	for {
-		wsProvider, headersFeed, clientSubscription, err := SubscribeToBlockHeaders(
-			config.Provider.Ws, logger,
-		)
+	runningAccounts := len(accounts)
+	var accountErrs []error
+	// Returns true once no account is left running
+	registerStoppedAccount := func(err error) bool {
+		runningAccounts--
 		if err != nil {
-			return err
+			accountErrs = append(accountErrs, err)
+		}
+		return runningAccounts == 0
+	}
+
+	activeProvider := -1
+	usePolling := false
+	wsFailures := 0
+	registerWsFailure := func() {
+		wsFailures++
+		if wsFailures >= constants.MAX_WS_FAILURES && !usePolling {
+			logger.Warnw("Websocket keeps failing, falling back to http polling", "failures", wsFailures)
+			usePolling = true
 		}
+	}
 
-		stopProcessingHeaders := make(chan error)
+	for {
+		// Accounts can also stop while no header source is running
+		select {
+		case err := <-accountStopped:
+			if registerStoppedAccount(err) {
+				return errors.Join(accountErrs...)
+			}
+		default:
+		}
 
-		wg.Go(func() {
-			err := ProcessBlockHeaders(headersFeed, signer, logger, dispatcher, maxRetries, metricsServer)
+		providerIndex, provider := providers.ActiveEndpoint()
+		if providerIndex != activeProvider {
+			activeProvider = providerIndex
+			wsFailures = 0
+			usePolling = provider.Ws == ""
+			if usePolling {
+				logger.Info("No websocket provider set, block headers will be polled through http")
+			}
+		}
+
+		var headerSource BlockHeaderSource
+		if usePolling {
+			pollingSource, err := NewPollingHeaderSource(providers, pollInterval, logger)
 			if err != nil {
-				stopProcessingHeaders <- err
+				return err
 			}
-		})
+			headerSource = pollingSource
+		} else {
+			headerSource = NewWsHeaderSource(provider.Ws, logger)
+		}
 
-		select {
-		case err := <-clientSubscription.Err():
-			logger.Errorw("Block header subscription", "error", err)
-			logger.Debugw("Ending headers subscription, closing websocket connection, and retrying...")
-			cleanUp(wsProvider, headersFeed)
-		case err := <-stopProcessingHeaders:
-			cleanUp(wsProvider, headersFeed)
-			return err
+		sourceFeed := make(chan *rpc.BlockHeader)
+		if err := headerSource.Start(sourceFeed); err != nil {
+			logger.Errorw(
+				"Failed to start receiving block headers", "source", headerSource.String(), "error", err,
+			)
+			registerWsFailure()
+			Sleep(time.Second)
+			continue
+		}
+		startedAt := time.Now()
+
+		stalled := time.NewTimer(headerStallTimeout)
+	forwardHeaders:
+		for {
+			select {
+			case blockHeader := <-sourceFeed:
+				for _, account := range accounts {
+					account.Feed(blockHeader)
+				}
+				stalled.Reset(headerStallTimeout)
+			case <-stalled.C:
+				logger.Warnw(
+					"No new block headers received, moving to another provider",
+					"source", headerSource.String(),
+					"timeout", headerStallTimeout,
+				)
+				headerSource.Close()
+				providers.ReportFailure(providerIndex, "block headers stalled")
+				break forwardHeaders
+			case err := <-headerSource.Err():
+				stalled.Stop()
+				logger.Errorw("Block header subscription", "error", err)
+				logger.Debugw("Ending headers subscription, closing websocket connection, and retrying...")
+				headerSource.Close()
+
+				if time.Since(startedAt) >= wsHealthyDuration {
+					wsFailures = 0
+				}
+				registerWsFailure()
+				break forwardHeaders
+			case err := <-accountStopped:
+				if registerStoppedAccount(err) {
+					stalled.Stop()
+					headerSource.Close()
+					return errors.Join(accountErrs...)
+				}
+			}
 		}
 	}
 }
@@ -132,14 +275,33 @@ func ProcessBlockHeaders[Account signerP.Signer](
 
 	SetTargetBlockHashIfExists(account, logger, &attestInfo)
 
-	for blockHeader := range headersFeed {
-		logger.Infof("Block %d received", blockHeader.Number)
-		logger.Debugw("Block header information", "block header", blockHeader)
-
+	var prevBlockHeader *rpc.BlockHeader
+	processBlockHeader := func(blockHeader *rpc.BlockHeader) error {
 		// Update latest block number metric
 		metricsServer.UpdateLatestBlockNumber(ChainID, blockHeader.Number)
 
-		if blockHeader.Number == epochInfo.CurrentEpochStartingBlock.Uint64()+epochInfo.EpochLen {
+		if IsReorg(prevBlockHeader, blockHeader) {
+			logger.Warnw(
+				"Chain reorg detected",
+				"previous block number", prevBlockHeader.Number,
+				"previous block hash", prevBlockHeader.Hash,
+				"new block number", blockHeader.Number,
+				"new block hash", blockHeader.Hash,
+				"new block parent hash", blockHeader.ParentHash,
+			)
+			metricsServer.RecordChainReorg(ChainID)
+			// Within the window, the new hash is attested to when the dispatcher is asked
+			// below, which stops tracking the attest sent for the stale one
+			UpdateTargetBlockHashOnReorg(account, logger, &attestInfo, blockHeader)
+		}
+		prevBlockHeader = blockHeader
+
+		// A header past the start of the next epoch also switches it, since the first
+		// header of the epoch can be missed, e.g. when starting right at the epoch boundary
+		// or after a deep reorg. The switch updates the epoch info, so it only happens once
+		// per epoch, and the new epoch id is checked to be the next one
+		nextEpochStart := epochInfo.CurrentEpochStartingBlock.Uint64() + epochInfo.EpochLen
+		if blockHeader.Number >= nextEpochStart {
 			logger.Infow("New epoch start", "epoch id", epochInfo.EpochId+1)
 			prevEpochInfo := epochInfo
 			epochInfo, attestInfo, err = FetchEpochAndAttestInfoWithRetry(
@@ -173,19 +335,183 @@ func ProcessBlockHeaders[Account signerP.Signer](
 
 		if BlockNumber(blockHeader.Number) >= attestInfo.WindowStart-1 &&
 			BlockNumber(blockHeader.Number) < attestInfo.WindowEnd {
-			dispatcher.AttestRequired <- AttestRequired{
-				BlockHash: attestInfo.TargetBlockHash,
+			// The target block hash is unset when it couldn't be fetched after a reorg.
+			// It's fetched again, and nothing is attested to until it's known
+			if attestInfo.TargetBlockHash == (BlockHash{}) {
+				SetTargetBlockHashIfExists(account, logger, &attestInfo)
+			}
+			if attestInfo.TargetBlockHash != (BlockHash{}) {
+				dispatcher.AttestRequired <- AttestRequired{
+					BlockHash:     attestInfo.TargetBlockHash,
+					EpochId:       epochInfo.EpochId,
+					TargetBlock:   attestInfo.TargetBlock,
+					StakerAddress: epochInfo.StakerAddress,
+				}
+			} else {
+				logger.Warnw(
+					"Target block hash is unknown, waiting for it to attest",
+					"target block", attestInfo.TargetBlock.Uint64(),
+				)
 			}
 		}
 
 		if BlockNumber(blockHeader.Number) == attestInfo.WindowEnd {
 			dispatcher.EndOfWindow <- struct{}{}
 		}
+
+		return nil
+	}
+
+	for blockHeader := range headersFeed {
+		logger.Infof("Block %d received", blockHeader.Number)
+		logger.Debugw("Block header information", "block header", blockHeader)
+
+		if IsSameBlock(prevBlockHeader, blockHeader) {
+			// Usually happens when re-subscribing, the head is sent again
+			logger.Debugw("Block already processed", "block number", blockHeader.Number)
+			continue
+		}
+
+		if prevBlockHeader != nil && blockHeader.Number > prevBlockHeader.Number+1 {
+			logger.Warnw(
+				"Missed block headers, fetching them",
+				"from", prevBlockHeader.Number+1,
+				"to", blockHeader.Number-1,
+			)
+			for blockNumber := prevBlockHeader.Number + 1; blockNumber < blockHeader.Number; blockNumber++ {
+				missedBlockHeader, err := FetchBlockHeaderWithRetry(
+					account, logger, blockNumber, maxRetries,
+				)
+				if err != nil {
+					return err
+				}
+				if err := processBlockHeader(missedBlockHeader); err != nil {
+					return err
+				}
+			}
+		}
+
+		if err := processBlockHeader(blockHeader); err != nil {
+			return err
+		}
 	}
 
 	return nil
 }
 
+// Returns true if both block headers refer to the same block
+func IsSameBlock(prevBlockHeader, blockHeader *rpc.BlockHeader) bool {
+	return prevBlockHeader != nil &&
+		blockHeader.Number == prevBlockHeader.Number &&
+		blockHeader.Hash.Equal(prevBlockHeader.Hash)
+}
+
+// Fetches the header of an already accepted block, retrying in case of failure
+func FetchBlockHeaderWithRetry[Account signerP.Signer](
+	account Account,
+	logger *utils.ZapLogger,
+	blockNumber uint64,
+	maxRetries types.Retries,
+) (*rpc.BlockHeader, error) {
+	// storing the initial value for error reporting
+	totalRetryAmount := maxRetries.String()
+
+	blockHeader, err := FetchBlockHeader(account, blockNumber)
+	for err != nil && !maxRetries.IsZero() {
+		logger.Debugw("Failed to fetch block header", "block number", blockNumber, "error", err)
+		logger.Debugf("Retrying to fetch block header: %s retries remaining", &maxRetries)
+
+		Sleep(time.Second)
+
+		blockHeader, err = FetchBlockHeader(account, blockNumber)
+		maxRetries.Sub()
+	}
+
+	if err != nil {
+		return nil, errors.Errorf(
+			"Failed to fetch block header after %s retries. Block number: %d. Error: %s",
+			totalRetryAmount,
+			blockNumber,
+			err.Error(),
+		)
+	}
+
+	return blockHeader, nil
+}
+
+func FetchBlockHeader[Account signerP.Signer](
+	account Account, blockNumber uint64,
+) (*rpc.BlockHeader, error) {
+	res, err := account.BlockWithTxHashes(
+		context.Background(), rpc.BlockID{Number: &blockNumber},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	block, ok := res.(*rpc.BlockTxHashes)
+	if !ok {
+		return nil, errors.Errorf("block %d is not accepted yet", blockNumber)
+	}
+
+	return &block.BlockHeader, nil
+}
+
+// Returns true if the new block header does not extend the previously received one,
+// meaning the chain has been reorganised
+func IsReorg(prevBlockHeader, blockHeader *rpc.BlockHeader) bool {
+	if prevBlockHeader == nil {
+		return false
+	}
+
+	if blockHeader.Number == prevBlockHeader.Number {
+		return !blockHeader.Hash.Equal(prevBlockHeader.Hash)
+	}
+	if blockHeader.Number < prevBlockHeader.Number {
+		return true
+	}
+
+	return blockHeader.Number == prevBlockHeader.Number+1 &&
+		blockHeader.ParentHash != nil &&
+		!blockHeader.ParentHash.Equal(prevBlockHeader.Hash)
+}
+
+// Makes sure the target block hash belongs to the canonical chain after a reorg.
+// Returns true if the target block hash was changed
+func UpdateTargetBlockHashOnReorg[Account signerP.Signer](
+	account Account,
+	logger *utils.ZapLogger,
+	attestInfo *AttestInfo,
+	blockHeader *rpc.BlockHeader,
+) bool {
+	staleHash := attestInfo.TargetBlockHash
+
+	switch {
+	case BlockNumber(blockHeader.Number) < attestInfo.TargetBlock:
+		// The target block is yet to be (re)produced
+		attestInfo.TargetBlockHash = BlockHash{}
+	case BlockNumber(blockHeader.Number) == attestInfo.TargetBlock:
+		attestInfo.TargetBlockHash = BlockHash(*blockHeader.Hash)
+	default:
+		// The fork point is unknown, the target block might have been replaced as well.
+		// Its hash is left unset if it can't be fetched, so the stale one isn't attested to
+		attestInfo.TargetBlockHash = BlockHash{}
+		SetTargetBlockHashIfExists(account, logger, attestInfo)
+	}
+
+	if attestInfo.TargetBlockHash == staleHash {
+		return false
+	}
+
+	logger.Warnw(
+		"Target block hash changed due to reorg",
+		"target block", attestInfo.TargetBlock.Uint64(),
+		"stale block hash", staleHash.String(),
+		"new block hash", attestInfo.TargetBlockHash.String(),
+	)
+	return true
+}
+
 func SetTargetBlockHashIfExists[Account signerP.Signer](
 	account Account,
 	logger *utils.ZapLogger,
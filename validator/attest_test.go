@@ -45,6 +45,15 @@ func TestAttest(t *testing.T) {
 		mockRPC := validator.MockRPCServer(t, operationalAddress, serverInternalError)
 		defer mockRPC.Close()
 
+		mockSigner := validator.MockSignerServer(
+			t,
+			validator.MockPublicKey(t),
+			func(w http.ResponseWriter, r *http.Request) {
+				http.Error(w, "not expected to sign", http.StatusInternalServerError)
+			},
+		)
+		defer mockSigner.Close()
+
 		config := &config.Config{
 			Provider: config.Provider{
 				Http: mockRPC.URL,
@@ -52,7 +61,7 @@ func TestAttest(t *testing.T) {
 			},
 			Signer: config.Signer{
 				OperationalAddress: operationalAddress.String(),
-				ExternalURL:        "http://localhost:5678",
+				ExternalURL:        mockSigner.URL,
 			},
 		}
 
@@ -62,7 +71,9 @@ func TestAttest(t *testing.T) {
 		logger := utils.NewNopZapLogger()
 		ctx := context.Background()
 		metricsServer := mockMetricsServer()
-		err = validator.Attest(ctx, config, sepoliaConfig, defaultRetries(t), *logger, metricsServer)
+		err = validator.Attest(
+			ctx, config, sepoliaConfig, defaultRetries(t), time.Second, "", *logger, metricsServer,
+		)
 
 		expectedErrorMsg := fmt.Sprintf(
 			"Error when calling entrypoint `get_attestation_info_by_operational_address`: -32603 The error is not a valid RPC error: %d Internal Server Error: %s",
@@ -104,7 +115,9 @@ func TestAttest(t *testing.T) {
 		logger := utils.NewNopZapLogger()
 		ctx := context.Background()
 		metricsServer := mockMetricsServer()
-		err = validator.Attest(ctx, config, sepoliaConfig, defaultRetries(t), *logger, metricsServer)
+		err = validator.Attest(
+			ctx, config, sepoliaConfig, defaultRetries(t), time.Second, "", *logger, metricsServer,
+		)
 
 		expectedErrorMsg := fmt.Sprintf(
 			"Error when calling entrypoint `get_attestation_info_by_operational_address`: -32603 The error is not a valid RPC error: %d Internal Server Error: %s",
@@ -114,6 +127,52 @@ func TestAttest(t *testing.T) {
 
 		require.ErrorContains(t, err, expectedErrorMsg)
 	})
+
+	t.Run("Failing to set up an account does not stop the others", func(t *testing.T) {
+		operationalAddress := utils.HexToFelt(t, "0x789")
+		serverInternalError := "Some internal server error when fetching epoch and attest info" +
+			" (multiple accounts test)"
+
+		mockRpc := validator.MockRPCServer(t, operationalAddress, serverInternalError)
+		defer mockRpc.Close()
+
+		config := &config.Config{
+			Provider: config.Provider{
+				Http: mockRpc.URL,
+			},
+			Signers: []config.Signer{
+				{
+					OperationalAddress: "0xabc",
+					PrivKey:            "not a private key",
+				},
+				{
+					OperationalAddress: operationalAddress.String(),
+					PrivKey:            "0x123",
+				},
+			},
+		}
+
+		validator.Sleep = func(d time.Duration) {}
+		defer func() { validator.Sleep = time.Sleep }()
+
+		logger := utils.NewNopZapLogger()
+		metricsServer := mockMetricsServer()
+		err := validator.Attest(
+			context.Background(),
+			config,
+			sepoliaConfig,
+			defaultRetries(t),
+			time.Millisecond,
+			"",
+			*logger,
+			metricsServer,
+		)
+
+		// The error comes from the 2nd account processing blocks,
+		// which means it started despite the 1st one failing to set up
+		require.ErrorContains(t, err, serverInternalError)
+		require.NotContains(t, err.Error(), "private key")
+	})
 }
 
 func TestProcessBlockHeaders(t *testing.T) {
@@ -204,7 +263,10 @@ func TestProcessBlockHeaders(t *testing.T) {
 		// Assert
 		require.Equal(t, 1, len(receivedAttestEvents))
 
-		actualCount, exists := receivedAttestEvents[validator.AttestRequired{BlockHash: targetBlockHash}]
+		actualCount, exists := receivedAttestEvents[validator.AttestRequired{
+			BlockHash: targetBlockHash, EpochId: epoch.EpochId, TargetBlock: expectedTargetBlock,
+			StakerAddress: epoch.StakerAddress,
+		}]
 		require.True(t, exists)
 		require.Equal(t, uint(attestWindow-constants.MIN_ATTESTATION_WINDOW+1), actualCount)
 
@@ -303,11 +365,17 @@ func TestProcessBlockHeaders(t *testing.T) {
 		// Assert
 		require.Equal(t, 2, len(receivedAttestEvents))
 
-		countEpoch1, exists := receivedAttestEvents[validator.AttestRequired{BlockHash: targetBlockHashEpoch1}]
+		countEpoch1, exists := receivedAttestEvents[validator.AttestRequired{
+			BlockHash: targetBlockHashEpoch1, EpochId: epoch1.EpochId, TargetBlock: expectedTargetBlock1,
+			StakerAddress: epoch1.StakerAddress,
+		}]
 		require.True(t, exists)
 		require.Equal(t, uint(16-constants.MIN_ATTESTATION_WINDOW+1), countEpoch1)
 
-		countEpoch2, exists := receivedAttestEvents[validator.AttestRequired{BlockHash: targetBlockHashEpoch2}]
+		countEpoch2, exists := receivedAttestEvents[validator.AttestRequired{
+			BlockHash: targetBlockHashEpoch2, EpochId: epoch2.EpochId, TargetBlock: expectedTargetBlock2,
+			StakerAddress: epoch2.StakerAddress,
+		}]
 		require.True(t, exists)
 		require.Equal(t, uint(16-constants.MIN_ATTESTATION_WINDOW+1), countEpoch2)
 
@@ -435,7 +503,10 @@ func TestProcessBlockHeaders(t *testing.T) {
 			// Assert
 			require.Equal(t, 1, len(receivedAttestEvents))
 
-			countEpoch1, exists := receivedAttestEvents[validator.AttestRequired{BlockHash: targetBlockHashEpoch1}]
+			countEpoch1, exists := receivedAttestEvents[validator.AttestRequired{
+				BlockHash: targetBlockHashEpoch1, EpochId: epoch1.EpochId, TargetBlock: expectedTargetBlock1,
+				StakerAddress: epoch1.StakerAddress,
+			}]
 			require.True(t, exists)
 			require.Equal(t, uint(attestWindow-constants.MIN_ATTESTATION_WINDOW+1), countEpoch1)
 
@@ -444,6 +515,645 @@ func TestProcessBlockHeaders(t *testing.T) {
 			require.ErrorContains(t, err, epoch1.String())
 			require.ErrorContains(t, err, epoch2.String())
 		})
+
+	t.Run("Scenario: reorg replaces the target block during the window", func(t *testing.T) {
+		dispatcher := validator.NewEventDispatcher[*mocks.MockSigner]()
+		headersFeed := make(chan *rpc.BlockHeader)
+
+		attestWindow := uint64(16)
+		epoch := validator.EpochInfo{
+			StakerAddress:             types.AddressFromString("0x123"),
+			Stake:                     uint128.New(1000000000000000000, 0),
+			EpochId:                   1516,
+			CurrentEpochStartingBlock: 639270,
+			EpochLen:                  40,
+		}
+		expectedTargetBlock := validator.BlockNumber(639276)
+		mockSuccessfullyFetchedEpochAndAttestInfo(t, mockSigner, &epoch, attestWindow, 1)
+
+		staleTargetBlockHash := validator.BlockHash(*utils.HexToFelt(t, "0x6d8dc0a8"))
+		blockHeaders := mockHeaderFeed(
+			t,
+			epoch.CurrentEpochStartingBlock,
+			expectedTargetBlock,
+			&staleTargetBlockHash,
+			epoch.EpochLen,
+		)
+
+		// Block 639289 (within the attestation window) belongs to a different fork
+		const reorgBlockIndex = 19
+		blockHeaders[reorgBlockIndex].ParentHash = utils.HexToFelt(t, "0x2")
+
+		// Mock SetTargetBlockHashIfExists call at startup
+		targetBlockUint64 := expectedTargetBlock.Uint64()
+		mockSigner.
+			EXPECT().
+			BlockWithTxHashes(context.Background(), rpc.BlockID{Number: &targetBlockUint64}).
+			Return(nil, errors.New("Block not found"))
+
+		// Mock SetTargetBlockHashIfExists call after the reorg is detected
+		newTargetBlockHash := validator.BlockHash(*utils.HexToFelt(t, "0x7e1a"))
+		mockSigner.
+			EXPECT().
+			BlockWithTxHashes(context.Background(), rpc.BlockID{Number: &targetBlockUint64}).
+			Return(&rpc.BlockTxHashes{
+				BlockHeader: rpc.BlockHeader{Hash: newTargetBlockHash.Felt()},
+			}, nil)
+
+		wgFeed := conc.NewWaitGroup()
+		wgFeed.Go(func() {
+			sendHeaders(t, headersFeed, blockHeaders)
+			close(headersFeed)
+		})
+
+		receivedAttestEvents := make(map[validator.AttestRequired]uint)
+		receivedEndOfWindowEvents := uint8(0)
+		wgDispatcher := conc.NewWaitGroup()
+		wgDispatcher.Go(
+			func() {
+				registerReceivedEvents(
+					t, &dispatcher, receivedAttestEvents, &receivedEndOfWindowEvents,
+				)
+			},
+		)
+
+		metricsServer := mockMetricsServer()
+		err := validator.ProcessBlockHeaders(
+			headersFeed, mockSigner, logger, &dispatcher, defaultRetries(t), metricsServer,
+		)
+		require.NoError(t, err)
+
+		wgFeed.Wait()
+		close(dispatcher.AttestRequired)
+		wgDispatcher.Wait()
+
+		// Assert: blocks 639286 to 639288 ask to attest to the stale hash and
+		// blocks 639289 to 639291 ask to attest to the new one
+		require.Equal(t, 2, len(receivedAttestEvents))
+		require.Equal(
+			t,
+			uint(3),
+			receivedAttestEvents[validator.AttestRequired{
+				BlockHash: staleTargetBlockHash, EpochId: epoch.EpochId, TargetBlock: expectedTargetBlock,
+				StakerAddress: epoch.StakerAddress,
+			}],
+		)
+		require.Equal(
+			t,
+			uint(3),
+			receivedAttestEvents[validator.AttestRequired{
+				BlockHash: newTargetBlockHash, EpochId: epoch.EpochId, TargetBlock: expectedTargetBlock,
+				StakerAddress: epoch.StakerAddress,
+			}],
+		)
+		require.Equal(t, uint8(1), receivedEndOfWindowEvents)
+	})
+
+	t.Run("Scenario: target block hash can't be fetched after a reorg", func(t *testing.T) {
+		dispatcher := validator.NewEventDispatcher[*mocks.MockSigner]()
+		headersFeed := make(chan *rpc.BlockHeader)
+
+		attestWindow := uint64(16)
+		epoch := validator.EpochInfo{
+			StakerAddress:             types.AddressFromString("0x123"),
+			Stake:                     uint128.New(1000000000000000000, 0),
+			EpochId:                   1516,
+			CurrentEpochStartingBlock: 639270,
+			EpochLen:                  40,
+		}
+		expectedTargetBlock := validator.BlockNumber(639276)
+		mockSuccessfullyFetchedEpochAndAttestInfo(t, mockSigner, &epoch, attestWindow, 1)
+
+		staleTargetBlockHash := validator.BlockHash(*utils.HexToFelt(t, "0x6d8dc0a8"))
+		blockHeaders := mockHeaderFeed(
+			t,
+			epoch.CurrentEpochStartingBlock,
+			expectedTargetBlock,
+			&staleTargetBlockHash,
+			epoch.EpochLen,
+		)
+
+		// Block 639289 (within the attestation window) belongs to a different fork
+		const reorgBlockIndex = 19
+		blockHeaders[reorgBlockIndex].ParentHash = utils.HexToFelt(t, "0x2")
+
+		// Mock SetTargetBlockHashIfExists call at startup
+		targetBlockUint64 := expectedTargetBlock.Uint64()
+		mockSigner.
+			EXPECT().
+			BlockWithTxHashes(context.Background(), rpc.BlockID{Number: &targetBlockUint64}).
+			Return(nil, errors.New("Block not found"))
+
+		// Mock SetTargetBlockHashIfExists calls after the reorg is detected and before
+		// attesting in the same block, which fail, and the one of the next header, which
+		// fetches the new hash
+		newTargetBlockHash := validator.BlockHash(*utils.HexToFelt(t, "0x7e1a"))
+		gomock.InOrder(
+			mockSigner.
+				EXPECT().
+				BlockWithTxHashes(context.Background(), rpc.BlockID{Number: &targetBlockUint64}).
+				Return(nil, errors.New("Request timed out")).
+				Times(2),
+			mockSigner.
+				EXPECT().
+				BlockWithTxHashes(context.Background(), rpc.BlockID{Number: &targetBlockUint64}).
+				Return(&rpc.BlockTxHashes{
+					BlockHeader: rpc.BlockHeader{Hash: newTargetBlockHash.Felt()},
+				}, nil),
+		)
+
+		wgFeed := conc.NewWaitGroup()
+		wgFeed.Go(func() {
+			sendHeaders(t, headersFeed, blockHeaders)
+			close(headersFeed)
+		})
+
+		receivedAttestEvents := make(map[validator.AttestRequired]uint)
+		receivedEndOfWindowEvents := uint8(0)
+		wgDispatcher := conc.NewWaitGroup()
+		wgDispatcher.Go(
+			func() {
+				registerReceivedEvents(
+					t, &dispatcher, receivedAttestEvents, &receivedEndOfWindowEvents,
+				)
+			},
+		)
+
+		metricsServer := mockMetricsServer()
+		err := validator.ProcessBlockHeaders(
+			headersFeed, mockSigner, logger, &dispatcher, defaultRetries(t), metricsServer,
+		)
+		require.NoError(t, err)
+
+		wgFeed.Wait()
+		close(dispatcher.AttestRequired)
+		wgDispatcher.Wait()
+
+		// Assert: blocks 639286 to 639288 ask to attest to the stale hash, block 639289
+		// asks nothing and blocks 639290 to 639291 ask to attest to the new one
+		require.Equal(t, 2, len(receivedAttestEvents))
+		require.Equal(
+			t,
+			uint(3),
+			receivedAttestEvents[validator.AttestRequired{
+				BlockHash: staleTargetBlockHash, EpochId: epoch.EpochId, TargetBlock: expectedTargetBlock,
+				StakerAddress: epoch.StakerAddress,
+			}],
+		)
+		require.Equal(
+			t,
+			uint(2),
+			receivedAttestEvents[validator.AttestRequired{
+				BlockHash: newTargetBlockHash, EpochId: epoch.EpochId, TargetBlock: expectedTargetBlock,
+				StakerAddress: epoch.StakerAddress,
+			}],
+		)
+		require.Equal(t, uint8(1), receivedEndOfWindowEvents)
+	})
+}
+
+func TestProcessBlockHeadersWithMissedHeaders(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	t.Cleanup(mockCtrl.Finish)
+
+	mockSigner := mocks.NewMockSigner(mockCtrl)
+	mockSigner.EXPECT().ValidationContracts().Return(
+		validator.SepoliaValidationContracts(t),
+	).AnyTimes()
+
+	logger := utils.NewNopZapLogger()
+
+	stakerAddress := types.AddressFromString("0x123")
+	stake := uint128.New(1000000000000000000, 0)
+	epochLength := uint64(40)
+	attestWindow := uint64(16)
+
+	t.Run("Target block and end of window are backfilled", func(t *testing.T) {
+		dispatcher := validator.NewEventDispatcher[*mocks.MockSigner]()
+		headersFeed := make(chan *rpc.BlockHeader)
+
+		epoch := validator.EpochInfo{
+			StakerAddress:             stakerAddress,
+			Stake:                     stake,
+			EpochId:                   1516,
+			CurrentEpochStartingBlock: 639270,
+			EpochLen:                  epochLength,
+		}
+		expectedTargetBlock := validator.BlockNumber(639276)
+		mockSuccessfullyFetchedEpochAndAttestInfo(t, mockSigner, &epoch, attestWindow, 1)
+
+		targetBlockHash := validator.BlockHash(
+			*utils.HexToFelt(t, "0x6d8dc0a8bdf98854b6bc146cb7cab6cddda85619c6ae2948ee65da25815e045"),
+		)
+		blockHeaders := mockHeaderFeed(
+			t, epoch.CurrentEpochStartingBlock, expectedTargetBlock, &targetBlockHash, epoch.EpochLen,
+		)
+
+		// Mock SetTargetBlockHashIfExists call
+		targetBlockUint64 := expectedTargetBlock.Uint64()
+		mockSigner.
+			EXPECT().
+			BlockWithTxHashes(context.Background(), rpc.BlockID{Number: &targetBlockUint64}).
+			Return(nil, errors.New("Block not found"))
+
+		// Drop the target block (639276) and the end of window block (639292),
+		// along with some of their neighbours
+		missedHeaders := append([]rpc.BlockHeader{}, blockHeaders[5:9]...)
+		missedHeaders = append(missedHeaders, blockHeaders[20:24]...)
+		mockFetchedBlockHeaders(t, mockSigner, missedHeaders)
+
+		sentHeaders := append([]rpc.BlockHeader{}, blockHeaders[:5]...)
+		sentHeaders = append(sentHeaders, blockHeaders[9:20]...)
+		sentHeaders = append(sentHeaders, blockHeaders[24:]...)
+
+		wgFeed := conc.NewWaitGroup()
+		wgFeed.Go(func() {
+			sendHeaders(t, headersFeed, sentHeaders)
+			close(headersFeed)
+		})
+
+		receivedAttestEvents := make(map[validator.AttestRequired]uint)
+		receivedEndOfWindowEvents := uint8(0)
+		wgDispatcher := conc.NewWaitGroup()
+		wgDispatcher.Go(func() {
+			registerReceivedEvents(t, &dispatcher, receivedAttestEvents, &receivedEndOfWindowEvents)
+		})
+
+		metricsServer := mockMetricsServer()
+		err := validator.ProcessBlockHeaders(
+			headersFeed, mockSigner, logger, &dispatcher, defaultRetries(t), metricsServer,
+		)
+		require.NoError(t, err)
+
+		wgFeed.Wait()
+		close(dispatcher.AttestRequired)
+		wgDispatcher.Wait()
+
+		// Assert
+		require.Equal(t, 1, len(receivedAttestEvents))
+
+		actualCount, exists := receivedAttestEvents[validator.AttestRequired{
+			BlockHash: targetBlockHash, EpochId: epoch.EpochId, TargetBlock: expectedTargetBlock,
+			StakerAddress: epoch.StakerAddress,
+		}]
+		require.True(t, exists)
+		require.Equal(t, uint(attestWindow-constants.MIN_ATTESTATION_WINDOW+1), actualCount)
+
+		require.Equal(t, uint8(1), receivedEndOfWindowEvents)
+	})
+
+	t.Run("Epoch switch is backfilled", func(t *testing.T) {
+		dispatcher := validator.NewEventDispatcher[*mocks.MockSigner]()
+		headersFeed := make(chan *rpc.BlockHeader)
+
+		epoch1 := validator.EpochInfo{
+			StakerAddress:             stakerAddress,
+			Stake:                     stake,
+			EpochId:                   1516,
+			CurrentEpochStartingBlock: 639270,
+			EpochLen:                  epochLength,
+		}
+		expectedTargetBlock1 := validator.BlockNumber(639276)
+		mockSuccessfullyFetchedEpochAndAttestInfo(t, mockSigner, &epoch1, attestWindow, 1)
+
+		epoch2 := validator.EpochInfo{
+			StakerAddress:             stakerAddress,
+			Stake:                     stake,
+			EpochId:                   1517,
+			CurrentEpochStartingBlock: 639310,
+			EpochLen:                  epochLength,
+		}
+		expectedTargetBlock2 := validator.BlockNumber(639315)
+		mockSuccessfullyFetchedEpochAndAttestInfo(t, mockSigner, &epoch2, attestWindow, 1)
+
+		targetBlockHashEpoch1 := validator.BlockHash(
+			*utils.HexToFelt(t, "0x6d8dc0a8bdf98854b6bc146cb7cab6cddda85619c6ae2948ee65da25815e045"),
+		)
+		blockHeaders1 := mockHeaderFeed(
+			t, epoch1.CurrentEpochStartingBlock, expectedTargetBlock1, &targetBlockHashEpoch1, epochLength,
+		)
+		targetBlockHashEpoch2 := validator.BlockHash(
+			*utils.HexToFelt(t, "0x2124ae375432a16ef644f539c3b148f63c706067bf576088f32033fe59c345e"),
+		)
+		blockHeaders2 := mockHeaderFeed(
+			t, epoch2.CurrentEpochStartingBlock, expectedTargetBlock2, &targetBlockHashEpoch2, epochLength,
+		)
+
+		// Mock SetTargetBlockHashIfExists call
+		targetBlockUint64 := expectedTargetBlock1.Uint64()
+		mockSigner.
+			EXPECT().
+			BlockWithTxHashes(context.Background(), rpc.BlockID{Number: &targetBlockUint64}).
+			Return(nil, errors.New("Block not found"))
+
+		// Drop the last blocks of epoch 1 and the first blocks of epoch 2
+		missedHeaders := append([]rpc.BlockHeader{}, blockHeaders1[37:]...)
+		missedHeaders = append(missedHeaders, blockHeaders2[:3]...)
+		mockFetchedBlockHeaders(t, mockSigner, missedHeaders)
+
+		wgFeed := conc.NewWaitGroup()
+		wgFeed.Go(func() {
+			sendHeaders(t, headersFeed, blockHeaders1[:37])
+			sendHeaders(t, headersFeed, blockHeaders2[3:])
+			close(headersFeed)
+		})
+
+		receivedAttestEvents := make(map[validator.AttestRequired]uint)
+		receivedEndOfWindowEvents := uint8(0)
+		wgDispatcher := conc.NewWaitGroup()
+		wgDispatcher.Go(func() {
+			registerReceivedEvents(t, &dispatcher, receivedAttestEvents, &receivedEndOfWindowEvents)
+		})
+
+		metricsServer := mockMetricsServer()
+		err := validator.ProcessBlockHeaders(
+			headersFeed, mockSigner, logger, &dispatcher, defaultRetries(t), metricsServer,
+		)
+		require.NoError(t, err)
+
+		wgFeed.Wait()
+		close(dispatcher.AttestRequired)
+		wgDispatcher.Wait()
+
+		// Assert
+		require.Equal(t, 2, len(receivedAttestEvents))
+
+		countEpoch1, exists := receivedAttestEvents[validator.AttestRequired{
+			BlockHash: targetBlockHashEpoch1, EpochId: epoch1.EpochId, TargetBlock: expectedTargetBlock1,
+			StakerAddress: epoch1.StakerAddress,
+		}]
+		require.True(t, exists)
+		require.Equal(t, uint(attestWindow-constants.MIN_ATTESTATION_WINDOW+1), countEpoch1)
+
+		countEpoch2, exists := receivedAttestEvents[validator.AttestRequired{
+			BlockHash: targetBlockHashEpoch2, EpochId: epoch2.EpochId, TargetBlock: expectedTargetBlock2,
+			StakerAddress: epoch2.StakerAddress,
+		}]
+		require.True(t, exists)
+		require.Equal(t, uint(attestWindow-constants.MIN_ATTESTATION_WINDOW+1), countEpoch2)
+
+		require.Equal(t, uint8(2), receivedEndOfWindowEvents)
+	})
+
+	t.Run("Epoch switch without receiving the first header of the epoch", func(t *testing.T) {
+		dispatcher := validator.NewEventDispatcher[*mocks.MockSigner]()
+		headersFeed := make(chan *rpc.BlockHeader)
+
+		epoch1 := validator.EpochInfo{
+			StakerAddress:             stakerAddress,
+			Stake:                     stake,
+			EpochId:                   1516,
+			CurrentEpochStartingBlock: 639270,
+			EpochLen:                  epochLength,
+		}
+		expectedTargetBlock1 := validator.BlockNumber(639276)
+		mockSuccessfullyFetchedEpochAndAttestInfo(t, mockSigner, &epoch1, attestWindow, 1)
+
+		epoch2 := validator.EpochInfo{
+			StakerAddress:             stakerAddress,
+			Stake:                     stake,
+			EpochId:                   1517,
+			CurrentEpochStartingBlock: 639310,
+			EpochLen:                  epochLength,
+		}
+		expectedTargetBlock2 := validator.BlockNumber(639315)
+		mockSuccessfullyFetchedEpochAndAttestInfo(t, mockSigner, &epoch2, attestWindow, 1)
+
+		targetBlockHash := validator.BlockHash(
+			*utils.HexToFelt(t, "0x2124ae375432a16ef644f539c3b148f63c706067bf576088f32033fe59c345e"),
+		)
+		blockHeaders := mockHeaderFeed(
+			t, epoch2.CurrentEpochStartingBlock, expectedTargetBlock2, &targetBlockHash, epochLength,
+		)
+
+		// Mock SetTargetBlockHashIfExists call
+		targetBlockUint64 := expectedTargetBlock1.Uint64()
+		mockSigner.
+			EXPECT().
+			BlockWithTxHashes(context.Background(), rpc.BlockID{Number: &targetBlockUint64}).
+			Return(nil, errors.New("Block not found"))
+
+		// The validator starts right at the epoch boundary: the epoch info read is the one
+		// of the previous epoch, and the first header received is past the epoch start
+		wgFeed := conc.NewWaitGroup()
+		wgFeed.Go(func() {
+			sendHeaders(t, headersFeed, blockHeaders[1:])
+			close(headersFeed)
+		})
+
+		receivedAttestEvents := make(map[validator.AttestRequired]uint)
+		receivedEndOfWindowEvents := uint8(0)
+		wgDispatcher := conc.NewWaitGroup()
+		wgDispatcher.Go(func() {
+			registerReceivedEvents(t, &dispatcher, receivedAttestEvents, &receivedEndOfWindowEvents)
+		})
+
+		metricsServer := mockMetricsServer()
+		err := validator.ProcessBlockHeaders(
+			headersFeed, mockSigner, logger, &dispatcher, defaultRetries(t), metricsServer,
+		)
+		require.NoError(t, err)
+
+		wgFeed.Wait()
+		close(dispatcher.AttestRequired)
+		wgDispatcher.Wait()
+
+		// Assert
+		require.Equal(t, 1, len(receivedAttestEvents))
+		count, exists := receivedAttestEvents[validator.AttestRequired{
+			BlockHash: targetBlockHash, EpochId: epoch2.EpochId, TargetBlock: expectedTargetBlock2,
+			StakerAddress: epoch2.StakerAddress,
+		}]
+		require.True(t, exists)
+		require.Equal(t, uint(attestWindow-constants.MIN_ATTESTATION_WINDOW+1), count)
+		require.Equal(t, uint8(1), receivedEndOfWindowEvents)
+	})
+
+	t.Run("Error fetching a missed header", func(t *testing.T) {
+		dispatcher := validator.NewEventDispatcher[*mocks.MockSigner]()
+		headersFeed := make(chan *rpc.BlockHeader)
+
+		epoch := validator.EpochInfo{
+			StakerAddress:             stakerAddress,
+			Stake:                     stake,
+			EpochId:                   1516,
+			CurrentEpochStartingBlock: 639270,
+			EpochLen:                  epochLength,
+		}
+		expectedTargetBlock := validator.BlockNumber(639276)
+		mockSuccessfullyFetchedEpochAndAttestInfo(t, mockSigner, &epoch, attestWindow, 1)
+
+		targetBlockHash := validator.BlockHash(*utils.HexToFelt(t, "0x6d8dc0a8"))
+		blockHeaders := mockHeaderFeed(
+			t, epoch.CurrentEpochStartingBlock, expectedTargetBlock, &targetBlockHash, epoch.EpochLen,
+		)
+
+		// Mock SetTargetBlockHashIfExists call
+		targetBlockUint64 := expectedTargetBlock.Uint64()
+		mockSigner.
+			EXPECT().
+			BlockWithTxHashes(context.Background(), rpc.BlockID{Number: &targetBlockUint64}).
+			Return(nil, errors.New("Block not found"))
+
+		// Block 639271 is missed and cannot be fetched
+		missedBlockNumber := blockHeaders[1].Number
+		mockSigner.
+			EXPECT().
+			BlockWithTxHashes(context.Background(), rpc.BlockID{Number: &missedBlockNumber}).
+			Return(nil, errors.New("some internal error")).
+			Times(constants.DEFAULT_MAX_RETRIES + 1)
+
+		wgFeed := conc.NewWaitGroup()
+		wgFeed.Go(func() {
+			headersFeed <- &blockHeaders[0]
+			headersFeed <- &blockHeaders[2]
+		})
+
+		validator.Sleep = func(time.Duration) { /* do nothing (avoid waiting) */ }
+		defer func() { validator.Sleep = time.Sleep }()
+
+		metricsServer := mockMetricsServer()
+		err := validator.ProcessBlockHeaders(
+			headersFeed, mockSigner, logger, &dispatcher, defaultRetries(t), metricsServer,
+		)
+		wgFeed.Wait()
+
+		require.ErrorContains(t, err, "Failed to fetch block header after 10 retries")
+		require.ErrorContains(t, err, "some internal error")
+	})
+}
+
+func TestIsReorg(t *testing.T) {
+	hashA := utils.HexToFelt(t, "0xa")
+	hashB := utils.HexToFelt(t, "0xb")
+	hashC := utils.HexToFelt(t, "0xc")
+
+	prevBlockHeader := rpc.BlockHeader{Number: 10, Hash: hashA}
+
+	testCases := []struct {
+		name            string
+		prevBlockHeader *rpc.BlockHeader
+		blockHeader     rpc.BlockHeader
+		expected        bool
+	}{
+		{
+			name:        "No previous block header",
+			blockHeader: rpc.BlockHeader{Number: 11, Hash: hashB, ParentHash: hashC},
+			expected:    false,
+		},
+		{
+			name:            "Block extends the previous one",
+			prevBlockHeader: &prevBlockHeader,
+			blockHeader:     rpc.BlockHeader{Number: 11, Hash: hashB, ParentHash: hashA},
+			expected:        false,
+		},
+		{
+			name:            "Same block received twice",
+			prevBlockHeader: &prevBlockHeader,
+			blockHeader:     rpc.BlockHeader{Number: 10, Hash: hashA},
+			expected:        false,
+		},
+		{
+			name:            "Block does not extend the previous one",
+			prevBlockHeader: &prevBlockHeader,
+			blockHeader:     rpc.BlockHeader{Number: 11, Hash: hashB, ParentHash: hashC},
+			expected:        true,
+		},
+		{
+			name:            "Block with the same number but a different hash",
+			prevBlockHeader: &prevBlockHeader,
+			blockHeader:     rpc.BlockHeader{Number: 10, Hash: hashB},
+			expected:        true,
+		},
+		{
+			name:            "Block with a lower number",
+			prevBlockHeader: &prevBlockHeader,
+			blockHeader:     rpc.BlockHeader{Number: 9, Hash: hashB},
+			expected:        true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.expected, validator.IsReorg(tc.prevBlockHeader, &tc.blockHeader))
+		})
+	}
+}
+
+func TestIsSameBlock(t *testing.T) {
+	blockHeader := rpc.BlockHeader{Number: 10, Hash: utils.HexToFelt(t, "0xa")}
+
+	require.False(t, validator.IsSameBlock(nil, &blockHeader))
+	require.True(t, validator.IsSameBlock(&blockHeader, &blockHeader))
+	require.False(t, validator.IsSameBlock(
+		&blockHeader, &rpc.BlockHeader{Number: 10, Hash: utils.HexToFelt(t, "0xb")},
+	))
+	require.False(t, validator.IsSameBlock(
+		&blockHeader, &rpc.BlockHeader{Number: 11, Hash: utils.HexToFelt(t, "0xa")},
+	))
+}
+
+func TestUpdateTargetBlockHashOnReorg(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	t.Cleanup(mockCtrl.Finish)
+
+	mockAccount := mocks.NewMockSigner(mockCtrl)
+	logger := utils.NewNopZapLogger()
+
+	staleHash := validator.BlockHash(*utils.HexToFelt(t, "0x123"))
+
+	t.Run("Reorg below the target block resets its hash", func(t *testing.T) {
+		attestInfo := validator.AttestInfo{TargetBlock: 10, TargetBlockHash: staleHash}
+		changed := validator.UpdateTargetBlockHashOnReorg(
+			mockAccount, logger, &attestInfo, &rpc.BlockHeader{Number: 9},
+		)
+
+		require.True(t, changed)
+		require.Equal(t, validator.BlockHash{}, attestInfo.TargetBlockHash)
+	})
+
+	t.Run("Reorg at the target block uses the new header hash", func(t *testing.T) {
+		newHash := utils.HexToFelt(t, "0x456")
+		attestInfo := validator.AttestInfo{TargetBlock: 10, TargetBlockHash: staleHash}
+		changed := validator.UpdateTargetBlockHashOnReorg(
+			mockAccount, logger, &attestInfo, &rpc.BlockHeader{Number: 10, Hash: newHash},
+		)
+
+		require.True(t, changed)
+		require.Equal(t, validator.BlockHash(*newHash), attestInfo.TargetBlockHash)
+	})
+
+	t.Run("Reorg above the target block that didn't replace it", func(t *testing.T) {
+		targetBlockNumber := uint64(10)
+		mockAccount.
+			EXPECT().
+			BlockWithTxHashes(context.Background(), rpc.BlockID{Number: &targetBlockNumber}).
+			Return(&rpc.BlockTxHashes{BlockHeader: rpc.BlockHeader{Hash: staleHash.Felt()}}, nil)
+
+		attestInfo := validator.AttestInfo{TargetBlock: 10, TargetBlockHash: staleHash}
+		changed := validator.UpdateTargetBlockHashOnReorg(
+			mockAccount, logger, &attestInfo, &rpc.BlockHeader{Number: 15},
+		)
+
+		require.False(t, changed)
+		require.Equal(t, staleHash, attestInfo.TargetBlockHash)
+	})
+
+	t.Run("Reorg above the target block whose hash can't be fetched resets it", func(t *testing.T) {
+		targetBlockNumber := uint64(10)
+		mockAccount.
+			EXPECT().
+			BlockWithTxHashes(context.Background(), rpc.BlockID{Number: &targetBlockNumber}).
+			Return(nil, errors.New("Request timed out"))
+
+		attestInfo := validator.AttestInfo{TargetBlock: 10, TargetBlockHash: staleHash}
+		changed := validator.UpdateTargetBlockHashOnReorg(
+			mockAccount, logger, &attestInfo, &rpc.BlockHeader{Number: 15},
+		)
+
+		require.True(t, changed)
+		require.Equal(t, validator.BlockHash{}, attestInfo.TargetBlockHash)
+	})
 }
 
 // Test helper function to send headers
@@ -560,6 +1270,21 @@ func mockFailedFetchingEpochAndAttestInfo(
 		Times(howManyTimes)
 }
 
+// Test helper function to mock the fetching of missed block headers
+func mockFetchedBlockHeaders(
+	t *testing.T, mockSigner *mocks.MockSigner, blockHeaders []rpc.BlockHeader,
+) {
+	t.Helper()
+
+	for i := range blockHeaders {
+		blockNumber := blockHeaders[i].Number
+		mockSigner.
+			EXPECT().
+			BlockWithTxHashes(context.Background(), rpc.BlockID{Number: &blockNumber}).
+			Return(&rpc.BlockTxHashes{BlockHeader: blockHeaders[i]}, nil)
+	}
+}
+
 func mockHeaderFeed(
 	t *testing.T,
 	startingBlock,
@@ -570,6 +1295,7 @@ func mockHeaderFeed(
 	t.Helper()
 
 	blockHeaders := make([]rpc.BlockHeader, epochLength)
+	parentHash := new(felt.Felt).SetUint64(1)
 	for i := range uint64(epochLength) {
 		blockNumber := validator.BlockNumber(i) + startingBlock
 
@@ -580,9 +1306,11 @@ func mockHeaderFeed(
 		}
 
 		blockHeaders[i] = rpc.BlockHeader{
-			Number: blockNumber.Uint64(),
-			Hash:   blockHash,
+			Number:     blockNumber.Uint64(),
+			Hash:       blockHash,
+			ParentHash: parentHash,
 		}
+		parentHash = blockHash
 	}
 	return blockHeaders
 }
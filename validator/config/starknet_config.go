@@ -85,6 +85,15 @@ func (ca *ContractAddresses) String() string {
 type StarknetConfig struct {
 	ContractAddresses ContractAddresses
 	AttestOptions     string
+	// Multiplier applied to the estimated fee of the attest transactions
+	FeeMultiplier float64
+	// Limits of the fee paid by the attest transactions, empty for no limit
+	MaxAttestFee   string
+	DailyFeeBudget string
+	// Whether to retry an attest above the fee limits instead of skipping the epoch
+	WaitForLowerFee bool
+	// Blocks an attest transaction can wait to be included before being replaced
+	ReplaceAfterBlocks uint64
 }
 
 func (c *StarknetConfig) SetDefaults(chainID string) *StarknetConfig {
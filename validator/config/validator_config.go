@@ -1,9 +1,19 @@
 package config
 
 import (
+	"crypto/tls"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/NethermindEth/juno/core/felt"
+	"github.com/NethermindEth/starknet-staking-v2/signer"
+	"github.com/NethermindEth/starknet-staking-v2/validator/constants"
 )
 
 type Provider struct {
@@ -18,13 +28,38 @@ func ProviderFromEnv() Provider {
 	}
 }
 
+// Parses a provider written as "<http url>[,<ws url>]"
+func ProviderFromString(value string) Provider {
+	httpUrl, wsUrl, _ := strings.Cut(value, ",")
+	return Provider{
+		Http: strings.TrimSpace(httpUrl),
+		Ws:   strings.TrimSpace(wsUrl),
+	}
+}
+
+// Reads the fallback providers as a ";" separated list of "<http url>[,<ws url>]"
+func FallbackProvidersFromEnv() []Provider {
+	value := os.Getenv("PROVIDER_FALLBACK_URLS")
+	if value == "" {
+		return nil
+	}
+
+	entries := strings.Split(value, ";")
+	providers := make([]Provider, 0, len(entries))
+	for _, entry := range entries {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+		providers = append(providers, ProviderFromString(entry))
+	}
+	return providers
+}
+
 func (p *Provider) Check() error {
 	if p.Http == "" {
 		return errors.New("http provider url not set in provider configuration")
 	}
-	if p.Ws == "" {
-		return errors.New("ws provider url not set in provider configuration")
-	}
+	// The ws url is optional, new blocks are polled through http when missing
 	return nil
 }
 
@@ -39,19 +74,67 @@ func (p *Provider) Fill(other *Provider) {
 }
 
 type Signer struct {
-	ExternalURL        string `json:"url"`
-	PrivKey            string `json:"privateKey"`
-	OperationalAddress string `json:"operationalAddress"`
+	ExternalURL string `json:"url"`
+	// External signers holding the same key, used in order whenever the ones before
+	// them are failing
+	FallbackURLs       []string `json:"fallbackUrls,omitempty"`
+	PrivKey            string   `json:"privateKey"`
+	OperationalAddress string   `json:"operationalAddress"`
+	// Encrypted keystore with the private key, and the file with its password.
+	// The password is prompted when no file is given
+	Keystore             string `json:"keystore,omitempty"`
+	KeystorePasswordFile string `json:"keystorePasswordFile,omitempty"`
+	// Credentials for the external signer, the scheme defaults to hmac when a secret is set
+	AuthScheme string `json:"authScheme,omitempty"`
+	AuthSecret string `json:"authSecret,omitempty"`
+	// TLS options for an external signer served over https. The CA verifies the signer's
+	// certificate, the client certificate and key are needed when it requires mutual TLS
+	TLSCACert     string `json:"tlsCaCert,omitempty"`
+	TLSClientCert string `json:"tlsClientCert,omitempty"`
+	TLSClientKey  string `json:"tlsClientKey,omitempty"`
+	// Duration each request to the external signer can take, e.g. "5s", and how many times
+	// requests failing with a connection or server error are retried
+	Timeout    string `json:"timeout,omitempty"`
+	MaxRetries string `json:"maxRetries,omitempty"`
+	// Version of the protocol spoken with the external signer, "v1" or "v2". The unversioned
+	// endpoint is used when not set. With "v2", the sign mode can be set to "hash" so the
+	// signer is only sent the transaction hash
+	Protocol string `json:"protocol,omitempty"`
+	SignMode string `json:"signMode,omitempty"`
+	// Whether a v2 external signer can answer with signatures other than `r` and `s`, which
+	// can't be verified against the public key, for accounts validating them differently
+	AllowUnverifiedSignatures bool `json:"allowUnverifiedSignatures,omitempty"`
 }
 
 func (s *Signer) Check() error {
 	if s.OperationalAddress == "" {
 		return errors.New("operational address is not set in signer configuration")
 	}
+	if len(s.FallbackURLs) > 0 && !s.External() {
+		return errors.New("fallback urls set without an external url in signer configuration")
+	}
+	for i := range s.FallbackURLs {
+		if s.FallbackURLs[i] == "" {
+			return fmt.Errorf("fallback url %d of the external signer is empty", i+1)
+		}
+	}
 	if s.External() {
-		return nil
+		if _, err := s.Auth(); err != nil {
+			return err
+		}
+		if _, _, err := s.RequestLimits(); err != nil {
+			return err
+		}
+		if _, _, err := s.SigningProtocol(); err != nil {
+			return err
+		}
+		_, err := s.TLSConfig()
+		return err
+	}
+	if s.PrivKey != "" && s.Keystore != "" {
+		return errors.New("both private key and keystore set in signer configuration")
 	}
-	if s.PrivKey == "" {
+	if s.PrivKey == "" && s.Keystore == "" {
 		return errors.New("neither private key nor external url set in signer configuration")
 	}
 	return nil
@@ -59,38 +142,228 @@ func (s *Signer) Check() error {
 
 func SignerFromEnv() Signer {
 	return Signer{
-		ExternalURL:        os.Getenv("SIGNER_EXTERNAL_URL"),
-		PrivKey:            os.Getenv("SIGNER_PRIVATE_KEY"),
-		OperationalAddress: os.Getenv("SIGNER_OPERATIONAL_ADDRESS"),
+		ExternalURL:               os.Getenv("SIGNER_EXTERNAL_URL"),
+		FallbackURLs:              SignerFallbackURLsFromEnv(),
+		PrivKey:                   os.Getenv("SIGNER_PRIVATE_KEY"),
+		OperationalAddress:        os.Getenv("SIGNER_OPERATIONAL_ADDRESS"),
+		Keystore:                  os.Getenv("SIGNER_KEYSTORE"),
+		KeystorePasswordFile:      os.Getenv("SIGNER_KEYSTORE_PASSWORD_FILE"),
+		AuthScheme:                os.Getenv("SIGNER_AUTH_SCHEME"),
+		AuthSecret:                os.Getenv("SIGNER_AUTH_SECRET"),
+		TLSCACert:                 os.Getenv("SIGNER_TLS_CA_CERT"),
+		TLSClientCert:             os.Getenv("SIGNER_TLS_CLIENT_CERT"),
+		TLSClientKey:              os.Getenv("SIGNER_TLS_CLIENT_KEY"),
+		Timeout:                   os.Getenv("SIGNER_TIMEOUT"),
+		MaxRetries:                os.Getenv("SIGNER_MAX_RETRIES"),
+		Protocol:                  os.Getenv("SIGNER_PROTOCOL"),
+		SignMode:                  os.Getenv("SIGNER_SIGN_MODE"),
+		AllowUnverifiedSignatures: os.Getenv("SIGNER_ALLOW_UNVERIFIED_SIGNATURES") == "true",
 	}
 }
 
+// Reads the fallback external signers as a ";" separated list of urls
+func SignerFallbackURLsFromEnv() []string {
+	value := os.Getenv("SIGNER_FALLBACK_URLS")
+	if value == "" {
+		return nil
+	}
+
+	entries := strings.Split(value, ";")
+	urls := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if entry = strings.TrimSpace(entry); entry != "" {
+			urls = append(urls, entry)
+		}
+	}
+	return urls
+}
+
 // Merge its missing fields with data from other signer
 func (s *Signer) Fill(other *Signer) {
 	if isZero(s.ExternalURL) {
 		s.ExternalURL = other.ExternalURL
 	}
+	if len(s.FallbackURLs) == 0 {
+		s.FallbackURLs = other.FallbackURLs
+	}
 	if isZero(s.PrivKey) {
 		s.PrivKey = other.PrivKey
 	}
 	if isZero(s.OperationalAddress) {
 		s.OperationalAddress = other.OperationalAddress
 	}
+	if isZero(s.Keystore) {
+		s.Keystore = other.Keystore
+	}
+	if isZero(s.KeystorePasswordFile) {
+		s.KeystorePasswordFile = other.KeystorePasswordFile
+	}
+	if isZero(s.AuthScheme) {
+		s.AuthScheme = other.AuthScheme
+	}
+	if isZero(s.AuthSecret) {
+		s.AuthSecret = other.AuthSecret
+	}
+	if isZero(s.TLSCACert) {
+		s.TLSCACert = other.TLSCACert
+	}
+	if isZero(s.TLSClientCert) {
+		s.TLSClientCert = other.TLSClientCert
+	}
+	if isZero(s.TLSClientKey) {
+		s.TLSClientKey = other.TLSClientKey
+	}
+	if isZero(s.Timeout) {
+		s.Timeout = other.Timeout
+	}
+	if isZero(s.MaxRetries) {
+		s.MaxRetries = other.MaxRetries
+	}
+	if isZero(s.Protocol) {
+		s.Protocol = other.Protocol
+	}
+	if isZero(s.SignMode) {
+		s.SignMode = other.SignMode
+	}
+	if isZero(s.AllowUnverifiedSignatures) {
+		s.AllowUnverifiedSignatures = other.AllowUnverifiedSignatures
+	}
 }
 
 func (s *Signer) External() bool {
 	return s.ExternalURL != ""
 }
 
+// Whether none of its fields is set
+func (s *Signer) IsZero() bool {
+	return reflect.ValueOf(*s).IsZero()
+}
+
+// Returns the private key of an internal signer, decrypting the keystore when it's set
+func (s *Signer) PrivateKey() (string, error) {
+	if s.Keystore == "" {
+		return s.PrivKey, nil
+	}
+	password, err := signer.ReadPassword(
+		s.KeystorePasswordFile,
+		fmt.Sprintf("Password of keystore %s for %s: ", s.Keystore, s.OperationalAddress),
+	)
+	if err != nil {
+		return "", err
+	}
+	return signer.DecryptKeystoreFile(s.Keystore, password)
+}
+
+// Returns the credentials sent to the external signer
+func (s *Signer) Auth() (signer.Auth, error) {
+	scheme := s.AuthScheme
+	if scheme == "" && s.AuthSecret != "" {
+		scheme = string(signer.AuthHMAC)
+	}
+	auth, err := signer.NewAuth(scheme, s.AuthSecret)
+	if err != nil {
+		return signer.Auth{}, fmt.Errorf("invalid external signer authentication: %w", err)
+	}
+	return auth, nil
+}
+
+// Returns the TLS configuration used to connect to the external signer, nil when no
+// TLS option is set, in which case the system CAs are used for https urls
+func (s *Signer) TLSConfig() (*tls.Config, error) {
+	if s.TLSCACert == "" && s.TLSClientCert == "" && s.TLSClientKey == "" {
+		return nil, nil
+	}
+	tlsConfig, err := signer.ClientTLSConfig(s.TLSCACert, s.TLSClientCert, s.TLSClientKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid external signer TLS configuration: %w", err)
+	}
+	return tlsConfig, nil
+}
+
+// Returns how long each request to the external signer can take and how many times
+// it's retried, using the defaults for the values not set
+func (s *Signer) RequestLimits() (time.Duration, int, error) {
+	timeout := constants.DEFAULT_SIGNER_TIMEOUT
+	if s.Timeout != "" {
+		parsed, err := time.ParseDuration(s.Timeout)
+		if err != nil || parsed <= 0 {
+			return 0, 0, fmt.Errorf(
+				"invalid external signer timeout `%s`, expected a positive duration such as `10s`",
+				s.Timeout,
+			)
+		}
+		timeout = parsed
+	}
+
+	maxRetries := constants.DEFAULT_SIGNER_MAX_RETRIES
+	if s.MaxRetries != "" {
+		parsed, err := strconv.Atoi(s.MaxRetries)
+		if err != nil || parsed < 0 {
+			return 0, 0, fmt.Errorf(
+				"invalid external signer max retries `%s`, expected a non negative integer",
+				s.MaxRetries,
+			)
+		}
+		maxRetries = parsed
+	}
+	return timeout, maxRetries, nil
+}
+
+// Returns the version of the protocol spoken with the external signer, empty for the
+// unversioned endpoint, and the sign mode, which defaults to "transaction"
+func (s *Signer) SigningProtocol() (string, string, error) {
+	switch s.Protocol {
+	case "", signer.PROTOCOL_V1, signer.PROTOCOL_V2:
+	default:
+		return "", "", fmt.Errorf(
+			"unknown external signer protocol `%s`, expected either `%s` or `%s`",
+			s.Protocol,
+			signer.PROTOCOL_V1,
+			signer.PROTOCOL_V2,
+		)
+	}
+	if s.AllowUnverifiedSignatures && s.Protocol != signer.PROTOCOL_V2 {
+		return "", "", fmt.Errorf(
+			"unverified external signer signatures require protocol `%s`", signer.PROTOCOL_V2,
+		)
+	}
+
+	switch s.SignMode {
+	case "", signer.SIGN_MODE_TRANSACTION:
+		return s.Protocol, signer.SIGN_MODE_TRANSACTION, nil
+	case signer.SIGN_MODE_HASH:
+		if s.Protocol != signer.PROTOCOL_V2 {
+			return "", "", fmt.Errorf(
+				"external signer sign mode `%s` requires protocol `%s`",
+				signer.SIGN_MODE_HASH,
+				signer.PROTOCOL_V2,
+			)
+		}
+		return s.Protocol, s.SignMode, nil
+	default:
+		return "", "", fmt.Errorf(
+			"unknown external signer sign mode `%s`, expected either `%s` or `%s`",
+			s.SignMode,
+			signer.SIGN_MODE_TRANSACTION,
+			signer.SIGN_MODE_HASH,
+		)
+	}
+}
+
 type Config struct {
 	Provider Provider `json:"provider"`
-	Signer   Signer   `json:"signer"`
+	// Used in order whenever the providers before them are unhealthy
+	FallbackProviders []Provider `json:"fallbackProviders,omitempty"`
+	Signer            Signer     `json:"signer"`
+	// Additional operational accounts attesting from the same process
+	Signers []Signer `json:"signers,omitempty"`
 }
 
 func FromEnv() Config {
 	return Config{
-		Provider: ProviderFromEnv(),
-		Signer:   SignerFromEnv(),
+		Provider:          ProviderFromEnv(),
+		FallbackProviders: FallbackProvidersFromEnv(),
+		Signer:            SignerFromEnv(),
 	}
 }
 
@@ -114,7 +387,30 @@ func FromData(data []byte) (Config, error) {
 // Fills its missing fields with data from other config
 func (c *Config) Fill(other *Config) {
 	c.Provider.Fill(&other.Provider)
+	if len(c.FallbackProviders) == 0 {
+		c.FallbackProviders = other.FallbackProviders
+	}
 	c.Signer.Fill(&other.Signer)
+	if len(c.Signers) == 0 {
+		c.Signers = other.Signers
+	}
+}
+
+// Returns the main provider followed by the fallback ones
+func (c *Config) Providers() []Provider {
+	providers := make([]Provider, 0, len(c.FallbackProviders)+1)
+	providers = append(providers, c.Provider)
+	return append(providers, c.FallbackProviders...)
+}
+
+// Returns the signer of every operational account. The main signer is left out
+// when it's not set and additional signers are
+func (c *Config) AllSigners() []Signer {
+	signers := make([]Signer, 0, len(c.Signers)+1)
+	if !c.Signer.IsZero() || len(c.Signers) == 0 {
+		signers = append(signers, c.Signer)
+	}
+	return append(signers, c.Signers...)
 }
 
 // Verifies its data is appropiatly set
@@ -122,8 +418,32 @@ func (c *Config) Check() error {
 	if err := c.Provider.Check(); err != nil {
 		return err
 	}
-	if err := c.Signer.Check(); err != nil {
-		return err
+	for i := range c.FallbackProviders {
+		if err := c.FallbackProviders[i].Check(); err != nil {
+			return fmt.Errorf("fallback provider %d: %w", i+1, err)
+		}
+	}
+	signers := c.AllSigners()
+	// Addresses are compared by value, the same one can be written with different padding
+	seen := make(map[felt.Felt]bool, len(signers))
+	for i := range signers {
+		if err := signers[i].Check(); err != nil {
+			if len(signers) == 1 {
+				return err
+			}
+			return fmt.Errorf("signer %d: %w", i+1, err)
+		}
+		address, err := new(felt.Felt).SetString(signers[i].OperationalAddress)
+		if err != nil {
+			return fmt.Errorf("invalid operational address %s: %w", signers[i].OperationalAddress, err)
+		}
+		if seen[*address] {
+			return fmt.Errorf(
+				"operational address %s is set in more than one signer",
+				signers[i].OperationalAddress,
+			)
+		}
+		seen[*address] = true
 	}
 	return nil
 }
@@ -1,9 +1,14 @@
 package config
 
 import (
+	"math/big"
 	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
+	"github.com/NethermindEth/starknet-staking-v2/signer"
+	"github.com/NethermindEth/starknet-staking-v2/validator/constants"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -147,7 +152,7 @@ func TestCorrectConfig(t *testing.T) {
         }`)
 		config, err := FromData(data)
 		require.NoError(t, err)
-		require.ErrorContains(t, config.Check(), "ws provider url")
+		require.NoError(t, config.Check())
 	})
 
 	t.Run("Missing operational address", func(t *testing.T) {
@@ -214,6 +219,378 @@ func TestCorrectConfig(t *testing.T) {
 		require.NoError(t, err)
 		require.ErrorContains(t, config.Check(), "private key")
 	})
+
+	t.Run("Missing fallback provider http url", func(t *testing.T) {
+		data := []byte(`{
+            "provider": {
+                "http": "http://localhost:1234"
+            },
+            "fallbackProviders": [
+                {"http": "http://localhost:2234"},
+                {"ws": "ws://localhost:3235"}
+            ],
+            "signer": {
+                "privateKey": "0x123",
+                "operationalAddress": "0x456"
+            }
+        }`)
+		config, err := FromData(data)
+		require.NoError(t, err)
+		require.ErrorContains(t, config.Check(), "fallback provider 2: http provider url")
+	})
+}
+
+func TestSignerAuth(t *testing.T) {
+	t.Run("Load from file", func(t *testing.T) {
+		data := []byte(`{
+            "provider": {
+                "http": "http://localhost:1234"
+            },
+            "signer": {
+                "url": "http://localhost:5678",
+                "operationalAddress": "0x456",
+                "authScheme": "bearer",
+                "authSecret": "some secret"
+            }
+        }`)
+		config, err := FromData(data)
+		require.NoError(t, err)
+		require.NoError(t, config.Check())
+
+		auth, err := config.Signer.Auth()
+		require.NoError(t, err)
+		require.Equal(t, signer.Auth{Scheme: signer.AuthBearer, Secret: "some secret"}, auth)
+	})
+
+	t.Run("Scheme defaults to hmac when a secret is set", func(t *testing.T) {
+		configSigner := Signer{AuthSecret: "some secret"}
+		auth, err := configSigner.Auth()
+		require.NoError(t, err)
+		require.Equal(t, signer.Auth{Scheme: signer.AuthHMAC, Secret: "some secret"}, auth)
+
+		configSigner = Signer{}
+		auth, err = configSigner.Auth()
+		require.NoError(t, err)
+		require.Equal(t, signer.Auth{}, auth)
+	})
+
+	t.Run("Error when the external signer authentication is wrong", func(t *testing.T) {
+		config := Config{
+			Provider: Provider{Http: "http://localhost:1234"},
+			Signer: Signer{
+				ExternalURL:        "http://localhost:5678",
+				OperationalAddress: "0x456",
+				AuthScheme:         "bearer",
+			},
+		}
+		require.ErrorContains(t, config.Check(), "auth scheme `bearer` requires a secret")
+	})
+}
+
+func TestSignerKeystore(t *testing.T) {
+	dir := t.TempDir()
+	keystorePath := filepath.Join(dir, "keystore.json")
+	passwordFile := filepath.Join(dir, "password")
+
+	keystore, err := signer.EncryptKey(
+		big.NewInt(0x456),
+		"password",
+		signer.KDF_PBKDF2,
+		signer.KDFParams{DKLen: 32, C: 1024, PRF: "hmac-sha256"},
+	)
+	require.NoError(t, err)
+	require.NoError(t, keystore.Save(keystorePath))
+	require.NoError(t, os.WriteFile(passwordFile, []byte("password\n"), 0o600))
+
+	t.Run("Private key is decrypted from the keystore", func(t *testing.T) {
+		configSigner := Signer{
+			OperationalAddress:   "0x123",
+			Keystore:             keystorePath,
+			KeystorePasswordFile: passwordFile,
+		}
+		require.NoError(t, configSigner.Check())
+
+		privateKey, err := configSigner.PrivateKey()
+		require.NoError(t, err)
+		require.Equal(t, "0x456", privateKey)
+	})
+
+	t.Run("Private key is used when there is no keystore", func(t *testing.T) {
+		configSigner := Signer{OperationalAddress: "0x123", PrivKey: "0x789"}
+		privateKey, err := configSigner.PrivateKey()
+		require.NoError(t, err)
+		require.Equal(t, "0x789", privateKey)
+	})
+
+	t.Run("Error with the wrong password", func(t *testing.T) {
+		wrongPasswordFile := filepath.Join(dir, "wrong-password")
+		require.NoError(t, os.WriteFile(wrongPasswordFile, []byte("wrong"), 0o600))
+
+		configSigner := Signer{
+			OperationalAddress:   "0x123",
+			Keystore:             keystorePath,
+			KeystorePasswordFile: wrongPasswordFile,
+		}
+		privateKey, err := configSigner.PrivateKey()
+		require.Empty(t, privateKey)
+		require.ErrorIs(t, err, signer.ErrWrongPassword)
+	})
+
+	t.Run("Error when both private key and keystore are set", func(t *testing.T) {
+		configSigner := Signer{
+			OperationalAddress: "0x123",
+			PrivKey:            "0x456",
+			Keystore:           keystorePath,
+		}
+		require.EqualError(
+			t, configSigner.Check(), "both private key and keystore set in signer configuration",
+		)
+	})
+}
+
+func TestSignerTLSConfig(t *testing.T) {
+	t.Run("No TLS options", func(t *testing.T) {
+		configSigner := Signer{ExternalURL: "https://localhost:5678"}
+		tlsConfig, err := configSigner.TLSConfig()
+		require.NoError(t, err)
+		require.Nil(t, tlsConfig)
+	})
+
+	t.Run("Error when the client certificate has no key", func(t *testing.T) {
+		config := Config{
+			Provider: Provider{Http: "http://localhost:1234"},
+			Signer: Signer{
+				ExternalURL:        "https://localhost:5678",
+				OperationalAddress: "0x456",
+				TLSClientCert:      "client.pem",
+			},
+		}
+		require.EqualError(
+			t,
+			config.Check(),
+			"invalid external signer TLS configuration: "+
+				"the TLS client certificate and key must be set together",
+		)
+	})
+
+	t.Run("Error when the CA certificate can't be read", func(t *testing.T) {
+		configSigner := Signer{TLSCACert: "missing-ca.pem"}
+		tlsConfig, err := configSigner.TLSConfig()
+		require.Nil(t, tlsConfig)
+		require.ErrorContains(t, err, "cannot load CA")
+	})
+}
+
+func TestSignerRequestLimits(t *testing.T) {
+	t.Run("Defaults", func(t *testing.T) {
+		configSigner := Signer{ExternalURL: "http://localhost:5678"}
+		timeout, maxRetries, err := configSigner.RequestLimits()
+		require.NoError(t, err)
+		require.Equal(t, constants.DEFAULT_SIGNER_TIMEOUT, timeout)
+		require.Equal(t, constants.DEFAULT_SIGNER_MAX_RETRIES, maxRetries)
+	})
+
+	t.Run("Set values", func(t *testing.T) {
+		configSigner := Signer{Timeout: "1m30s", MaxRetries: "0"}
+		timeout, maxRetries, err := configSigner.RequestLimits()
+		require.NoError(t, err)
+		require.Equal(t, 90*time.Second, timeout)
+		require.Zero(t, maxRetries)
+	})
+
+	t.Run("Error with an invalid value", func(t *testing.T) {
+		config := Config{
+			Provider: Provider{Http: "http://localhost:1234"},
+			Signer: Signer{
+				ExternalURL:        "http://localhost:5678",
+				OperationalAddress: "0x456",
+				Timeout:            "10",
+			},
+		}
+		require.EqualError(
+			t,
+			config.Check(),
+			"invalid external signer timeout `10`, expected a positive duration such as `10s`",
+		)
+
+		config.Signer.Timeout = ""
+		config.Signer.MaxRetries = "-1"
+		require.EqualError(
+			t,
+			config.Check(),
+			"invalid external signer max retries `-1`, expected a non negative integer",
+		)
+	})
+}
+
+func TestSignerSigningProtocol(t *testing.T) {
+	t.Run("Defaults", func(t *testing.T) {
+		configSigner := Signer{ExternalURL: "http://localhost:5678"}
+		protocol, signMode, err := configSigner.SigningProtocol()
+		require.NoError(t, err)
+		require.Empty(t, protocol)
+		require.Equal(t, signer.SIGN_MODE_TRANSACTION, signMode)
+	})
+
+	t.Run("Hash mode", func(t *testing.T) {
+		configSigner := Signer{Protocol: "v2", SignMode: "hash"}
+		protocol, signMode, err := configSigner.SigningProtocol()
+		require.NoError(t, err)
+		require.Equal(t, signer.PROTOCOL_V2, protocol)
+		require.Equal(t, signer.SIGN_MODE_HASH, signMode)
+	})
+
+	t.Run("Error with an invalid value", func(t *testing.T) {
+		config := Config{
+			Provider: Provider{Http: "http://localhost:1234"},
+			Signer: Signer{
+				ExternalURL:        "http://localhost:5678",
+				OperationalAddress: "0x456",
+				Protocol:           "v3",
+			},
+		}
+		require.EqualError(
+			t,
+			config.Check(),
+			"unknown external signer protocol `v3`, expected either `v1` or `v2`",
+		)
+
+		config.Signer.Protocol = "v1"
+		config.Signer.SignMode = "hash"
+		require.EqualError(
+			t, config.Check(), "external signer sign mode `hash` requires protocol `v2`",
+		)
+
+		config.Signer.Protocol = "v2"
+		config.Signer.SignMode = "message"
+		require.EqualError(
+			t,
+			config.Check(),
+			"unknown external signer sign mode `message`, expected either `transaction` or `hash`",
+		)
+
+		config.Signer.Protocol = "v1"
+		config.Signer.SignMode = ""
+		config.Signer.AllowUnverifiedSignatures = true
+		require.EqualError(
+			t, config.Check(), "unverified external signer signatures require protocol `v2`",
+		)
+	})
+}
+
+func TestFallbackProviders(t *testing.T) {
+	t.Run("Load from file", func(t *testing.T) {
+		data := []byte(`{
+            "provider": {
+                "http": "http://localhost:1234",
+                "ws": "ws://localhost:1235"
+            },
+            "fallbackProviders": [
+                {"http": "http://localhost:2234", "ws": "ws://localhost:2235"},
+                {"http": "http://localhost:3234"}
+            ]
+        }`)
+		config, err := FromData(data)
+		require.NoError(t, err)
+
+		expectedProviders := []Provider{
+			{Http: "http://localhost:1234", Ws: "ws://localhost:1235"},
+			{Http: "http://localhost:2234", Ws: "ws://localhost:2235"},
+			{Http: "http://localhost:3234"},
+		}
+		require.Equal(t, expectedProviders, config.Providers())
+	})
+
+	t.Run("Load from env", func(t *testing.T) {
+		t.Setenv(
+			"PROVIDER_FALLBACK_URLS",
+			"http://localhost:2234,ws://localhost:2235; http://localhost:3234;",
+		)
+
+		expectedProviders := []Provider{
+			{Http: "http://localhost:2234", Ws: "ws://localhost:2235"},
+			{Http: "http://localhost:3234"},
+		}
+		require.Equal(t, expectedProviders, FallbackProvidersFromEnv())
+	})
+
+	t.Run("Fill only when none are set", func(t *testing.T) {
+		config1 := Config{}
+		config2 := Config{FallbackProviders: []Provider{{Http: "http://localhost:2234"}}}
+		config3 := Config{FallbackProviders: []Provider{{Http: "http://localhost:3234"}}}
+
+		config1.Fill(&config2)
+		require.Equal(t, config2.FallbackProviders, config1.FallbackProviders)
+
+		config1.Fill(&config3)
+		require.Equal(t, config2.FallbackProviders, config1.FallbackProviders)
+	})
+}
+
+func TestSignerFallbackURLs(t *testing.T) {
+	t.Run("Load from file", func(t *testing.T) {
+		data := []byte(`{
+            "provider": {
+                "http": "http://localhost:1234"
+            },
+            "signer": {
+                "url": "http://localhost:5678",
+                "fallbackUrls": ["http://localhost:6678", "http://localhost:7678"],
+                "operationalAddress": "0x456"
+            }
+        }`)
+		config, err := FromData(data)
+		require.NoError(t, err)
+		require.NoError(t, config.Check())
+		require.Equal(
+			t, []string{"http://localhost:6678", "http://localhost:7678"}, config.Signer.FallbackURLs,
+		)
+	})
+
+	t.Run("Load from env", func(t *testing.T) {
+		t.Setenv("SIGNER_FALLBACK_URLS", "http://localhost:6678; http://localhost:7678;")
+
+		require.Equal(
+			t, []string{"http://localhost:6678", "http://localhost:7678"}, SignerFallbackURLsFromEnv(),
+		)
+	})
+
+	t.Run("Fill only when none are set", func(t *testing.T) {
+		signer1 := Signer{}
+		signer2 := Signer{FallbackURLs: []string{"http://localhost:6678"}}
+		signer3 := Signer{FallbackURLs: []string{"http://localhost:7678"}}
+
+		signer1.Fill(&signer2)
+		require.Equal(t, signer2.FallbackURLs, signer1.FallbackURLs)
+
+		signer1.Fill(&signer3)
+		require.Equal(t, signer2.FallbackURLs, signer1.FallbackURLs)
+	})
+
+	t.Run("Error without an external url", func(t *testing.T) {
+		configSigner := Signer{
+			OperationalAddress: "0x456",
+			PrivKey:            "0x123",
+			FallbackURLs:       []string{"http://localhost:6678"},
+		}
+		require.EqualError(
+			t,
+			configSigner.Check(),
+			"fallback urls set without an external url in signer configuration",
+		)
+
+		configSigner.PrivKey = ""
+		configSigner.ExternalURL = "http://localhost:5678"
+		configSigner.FallbackURLs = append(configSigner.FallbackURLs, "")
+		require.EqualError(
+			t, configSigner.Check(), "fallback url 2 of the external signer is empty",
+		)
+	})
+
+	t.Run("Signer with only fallback urls is not empty", func(t *testing.T) {
+		require.True(t, new(Signer).IsZero())
+		require.False(t, (&Signer{FallbackURLs: []string{"http://localhost:6678"}}).IsZero())
+	})
 }
 
 func TestConfigFill(t *testing.T) {
@@ -265,3 +642,78 @@ func TestConfigFill(t *testing.T) {
 	assert.Equal(t, expectedConfig1, config1)
 	assert.Equal(t, expectedConfig2, config2)
 }
+
+func TestAllSigners(t *testing.T) {
+	signer1 := Signer{PrivKey: "0x123", OperationalAddress: "0x456"}
+	signer2 := Signer{ExternalURL: "http://localhost:5678", OperationalAddress: "0x789"}
+
+	t.Run("Only the main signer", func(t *testing.T) {
+		config := Config{Signer: signer1}
+		require.Equal(t, []Signer{signer1}, config.AllSigners())
+	})
+
+	t.Run("Main signer followed by additional ones", func(t *testing.T) {
+		config := Config{Signer: signer1, Signers: []Signer{signer2}}
+		require.Equal(t, []Signer{signer1, signer2}, config.AllSigners())
+	})
+
+	t.Run("Only additional signers", func(t *testing.T) {
+		config := Config{Signers: []Signer{signer1, signer2}}
+		require.Equal(t, []Signer{signer1, signer2}, config.AllSigners())
+	})
+
+	t.Run("Load from file", func(t *testing.T) {
+		data := []byte(`{
+            "provider": {
+                "http": "http://localhost:1234"
+            },
+            "signers": [
+                {"privateKey": "0x123", "operationalAddress": "0x456"},
+                {"url": "http://localhost:5678", "operationalAddress": "0x789"}
+            ]
+        }`)
+		config, err := FromData(data)
+		require.NoError(t, err)
+		require.NoError(t, config.Check())
+		require.Equal(t, []Signer{signer1, signer2}, config.AllSigners())
+	})
+
+	t.Run("Error when an additional signer is wrong", func(t *testing.T) {
+		config := Config{
+			Provider: Provider{Http: "http://localhost:1234"},
+			Signers:  []Signer{signer1, {OperationalAddress: "0x789"}},
+		}
+		require.ErrorContains(t, config.Check(), "signer 2: neither private key nor external url")
+	})
+
+	t.Run("Error when an operational address is repeated", func(t *testing.T) {
+		config := Config{
+			Provider: Provider{Http: "http://localhost:1234"},
+			Signer:   signer1,
+			Signers:  []Signer{signer2, {PrivKey: "0x999", OperationalAddress: "0x456"}},
+		}
+		require.ErrorContains(t, config.Check(), "operational address 0x456 is set in more than one")
+	})
+
+	t.Run("Error when an operational address is repeated with different padding", func(t *testing.T) {
+		for _, repeated := range []string{"0x0456", "0X456", "0x000000456"} {
+			config := Config{
+				Provider: Provider{Http: "http://localhost:1234"},
+				Signer:   signer1,
+				Signers:  []Signer{signer2, {PrivKey: "0x999", OperationalAddress: repeated}},
+			}
+			require.ErrorContains(
+				t, config.Check(), "operational address "+repeated+" is set in more than one",
+			)
+		}
+	})
+
+	t.Run("Error when an operational address is invalid", func(t *testing.T) {
+		config := Config{
+			Provider: Provider{Http: "http://localhost:1234"},
+			Signer:   signer1,
+			Signers:  []Signer{{PrivKey: "0x999", OperationalAddress: "0xnothex"}},
+		}
+		require.ErrorContains(t, config.Check(), "invalid operational address 0xnothex")
+	})
+}
@@ -1,5 +1,7 @@
 package constants
 
+import "time"
+
 const (
 	SEPOLIA_STAKING_CONTRACT_ADDRESS = "0x03745ab04a431fc02871a139be6b93d9260b0ff3e779ad9c8b377183b23109f1"
 	SEPOLIA_ATTEST_CONTRACT_ADDRESS  = "0x3f32e152b9637c31bfcf73e434f78591067a01ba070505ff6ee195642c9acfb"
@@ -7,7 +9,28 @@ const (
 )
 
 const (
-	MIN_ATTESTATION_WINDOW    = 11
-	DEFAULT_MAX_RETRIES       = 10
-	FEE_ESTIMATION_MULTIPLIER = 1.5
+	MIN_ATTESTATION_WINDOW            = 11
+	DEFAULT_MAX_RETRIES               = 10
+	DEFAULT_FEE_ESTIMATION_MULTIPLIER = 1.5
+	MAX_WS_FAILURES                   = 3
+	// Blocks an attest transaction can wait to be included before it gets replaced
+	DEFAULT_REPLACE_AFTER_BLOCKS = 3
+	// Percentage the tip and resource prices are raised by when replacing a transaction
+	REPLACEMENT_FEE_BUMP_PERCENTAGE = 20
+)
+
+const (
+	// How long each request to the external signer can take
+	DEFAULT_SIGNER_TIMEOUT = 10 * time.Second
+	// How many times a request to the external signer failing with a connection
+	// or server error is retried
+	DEFAULT_SIGNER_MAX_RETRIES = 3
+	// Wait before the first retry of a request to the external signer, doubled on each one.
+	// Retries are at least a second apart so their HMAC timestamp differs and the signer
+	// doesn't reject them as replayed requests
+	SIGNER_RETRY_BACKOFF = time.Second
+	// How many requests in a row have to fail for an external signer to be skipped in
+	// favour of the next ones, and for how long it's skipped
+	SIGNER_CIRCUIT_BREAKER_THRESHOLD = 3
+	SIGNER_CIRCUIT_BREAKER_COOLDOWN  = 30 * time.Second
 )
@@ -9,7 +9,9 @@ import (
 	"github.com/NethermindEth/juno/utils"
 	"github.com/NethermindEth/starknet-staking-v2/validator/metrics"
 	signerP "github.com/NethermindEth/starknet-staking-v2/validator/signer"
+	"github.com/NethermindEth/starknet-staking-v2/validator/types"
 	"github.com/NethermindEth/starknet.go/rpc"
+	"github.com/cockroachdb/errors"
 	"github.com/sourcegraph/conc"
 )
 
@@ -26,10 +28,48 @@ const (
 	Failed
 )
 
+func (s AttestStatus) String() string {
+	switch s {
+	case Ongoing:
+		return "ongoing"
+	case Successful:
+		return "successful"
+	case Failed:
+		return "failed"
+	default:
+		return fmt.Sprintf("unknown(%d)", uint8(s))
+	}
+}
+
+func (s AttestStatus) MarshalText() ([]byte, error) {
+	switch s {
+	case Ongoing, Successful, Failed:
+		return []byte(s.String()), nil
+	default:
+		return nil, fmt.Errorf("invalid attest status: %d", uint8(s))
+	}
+}
+
+func (s *AttestStatus) UnmarshalText(text []byte) error {
+	for _, status := range []AttestStatus{Ongoing, Successful, Failed} {
+		if string(text) == status.String() {
+			*s = status
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid attest status: %q", text)
+}
+
 type AttestTracker struct {
 	Event           AttestRequired
 	TransactionHash felt.Felt
-	Status          AttestStatus
+	// Transaction replaced by the one in TransactionHash. It's tracked as well because
+	// either of them can be included, they share the same nonce
+	ReplacedTransactionHash felt.Felt
+	Status                  AttestStatus
+	// Max fee the tracked attest transactions can pay. When one was replaced,
+	// it's the max fee of the replacement, which is always higher
+	Fee *felt.Felt
 }
 
 func NewAttestTracker() AttestTracker {
@@ -56,32 +96,61 @@ func (a *AttestTracker) setEvent(event *AttestRequired) {
 	a.Event = *event
 }
 
-func (a *AttestTracker) setTransactionHash(txHash *felt.Felt) {
+func (a *AttestTracker) setTransactionHash(txHash *felt.Felt, fee *felt.Felt) {
 	a.TransactionHash = *txHash
+	a.Fee = fee
+}
+
+func (a *AttestTracker) setReplacementTransactionHash(txHash *felt.Felt, fee *felt.Felt) {
+	a.ReplacedTransactionHash = a.TransactionHash
+	a.TransactionHash = *txHash
+	a.Fee = fee
 }
 
 func (a *AttestTracker) resetTransactionHash() {
 	a.TransactionHash = felt.Zero
+	a.ReplacedTransactionHash = felt.Zero
+	a.Fee = nil
 }
 
 type EventDispatcher[S signerP.Signer] struct {
 	// Current epoch attest-related fields
 	CurrentAttest AttestTracker
-	// AttestFee     types.AttestFee
+	// Estimated before each attest unless set otherwise
+	AttestFee AttestFee
+	// Limits the fee the attest transactions can pay. An attest above it is either
+	// retried on the next blocks of the window, if waiting for lower fees, or skipped
+	FeeCap          FeeCap
+	WaitForLowerFee bool
+	feeCapSkipped   bool
 	// Event channels
 	AttestRequired chan AttestRequired
 	EndOfWindow    chan struct{}
+	// Optional, persists the current attest so it can be resumed after a restart
+	Journal     *AttestJournal
+	savedAttest AttestTracker
+	// Blocks the attest transaction can wait to be included before it gets replaced
+	// by one with a higher tip. Zero disables replacements
+	ReplaceAfterBlocks uint64
+	pendingBlocks      uint64
 }
 
 func NewEventDispatcher[S signerP.Signer]() EventDispatcher[S] {
 	return EventDispatcher[S]{
-		CurrentAttest: NewAttestTracker(),
-		// AttestFee:      *attestFee,
+		CurrentAttest:  NewAttestTracker(),
 		AttestRequired: make(chan AttestRequired),
 		EndOfWindow:    make(chan struct{}),
 	}
 }
 
+// Continues tracking the attest saved in the journal instead of starting from scratch,
+// with the fees spent from the daily budget
+func (d *EventDispatcher[S]) Resume(entry *JournalEntry) {
+	d.CurrentAttest = entry.Tracker()
+	d.savedAttest = d.CurrentAttest
+	d.FeeCap.RestoreSpent(entry.SpentFees)
+}
+
 func (d *EventDispatcher[S]) Dispatch(
 	signer S,
 	logger *utils.ZapLogger,
@@ -103,25 +172,87 @@ func (d *EventDispatcher[S]) Dispatch(
 				d.CurrentAttest.Status != Successful &&
 				d.CurrentAttest.TransactionHash != felt.Zero {
 				setAttestStatusOnTracking(signer, logger, &d.CurrentAttest)
+				d.saveCurrentAttest(logger)
 			}
 
 			if event == d.CurrentAttest.Event &&
 				(d.CurrentAttest.Status == Ongoing || d.CurrentAttest.Status == Successful) {
+				if d.CurrentAttest.Status == Ongoing {
+					d.replaceAttestIfStuck(signer, logger, metricsServer)
+				}
 				continue
 			}
 
+			if event == d.CurrentAttest.Event && d.feeCapSkipped {
+				continue
+			}
+
+			if event != d.CurrentAttest.Event && d.CurrentAttest.TransactionHash != felt.Zero {
+				// The target block hash changed (new epoch or reorg), the previous
+				// attest transaction is no longer relevant
+				logger.Debugw(
+					"Stop tracking attest transaction",
+					"block hash", d.CurrentAttest.Event.BlockHash.String(),
+					"transaction hash", d.CurrentAttest.TransactionHash.String(),
+				)
+				d.CurrentAttest.resetTransactionHash()
+			}
+
 			d.CurrentAttest.setEvent(&event)
+			d.pendingBlocks = 0
+			d.feeCapSkipped = false
+
+			// Avoids sending a transaction that would revert if the epoch was already
+			// attested, e.g. by a previous run of the validator or a backup instance
+			done, err := signerP.FetchAttestationDone(signer, &event.StakerAddress)
+			if err != nil {
+				logger.Warnw(
+					"Failed to check if the current epoch is already attested, attesting anyway",
+					"error", err,
+				)
+			} else if done {
+				logger.Infow(
+					"Current epoch is already attested, skipping attest",
+					"block hash", event.BlockHash.String(),
+				)
+				d.CurrentAttest.setSuccessful()
+				d.saveCurrentAttest(logger)
+				continue
+			}
+
 			d.CurrentAttest.setOngoing()
 
 			logger.Infow("Invoking attest", "block hash", event.BlockHash.String())
 
-			resp, err := signerP.InvokeAttest(signer, &event)
+			resp, fee, err := signerP.InvokeAttest(signer, &event, &d.AttestFee, &d.FeeCap)
+			if errors.Is(err, types.ErrFeeCapExceeded) {
+				metricsServer.RecordFeeCapExceeded(ChainID)
+				if d.WaitForLowerFee {
+					logger.Warnw(
+						"Attest fee is above the cap, waiting for lower fees",
+						"block hash", event.BlockHash.String(),
+						"error", err,
+					)
+				} else {
+					logger.Warnw(
+						"Attest fee is above the cap, skipping attest for the current epoch",
+						"block hash", event.BlockHash.String(),
+						"error", err,
+					)
+					d.feeCapSkipped = true
+				}
+				d.CurrentAttest.setFailed()
+				d.CurrentAttest.resetTransactionHash()
+				d.saveCurrentAttest(logger)
+				continue
+			}
 			if err != nil {
 				logger.Errorw(
 					"Failed to attest", "block hash", event.BlockHash.String(), "error", err,
 				)
 				d.CurrentAttest.setFailed()
 				d.CurrentAttest.resetTransactionHash()
+				d.saveCurrentAttest(logger)
 
 				// Record attestation failure in metrics
 				metricsServer.RecordAttestationFailure(ChainID)
@@ -131,15 +262,33 @@ func (d *EventDispatcher[S]) Dispatch(
 
 			// Record attestation submission in metrics
 			metricsServer.RecordAttestationSubmitted(ChainID)
+			metricsServer.UpdateFeesSpent(ChainID, d.FeeCap.Spent(time.Now()))
 
-			logger.Debugw("Attest transaction sent", "hash", resp.TransactionHash)
-			d.CurrentAttest.setTransactionHash(resp.TransactionHash)
+			logger.Debugw("Attest transaction sent", "hash", resp.TransactionHash, "max fee", fee)
+			d.CurrentAttest.setTransactionHash(resp.TransactionHash, fee)
+			d.saveCurrentAttest(logger)
 		case <-d.EndOfWindow:
 			logger.Info("End of window reached")
 
-			if d.CurrentAttest.Status != Successful {
-				setAttestStatusOnTracking(signer, logger, &d.CurrentAttest)
+			// The contract has the final word, the attest transaction status is only
+			// relied on when it can't be asked
+			done, err := signerP.FetchAttestationDone(
+				signer, &d.CurrentAttest.Event.StakerAddress,
+			)
+			if err != nil {
+				logger.Warnw(
+					"Failed to check if the current epoch is attested, using the attest transaction status",
+					"error", err,
+				)
+				if d.CurrentAttest.Status != Successful {
+					setAttestStatusOnTracking(signer, logger, &d.CurrentAttest)
+				}
+			} else if done {
+				d.CurrentAttest.setSuccessful()
+			} else {
+				d.CurrentAttest.setFailed()
 			}
+			d.saveCurrentAttest(logger)
 
 			if d.CurrentAttest.Status == Successful {
 				logger.Infow(
@@ -159,12 +308,91 @@ func (d *EventDispatcher[S]) Dispatch(
 	}
 }
 
+// Replaces the attest transaction by one paying a higher tip once it has been waiting
+// to be included for too many blocks
+func (d *EventDispatcher[S]) replaceAttestIfStuck(
+	signer S, logger *utils.ZapLogger, metricsServer *metrics.Metrics,
+) {
+	if d.ReplaceAfterBlocks == 0 || d.CurrentAttest.TransactionHash == felt.Zero {
+		return
+	}
+
+	d.pendingBlocks++
+	if d.pendingBlocks < d.ReplaceAfterBlocks {
+		return
+	}
+	d.pendingBlocks = 0
+
+	logger.Infow(
+		"Attest transaction is not included yet, replacing it with a higher tip",
+		"transaction hash", d.CurrentAttest.TransactionHash.String(),
+		"blocks waiting", d.ReplaceAfterBlocks,
+	)
+
+	resp, fee, err := signerP.ReplaceAttest(
+		signer, &d.CurrentAttest.TransactionHash, d.CurrentAttest.Fee, &d.FeeCap,
+	)
+	if errors.Is(err, types.ErrFeeCapExceeded) {
+		metricsServer.RecordFeeCapExceeded(ChainID)
+		logger.Warnw(
+			"Replacement attest transaction would be above the fee cap, keeping the original one",
+			"transaction hash", d.CurrentAttest.TransactionHash.String(),
+			"error", err,
+		)
+		return
+	}
+	if err != nil {
+		logger.Warnw(
+			"Failed to replace attest transaction",
+			"transaction hash", d.CurrentAttest.TransactionHash.String(),
+			"error", err,
+		)
+		return
+	}
+
+	metricsServer.RecordAttestationReplaced(ChainID)
+	metricsServer.UpdateFeesSpent(ChainID, d.FeeCap.Spent(time.Now()))
+
+	logger.Debugw(
+		"Replacement attest transaction sent", "hash", resp.TransactionHash, "max fee", fee,
+	)
+	d.CurrentAttest.setReplacementTransactionHash(resp.TransactionHash, fee)
+	d.saveCurrentAttest(logger)
+}
+
+// Writes the current attest to the journal, if any, when it changed since the last write
+func (d *EventDispatcher[S]) saveCurrentAttest(logger *utils.ZapLogger) {
+	if d.Journal == nil || d.CurrentAttest == d.savedAttest {
+		return
+	}
+
+	entry := JournalEntryFromTracker(&d.CurrentAttest)
+	// Fees are only spent when sending a transaction, which always changes the attest
+	entry.SpentFees = d.FeeCap.SpentFees(time.Now())
+	if err := d.Journal.Save(&entry); err != nil {
+		logger.Errorw("Failed to save attest journal", "path", d.Journal.Path(), "error", err)
+		return
+	}
+	d.savedAttest = d.CurrentAttest
+}
+
 func setAttestStatusOnTracking[S signerP.Signer](
 	signer S,
 	logger *utils.ZapLogger,
 	attestToTrack *AttestTracker,
 ) {
 	status := TrackAttest(signer, logger, &attestToTrack.Event, &attestToTrack.TransactionHash)
+	if status != Successful && attestToTrack.ReplacedTransactionHash != felt.Zero {
+		replacedStatus := TrackAttest(
+			signer, logger, &attestToTrack.Event, &attestToTrack.ReplacedTransactionHash,
+		)
+		if replacedStatus == Successful {
+			// The replaced transaction made it first, its replacement can't be included anymore
+			attestToTrack.TransactionHash = attestToTrack.ReplacedTransactionHash
+			attestToTrack.ReplacedTransactionHash = felt.Zero
+			status = Successful
+		}
+	}
 	attestToTrack.Status = status
 	switch status {
 	case Ongoing:
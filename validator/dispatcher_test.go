@@ -3,6 +3,7 @@ package validator_test
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/NethermindEth/juno/core/felt"
 	"github.com/NethermindEth/juno/utils"
@@ -13,6 +14,7 @@ import (
 	"github.com/NethermindEth/starknet-staking-v2/validator/metrics"
 	"github.com/NethermindEth/starknet-staking-v2/validator/types"
 	"github.com/NethermindEth/starknet.go/rpc"
+	snGoUtils "github.com/NethermindEth/starknet.go/utils"
 	"github.com/cockroachdb/errors"
 	"github.com/sourcegraph/conc"
 	"github.com/stretchr/testify/require"
@@ -42,10 +44,9 @@ func TestDispatch(t *testing.T) {
 		}}
 		addTxHash := utils.HexToFelt(t, "0x123")
 		mockedAddTxResp := rpc.AddInvokeTransactionResponse{TransactionHash: addTxHash}
+		mockEstimateAttestFee(mockAccount, calls)
 		mockAccount.EXPECT().
-			BuildAndSendInvokeTxn(
-				context.Background(), calls, constants.FEE_ESTIMATION_MULTIPLIER,
-			).
+			BuildAndSendInvokeTxn(context.Background(), calls, &attestResourceBounds).
 			Return(&mockedAddTxResp, nil)
 		mockAccount.EXPECT().ValidationContracts().Return(
 			validator.SepoliaValidationContracts(t),
@@ -54,21 +55,18 @@ func TestDispatch(t *testing.T) {
 		// Create a mock metrics server
 		metricsServer := metrics.NewMockMetricsForTest(logger)
 
+		mockAttestationDone(t, mockAccount, &validator.Address{}, false)
+
 		// Start routine
 		wg := &conc.WaitGroup{}
 		wg.Go(func() { dispatcher.Dispatch(mockAccount, logger, metricsServer) })
 
-		// Send event
+		// Send event, the epoch is not attested yet
 		blockHash := validator.BlockHash(*blockHashFelt)
 		dispatcher.AttestRequired <- validator.AttestRequired{BlockHash: blockHash}
 
-		// Preparation for EndOfWindow event
-		mockAccount.EXPECT().
-			GetTransactionStatus(context.Background(), addTxHash).
-			Return(&rpc.TxnStatusResult{
-				FinalityStatus:  rpc.TxnStatus_Accepted_On_L2,
-				ExecutionStatus: rpc.TxnExecutionStatusSUCCEEDED,
-			}, nil)
+		// Preparation for EndOfWindow event: the contract reports the epoch as attested
+		mockAttestationDone(t, mockAccount, &validator.Address{}, true)
 
 		// Send EndOfWindow
 		dispatcher.EndOfWindow <- struct{}{}
@@ -82,6 +80,7 @@ func TestDispatch(t *testing.T) {
 			Event:           validator.AttestRequired{BlockHash: blockHash},
 			TransactionHash: *addTxHash,
 			Status:          validator.Successful,
+			Fee:             attestMaxFee,
 		}
 		require.Equal(t, expectedAttest, dispatcher.CurrentAttest)
 	})
@@ -107,10 +106,9 @@ func TestDispatch(t *testing.T) {
 			addTxHash := utils.HexToFelt(t, "0x123")
 			mockedAddTxResp := rpc.AddInvokeTransactionResponse{TransactionHash: addTxHash}
 			// We expect BuildAndSendInvokeTxn to be called only once (even though 3 events are sent)
+			mockEstimateAttestFee(mockAccount, calls)
 			mockAccount.EXPECT().
-				BuildAndSendInvokeTxn(
-					context.Background(), calls, constants.FEE_ESTIMATION_MULTIPLIER,
-				).
+				BuildAndSendInvokeTxn(context.Background(), calls, &attestResourceBounds).
 				Return(&mockedAddTxResp, nil).
 				Times(1)
 			mockAccount.EXPECT().ValidationContracts().Return(
@@ -120,6 +118,8 @@ func TestDispatch(t *testing.T) {
 			// Create a mock metrics server
 			metricsServer := metrics.NewMockMetricsForTest(logger)
 
+			mockAttestationDone(t, mockAccount, &validator.Address{}, false)
+
 			// Start routine
 			wg := &conc.WaitGroup{}
 			wg.Go(func() { dispatcher.Dispatch(mockAccount, logger, metricsServer) })
@@ -166,6 +166,7 @@ func TestDispatch(t *testing.T) {
 				Event:           validator.AttestRequired{BlockHash: blockHash},
 				TransactionHash: *addTxHash,
 				Status:          validator.Successful,
+				Fee:             attestMaxFee,
 			}
 			require.Equal(t, expectedAttest, dispatcher.CurrentAttest)
 		},
@@ -191,10 +192,9 @@ func TestDispatch(t *testing.T) {
 		mockedAddTxResp1 := rpc.AddInvokeTransactionResponse{TransactionHash: addTxHash1}
 
 		// We expect BuildAndSendInvokeTxn to be called only once (for the 2 first events)
+		mockEstimateAttestFee(mockAccount, calls)
 		mockAccount.EXPECT().
-			BuildAndSendInvokeTxn(
-				context.Background(), calls, constants.FEE_ESTIMATION_MULTIPLIER,
-			).
+			BuildAndSendInvokeTxn(context.Background(), calls, &attestResourceBounds).
 			Return(&mockedAddTxResp1, nil).
 			Times(1)
 		mockAccount.EXPECT().ValidationContracts().Return(
@@ -204,6 +204,8 @@ func TestDispatch(t *testing.T) {
 		// Create a mock metrics server
 		metricsServer := metrics.NewMockMetricsForTest(logger)
 
+		mockAttestationDone(t, mockAccount, &validator.Address{}, false)
+
 		// Start routine
 		wg := &conc.WaitGroup{}
 		wg.Go(func() { dispatcher.Dispatch(mockAccount, logger, metricsServer) })
@@ -242,16 +244,17 @@ func TestDispatch(t *testing.T) {
 		mockedAddTxResp2 := rpc.AddInvokeTransactionResponse{TransactionHash: addTxHash2}
 
 		// We expect a 2nd call to BuildAndSendInvokeTxn
+		mockEstimateAttestFee(mockAccount, calls)
 		mockAccount.EXPECT().
-			BuildAndSendInvokeTxn(
-				context.Background(), calls, constants.FEE_ESTIMATION_MULTIPLIER,
-			).
+			BuildAndSendInvokeTxn(context.Background(), calls, &attestResourceBounds).
 			Return(&mockedAddTxResp2, nil).
 			Times(1)
 		mockAccount.EXPECT().ValidationContracts().Return(
 			validator.SepoliaValidationContracts(t),
 		).Times(1)
 
+		mockAttestationDone(t, mockAccount, &validator.Address{}, false)
+
 		// This 3rd event does not get ignored as invoke attestation has failed
 		// Proof: a 2nd call to BuildAndSendInvokeTxn is asserted
 		dispatcher.AttestRequired <- validator.AttestRequired{BlockHash: blockHash}
@@ -265,6 +268,7 @@ func TestDispatch(t *testing.T) {
 			Event:           validator.AttestRequired{BlockHash: blockHash},
 			TransactionHash: *addTxHash2,
 			Status:          validator.Ongoing,
+			Fee:             attestMaxFee,
 		}
 		require.Equal(t, expectedAttest, dispatcher.CurrentAttest)
 	})
@@ -288,10 +292,9 @@ func TestDispatch(t *testing.T) {
 			}}
 
 			// We expect BuildAndSendInvokeTxn to fail once
+			mockEstimateAttestFee(mockAccount, calls)
 			mockAccount.EXPECT().
-				BuildAndSendInvokeTxn(
-					context.Background(), calls, constants.FEE_ESTIMATION_MULTIPLIER,
-				).
+				BuildAndSendInvokeTxn(context.Background(), calls, &attestResourceBounds).
 				Return(nil, errors.New("sending invoke tx failed for some reason")).
 				Times(1)
 			mockAccount.EXPECT().ValidationContracts().Return(
@@ -301,6 +304,8 @@ func TestDispatch(t *testing.T) {
 			// Create a mock metrics server
 			metricsServer := metrics.NewMockMetricsForTest(logger)
 
+			mockAttestationDone(t, mockAccount, &validator.Address{}, false)
+
 			// Start routine
 			wg := &conc.WaitGroup{}
 			wg.Go(func() { dispatcher.Dispatch(mockAccount, logger, metricsServer) })
@@ -317,13 +322,14 @@ func TestDispatch(t *testing.T) {
 			// Next call to BuildAndSendInvokeTxn succeeds
 			addTxHash := utils.HexToFelt(t, "0x123")
 			mockedAddTxResp := rpc.AddInvokeTransactionResponse{TransactionHash: addTxHash}
+			mockEstimateAttestFee(mockAccount, calls)
 			mockAccount.EXPECT().
-				BuildAndSendInvokeTxn(
-					context.Background(), calls, constants.FEE_ESTIMATION_MULTIPLIER,
-				).
+				BuildAndSendInvokeTxn(context.Background(), calls, &attestResourceBounds).
 				Return(&mockedAddTxResp, nil).
 				Times(1)
 
+			mockAttestationDone(t, mockAccount, &validator.Address{}, false)
+
 			// This 2nd event gets considered as previous one failed
 			dispatcher.AttestRequired <- validator.AttestRequired{BlockHash: blockHash}
 
@@ -352,10 +358,78 @@ func TestDispatch(t *testing.T) {
 				Event:           validator.AttestRequired{BlockHash: blockHash},
 				TransactionHash: *addTxHash,
 				Status:          validator.Successful,
+				Fee:             attestMaxFee,
 			}
 			require.Equal(t, expectedAttest, dispatcher.CurrentAttest)
 		})
 
+	t.Run("Reorg replacing the target block stops tracking the stale attest", func(t *testing.T) {
+		// Setup
+		dispatcher := validator.NewEventDispatcher[*mocks.MockSigner]()
+		staleBlockHashFelt := new(felt.Felt).SetUint64(1)
+		newBlockHashFelt := new(felt.Felt).SetUint64(2)
+		staleEvent := validator.AttestRequired{
+			BlockHash: validator.BlockHash(*staleBlockHashFelt), EpochId: 1516, TargetBlock: 10,
+		}
+		newEvent := validator.AttestRequired{
+			BlockHash: validator.BlockHash(*newBlockHashFelt), EpochId: 1516, TargetBlock: 10,
+		}
+
+		attestCalls := func(blockHash *felt.Felt) []rpc.InvokeFunctionCall {
+			return []rpc.InvokeFunctionCall{{
+				ContractAddress: validationContracts.Attest.Felt(),
+				FunctionName:    "attest",
+				CallData:        []*felt.Felt{blockHash},
+			}}
+		}
+		staleTxHash := utils.HexToFelt(t, "0x123")
+		mockAttestationDone(t, mockAccount, &validator.Address{}, false)
+		mockAccount.EXPECT().ValidationContracts().Return(
+			validator.SepoliaValidationContracts(t),
+		).Times(1)
+		mockEstimateAttestFee(mockAccount, attestCalls(staleBlockHashFelt))
+		mockAccount.EXPECT().
+			BuildAndSendInvokeTxn(
+				context.Background(), attestCalls(staleBlockHashFelt), &attestResourceBounds,
+			).
+			Return(&rpc.AddInvokeTransactionResponse{TransactionHash: staleTxHash}, nil)
+
+		metricsServer := metrics.NewMockMetricsForTest(logger)
+
+		wg := &conc.WaitGroup{}
+		wg.Go(func() { dispatcher.Dispatch(mockAccount, logger, metricsServer) })
+
+		dispatcher.AttestRequired <- staleEvent
+
+		// The reorg replaced the target block: the stale attest transaction isn't tracked
+		// anymore (no call to GetTransactionStatus) and the new hash is attested
+		newTxHash := utils.HexToFelt(t, "0x456")
+		mockAttestationDone(t, mockAccount, &validator.Address{}, false)
+		mockAccount.EXPECT().ValidationContracts().Return(
+			validator.SepoliaValidationContracts(t),
+		).Times(1)
+		mockEstimateAttestFee(mockAccount, attestCalls(newBlockHashFelt))
+		mockAccount.EXPECT().
+			BuildAndSendInvokeTxn(
+				context.Background(), attestCalls(newBlockHashFelt), &attestResourceBounds,
+			).
+			Return(&rpc.AddInvokeTransactionResponse{TransactionHash: newTxHash}, nil)
+
+		dispatcher.AttestRequired <- newEvent
+
+		close(dispatcher.AttestRequired)
+		wg.Wait()
+
+		// Assert
+		expectedAttest := validator.AttestTracker{
+			Event:           newEvent,
+			TransactionHash: *newTxHash,
+			Status:          validator.Ongoing,
+			Fee:             attestMaxFee,
+		}
+		require.Equal(t, expectedAttest, dispatcher.CurrentAttest)
+	})
+
 	t.Run("AttestRequired events transition with EndOfWindow events", func(t *testing.T) {
 		// Sequence of actions:
 		// - an AttestRequired event A is emitted and processed (successful)
@@ -378,20 +452,12 @@ func TestDispatch(t *testing.T) {
 		mockedAddTxRespA := rpc.AddInvokeTransactionResponse{TransactionHash: addTxHashA}
 
 		// We expect BuildAndSendInvokeTxn to be called once for event A
+		mockEstimateAttestFee(mockAccount, callsA)
 		mockAccount.EXPECT().
-			BuildAndSendInvokeTxn(context.Background(), callsA, constants.FEE_ESTIMATION_MULTIPLIER).
+			BuildAndSendInvokeTxn(context.Background(), callsA, &attestResourceBounds).
 			Return(&mockedAddTxRespA, nil).
 			Times(1)
 
-		// We expect GetTransactionStatus to be called for event A (triggered by EndOfWindow)
-		mockAccount.EXPECT().
-			GetTransactionStatus(context.Background(), addTxHashA).
-			Return(&rpc.TxnStatusResult{
-				FinalityStatus:  rpc.TxnStatus_Accepted_On_L2,
-				ExecutionStatus: rpc.TxnExecutionStatusSUCCEEDED,
-			}, nil).
-			Times(1)
-
 		// For event B
 		blockHashFeltB := new(felt.Felt).SetUint64(2)
 		callsB := []rpc.InvokeFunctionCall{{
@@ -403,21 +469,12 @@ func TestDispatch(t *testing.T) {
 		mockedAddTxRespB := rpc.AddInvokeTransactionResponse{TransactionHash: addTxHashB}
 
 		// We expect BuildAndSendInvokeTxn to be called once for event B
+		mockEstimateAttestFee(mockAccount, callsB)
 		mockAccount.EXPECT().
-			BuildAndSendInvokeTxn(
-				context.Background(), callsB, constants.FEE_ESTIMATION_MULTIPLIER,
-			).
+			BuildAndSendInvokeTxn(context.Background(), callsB, &attestResourceBounds).
 			Return(&mockedAddTxRespB, nil).
 			Times(1)
 
-		// We expect GetTransactionStatus to be called once for event B (triggered by EndOfWindow)
-		mockAccount.EXPECT().
-			GetTransactionStatus(context.Background(), addTxHashB).
-			Return(&rpc.TxnStatusResult{
-				FinalityStatus: rpc.TxnStatus_Rejected,
-			}, nil).
-			Times(1)
-
 		mockAccount.EXPECT().ValidationContracts().Return(
 			validator.SepoliaValidationContracts(t),
 		).Times(2)
@@ -429,18 +486,24 @@ func TestDispatch(t *testing.T) {
 		wg := &conc.WaitGroup{}
 		wg.Go(func() { dispatcher.Dispatch(mockAccount, logger, metricsServer) })
 
+		mockAttestationDone(t, mockAccount, &validator.Address{}, false)
+
 		// Send event A
 		blockHashA := validator.BlockHash(*blockHashFeltA)
 		dispatcher.AttestRequired <- validator.AttestRequired{BlockHash: blockHashA}
 
-		// Send EndOfWindow event for event A
+		// Send EndOfWindow event for event A, the contract reports the epoch as attested
+		mockAttestationDone(t, mockAccount, &validator.Address{}, true)
 		dispatcher.EndOfWindow <- struct{}{}
 
+		mockAttestationDone(t, mockAccount, &validator.Address{}, false)
+
 		// Send event B
 		blockHashB := validator.BlockHash(*blockHashFeltB)
 		dispatcher.AttestRequired <- validator.AttestRequired{BlockHash: blockHashB}
 
-		// Send EndOfWindow event for event B
+		// Send EndOfWindow event for event B, the contract reports the epoch as not attested
+		mockAttestationDone(t, mockAccount, &validator.Address{}, false)
 		dispatcher.EndOfWindow <- struct{}{}
 
 		close(dispatcher.AttestRequired)
@@ -452,6 +515,482 @@ func TestDispatch(t *testing.T) {
 			Event:           validator.AttestRequired{BlockHash: blockHashB},
 			TransactionHash: *addTxHashB,
 			Status:          validator.Failed,
+			Fee:             attestMaxFee,
+		}
+		require.Equal(t, expectedAttest, dispatcher.CurrentAttest)
+	})
+
+	t.Run("Attest changes are saved to the journal", func(t *testing.T) {
+		// Setup
+		dispatcher := validator.NewEventDispatcher[*mocks.MockSigner]()
+		operationalAddress := types.AddressFromString("0x123")
+		journal, err := validator.NewAttestJournal(t.TempDir(), &operationalAddress)
+		require.NoError(t, err)
+		dispatcher.Journal = journal
+
+		blockHashFelt := new(felt.Felt).SetUint64(1)
+		calls := []rpc.InvokeFunctionCall{{
+			ContractAddress: validationContracts.Attest.Felt(),
+			FunctionName:    "attest",
+			CallData:        []*felt.Felt{blockHashFelt},
+		}}
+		addTxHash := utils.HexToFelt(t, "0x123")
+		mockEstimateAttestFee(mockAccount, calls)
+		mockAccount.EXPECT().
+			BuildAndSendInvokeTxn(context.Background(), calls, &attestResourceBounds).
+			Return(&rpc.AddInvokeTransactionResponse{TransactionHash: addTxHash}, nil)
+		mockAccount.EXPECT().ValidationContracts().Return(
+			validator.SepoliaValidationContracts(t),
+		).Times(1)
+
+		metricsServer := metrics.NewMockMetricsForTest(logger)
+
+		wg := &conc.WaitGroup{}
+		wg.Go(func() { dispatcher.Dispatch(mockAccount, logger, metricsServer) })
+
+		event := validator.AttestRequired{
+			BlockHash:     validator.BlockHash(*blockHashFelt),
+			EpochId:       1516,
+			TargetBlock:   639276,
+			StakerAddress: types.AddressFromString("0x456"),
+		}
+		mockAttestationDone(t, mockAccount, &event.StakerAddress, false)
+		dispatcher.AttestRequired <- event
+
+		mockAttestationDone(t, mockAccount, &event.StakerAddress, true)
+		dispatcher.EndOfWindow <- struct{}{}
+
+		close(dispatcher.AttestRequired)
+		wg.Wait()
+
+		// Assert
+		entry, err := journal.Load()
+		require.NoError(t, err)
+		// The fee spent is saved to keep the daily budget after a restart
+		require.Len(t, entry.SpentFees, 1)
+		require.Equal(t, attestMaxFee, entry.SpentFees[0].Fee)
+		entry.SpentFees = nil
+		expectedEntry := validator.JournalEntry{
+			EpochId:         1516,
+			TargetBlock:     639276,
+			StakerAddress:   *event.StakerAddress.Felt(),
+			BlockHash:       *blockHashFelt,
+			TransactionHash: *addTxHash,
+			Status:          validator.Successful,
+			Fee:             attestMaxFee,
+		}
+		require.Equal(t, &expectedEntry, entry)
+	})
+
+	t.Run("Attest resumed from the journal is tracked instead of sent again", func(t *testing.T) {
+		// Setup
+		dispatcher := validator.NewEventDispatcher[*mocks.MockSigner]()
+
+		event := validator.AttestRequired{
+			BlockHash:   validator.BlockHash(*new(felt.Felt).SetUint64(1)),
+			EpochId:     1516,
+			TargetBlock: 639276,
+		}
+		addTxHash := utils.HexToFelt(t, "0x123")
+		dispatcher.Resume(&validator.JournalEntry{
+			EpochId:         event.EpochId,
+			TargetBlock:     event.TargetBlock.Uint64(),
+			BlockHash:       *event.BlockHash.Felt(),
+			TransactionHash: *addTxHash,
+			Status:          validator.Ongoing,
+			SpentFees: []types.SpentFee{
+				{At: time.Now(), Fee: new(felt.Felt).SetUint64(1000)},
+			},
+		})
+		require.Equal(t, new(felt.Felt).SetUint64(1000), dispatcher.FeeCap.Spent(time.Now()))
+
+		// No call to BuildAndSendInvokeTxn is expected, only tracking the transaction
+		mockAccount.EXPECT().
+			GetTransactionStatus(context.Background(), addTxHash).
+			Return(&rpc.TxnStatusResult{
+				FinalityStatus: rpc.TxnStatus_Received,
+			}, nil).
+			Times(1)
+
+		metricsServer := metrics.NewMockMetricsForTest(logger)
+
+		wg := &conc.WaitGroup{}
+		wg.Go(func() { dispatcher.Dispatch(mockAccount, logger, metricsServer) })
+
+		dispatcher.AttestRequired <- event
+		close(dispatcher.AttestRequired)
+		wg.Wait()
+
+		// Assert
+		expectedAttest := validator.AttestTracker{
+			Event:           event,
+			TransactionHash: *addTxHash,
+			Status:          validator.Ongoing,
+		}
+		require.Equal(t, expectedAttest, dispatcher.CurrentAttest)
+	})
+
+	t.Run("Stuck attest transaction is replaced and both hashes are tracked", func(t *testing.T) {
+		// Setup
+		dispatcher := validator.NewEventDispatcher[*mocks.MockSigner]()
+		dispatcher.ReplaceAfterBlocks = 2
+		blockHashFelt := new(felt.Felt).SetUint64(1)
+		event := validator.AttestRequired{BlockHash: validator.BlockHash(*blockHashFelt)}
+
+		calls := []rpc.InvokeFunctionCall{{
+			ContractAddress: validationContracts.Attest.Felt(),
+			FunctionName:    "attest",
+			CallData:        []*felt.Felt{blockHashFelt},
+		}}
+		addTxHash := utils.HexToFelt(t, "0x123")
+		mockAttestationDone(t, mockAccount, &validator.Address{}, false)
+		mockAccount.EXPECT().ValidationContracts().Return(
+			validator.SepoliaValidationContracts(t),
+		).Times(1)
+		mockEstimateAttestFee(mockAccount, calls)
+		mockAccount.EXPECT().
+			BuildAndSendInvokeTxn(context.Background(), calls, &attestResourceBounds).
+			Return(&rpc.AddInvokeTransactionResponse{TransactionHash: addTxHash}, nil)
+
+		// The transaction stays received for the next 2 blocks
+		mockAccount.EXPECT().
+			GetTransactionStatus(context.Background(), addTxHash).
+			Return(&rpc.TxnStatusResult{FinalityStatus: rpc.TxnStatus_Received}, nil).
+			Times(2)
+
+		// So it gets replaced
+		replacementTxHash := utils.HexToFelt(t, "0x456")
+		mockAccount.EXPECT().
+			ReplaceInvokeTxn(
+				context.Background(),
+				addTxHash,
+				uint64(constants.REPLACEMENT_FEE_BUMP_PERCENTAGE),
+				nil,
+			).
+			Return(
+				&rpc.AddInvokeTransactionResponse{TransactionHash: replacementTxHash},
+				replacementMaxFee,
+				nil,
+			)
+
+		metricsServer := metrics.NewMockMetricsForTest(logger)
+
+		wg := &conc.WaitGroup{}
+		wg.Go(func() { dispatcher.Dispatch(mockAccount, logger, metricsServer) })
+
+		for range 3 {
+			dispatcher.AttestRequired <- event
+		}
+
+		// The original transaction ends up included before its replacement
+		mockAccount.EXPECT().
+			GetTransactionStatus(context.Background(), replacementTxHash).
+			Return(nil, validator.ErrTxnHashNotFound)
+		mockAccount.EXPECT().
+			GetTransactionStatus(context.Background(), addTxHash).
+			Return(&rpc.TxnStatusResult{
+				FinalityStatus:  rpc.TxnStatus_Accepted_On_L2,
+				ExecutionStatus: rpc.TxnExecutionStatusSUCCEEDED,
+			}, nil)
+
+		// No more replacements nor tracking once it's successful
+		dispatcher.AttestRequired <- event
+		dispatcher.AttestRequired <- event
+
+		close(dispatcher.AttestRequired)
+		wg.Wait()
+
+		// Assert
+		expectedAttest := validator.AttestTracker{
+			Event:           event,
+			TransactionHash: *addTxHash,
+			Status:          validator.Successful,
+			Fee:             replacementMaxFee,
+		}
+		require.Equal(t, expectedAttest, dispatcher.CurrentAttest)
+	})
+
+	t.Run("Failed replacement is retried after more blocks", func(t *testing.T) {
+		// Setup
+		dispatcher := validator.NewEventDispatcher[*mocks.MockSigner]()
+		dispatcher.ReplaceAfterBlocks = 1
+		blockHashFelt := new(felt.Felt).SetUint64(1)
+		event := validator.AttestRequired{BlockHash: validator.BlockHash(*blockHashFelt)}
+
+		addTxHash := utils.HexToFelt(t, "0x123")
+		mockAttestationDone(t, mockAccount, &validator.Address{}, false)
+		mockAccount.EXPECT().ValidationContracts().Return(
+			validator.SepoliaValidationContracts(t),
+		).Times(1)
+		mockEstimateAttestFee(mockAccount, gomock.Any())
+		mockAccount.EXPECT().
+			BuildAndSendInvokeTxn(context.Background(), gomock.Any(), &attestResourceBounds).
+			Return(&rpc.AddInvokeTransactionResponse{TransactionHash: addTxHash}, nil)
+
+		mockAccount.EXPECT().
+			GetTransactionStatus(context.Background(), addTxHash).
+			Return(&rpc.TxnStatusResult{FinalityStatus: rpc.TxnStatus_Received}, nil).
+			Times(2)
+
+		replacementTxHash := utils.HexToFelt(t, "0x456")
+		gomock.InOrder(
+			mockAccount.EXPECT().
+				ReplaceInvokeTxn(context.Background(), addTxHash, gomock.Any(), gomock.Any()).
+				Return(nil, nil, errors.New("some replacement error")),
+			mockAccount.EXPECT().
+				ReplaceInvokeTxn(context.Background(), addTxHash, gomock.Any(), gomock.Any()).
+				Return(
+					&rpc.AddInvokeTransactionResponse{TransactionHash: replacementTxHash},
+					replacementMaxFee,
+					nil,
+				),
+		)
+
+		metricsServer := metrics.NewMockMetricsForTest(logger)
+
+		wg := &conc.WaitGroup{}
+		wg.Go(func() { dispatcher.Dispatch(mockAccount, logger, metricsServer) })
+
+		for range 3 {
+			dispatcher.AttestRequired <- event
+		}
+		close(dispatcher.AttestRequired)
+		wg.Wait()
+
+		// Assert
+		expectedAttest := validator.AttestTracker{
+			Event:                   event,
+			TransactionHash:         *replacementTxHash,
+			ReplacedTransactionHash: *addTxHash,
+			Status:                  validator.Ongoing,
+			Fee:                     replacementMaxFee,
+		}
+		require.Equal(t, expectedAttest, dispatcher.CurrentAttest)
+	})
+
+	t.Run("Attest is skipped if the epoch is already attested", func(t *testing.T) {
+		// Setup
+		dispatcher := validator.NewEventDispatcher[*mocks.MockSigner]()
+		stakerAddress := types.AddressFromString("0x456")
+		event := validator.AttestRequired{
+			BlockHash:     validator.BlockHash(*new(felt.Felt).SetUint64(1)),
+			StakerAddress: stakerAddress,
+		}
+
+		// No call to BuildAndSendInvokeTxn is expected, the contract is asked only once
+		mockAttestationDone(t, mockAccount, &stakerAddress, true)
+
+		metricsServer := metrics.NewMockMetricsForTest(logger)
+
+		wg := &conc.WaitGroup{}
+		wg.Go(func() { dispatcher.Dispatch(mockAccount, logger, metricsServer) })
+
+		dispatcher.AttestRequired <- event
+		dispatcher.AttestRequired <- event
+		close(dispatcher.AttestRequired)
+		wg.Wait()
+
+		// Assert
+		expectedAttest := validator.AttestTracker{
+			Event:           event,
+			TransactionHash: felt.Zero,
+			Status:          validator.Successful,
+		}
+		require.Equal(t, expectedAttest, dispatcher.CurrentAttest)
+	})
+
+	t.Run("Attest is sent if asking the contract fails", func(t *testing.T) {
+		// Setup
+		dispatcher := validator.NewEventDispatcher[*mocks.MockSigner]()
+		blockHashFelt := new(felt.Felt).SetUint64(1)
+
+		mockAccount.EXPECT().ValidationContracts().Return(
+			validator.SepoliaValidationContracts(t),
+		).Times(2)
+		mockAccount.EXPECT().
+			Call(context.Background(), gomock.Any(), rpc.BlockID{Tag: "latest"}).
+			Return(nil, errors.New("some contract error"))
+
+		calls := []rpc.InvokeFunctionCall{{
+			ContractAddress: validationContracts.Attest.Felt(),
+			FunctionName:    "attest",
+			CallData:        []*felt.Felt{blockHashFelt},
+		}}
+		addTxHash := utils.HexToFelt(t, "0x123")
+		mockEstimateAttestFee(mockAccount, calls)
+		mockAccount.EXPECT().
+			BuildAndSendInvokeTxn(context.Background(), calls, &attestResourceBounds).
+			Return(&rpc.AddInvokeTransactionResponse{TransactionHash: addTxHash}, nil)
+
+		metricsServer := metrics.NewMockMetricsForTest(logger)
+
+		wg := &conc.WaitGroup{}
+		wg.Go(func() { dispatcher.Dispatch(mockAccount, logger, metricsServer) })
+
+		blockHash := validator.BlockHash(*blockHashFelt)
+		dispatcher.AttestRequired <- validator.AttestRequired{BlockHash: blockHash}
+
+		// At the end of window the contract can't be asked either,
+		// so the attest transaction status is used instead
+		mockAccount.EXPECT().ValidationContracts().Return(
+			validator.SepoliaValidationContracts(t),
+		).Times(1)
+		mockAccount.EXPECT().
+			Call(context.Background(), gomock.Any(), rpc.BlockID{Tag: "latest"}).
+			Return(nil, errors.New("some contract error"))
+		mockAccount.EXPECT().
+			GetTransactionStatus(context.Background(), addTxHash).
+			Return(&rpc.TxnStatusResult{
+				FinalityStatus:  rpc.TxnStatus_Accepted_On_L2,
+				ExecutionStatus: rpc.TxnExecutionStatusSUCCEEDED,
+			}, nil)
+		dispatcher.EndOfWindow <- struct{}{}
+
+		close(dispatcher.AttestRequired)
+		wg.Wait()
+
+		// Assert
+		expectedAttest := validator.AttestTracker{
+			Event:           validator.AttestRequired{BlockHash: blockHash},
+			TransactionHash: *addTxHash,
+			Status:          validator.Successful,
+			Fee:             attestMaxFee,
+		}
+		require.Equal(t, expectedAttest, dispatcher.CurrentAttest)
+	})
+
+	t.Run("End of window relies on the contract over the attest transaction", func(t *testing.T) {
+		// Setup: the attest transaction was successful but for a block that got reorged out
+		dispatcher := validator.NewEventDispatcher[*mocks.MockSigner]()
+		event := validator.AttestRequired{BlockHash: validator.BlockHash(*new(felt.Felt).SetUint64(1))}
+		addTxHash := utils.HexToFelt(t, "0x123")
+		dispatcher.Resume(&validator.JournalEntry{
+			BlockHash:       *event.BlockHash.Felt(),
+			TransactionHash: *addTxHash,
+			Status:          validator.Successful,
+		})
+
+		// No call to GetTransactionStatus is expected
+		mockAttestationDone(t, mockAccount, &validator.Address{}, false)
+
+		metricsServer := metrics.NewMockMetricsForTest(logger)
+
+		wg := &conc.WaitGroup{}
+		wg.Go(func() { dispatcher.Dispatch(mockAccount, logger, metricsServer) })
+
+		dispatcher.EndOfWindow <- struct{}{}
+		close(dispatcher.AttestRequired)
+		wg.Wait()
+
+		// Assert
+		expectedAttest := validator.AttestTracker{
+			Event:           event,
+			TransactionHash: *addTxHash,
+			Status:          validator.Failed,
+		}
+		require.Equal(t, expectedAttest, dispatcher.CurrentAttest)
+	})
+
+	t.Run("Attest above the fee cap is skipped for the rest of the epoch", func(t *testing.T) {
+		// Setup
+		dispatcher := validator.NewEventDispatcher[*mocks.MockSigner]()
+		feeCap, err := types.FeeCapFromStrings("0x3fff", "")
+		require.NoError(t, err)
+		dispatcher.FeeCap = feeCap
+		blockHashFelt := new(felt.Felt).SetUint64(1)
+		event := validator.AttestRequired{BlockHash: validator.BlockHash(*blockHashFelt)}
+
+		calls := []rpc.InvokeFunctionCall{{
+			ContractAddress: validationContracts.Attest.Felt(),
+			FunctionName:    "attest",
+			CallData:        []*felt.Felt{blockHashFelt},
+		}}
+		mockAttestationDone(t, mockAccount, &validator.Address{}, false)
+		mockAccount.EXPECT().ValidationContracts().Return(
+			validator.SepoliaValidationContracts(t),
+		).Times(1)
+		// No call to BuildAndSendInvokeTxn is expected
+		mockEstimateAttestFee(mockAccount, calls)
+
+		metricsServer := metrics.NewMockMetricsForTest(logger)
+
+		wg := &conc.WaitGroup{}
+		wg.Go(func() { dispatcher.Dispatch(mockAccount, logger, metricsServer) })
+
+		// The following events of the same epoch don't attempt to attest again
+		dispatcher.AttestRequired <- event
+		dispatcher.AttestRequired <- event
+		dispatcher.AttestRequired <- event
+
+		close(dispatcher.AttestRequired)
+		wg.Wait()
+
+		// Assert
+		expectedAttest := validator.AttestTracker{
+			Event:           event,
+			TransactionHash: felt.Zero,
+			Status:          validator.Failed,
+		}
+		require.Equal(t, expectedAttest, dispatcher.CurrentAttest)
+	})
+
+	t.Run("Attest above the fee cap waits for lower fees", func(t *testing.T) {
+		// Setup
+		dispatcher := validator.NewEventDispatcher[*mocks.MockSigner]()
+		feeCap, err := types.FeeCapFromStrings("0x3fff", "")
+		require.NoError(t, err)
+		dispatcher.FeeCap = feeCap
+		dispatcher.WaitForLowerFee = true
+		blockHashFelt := new(felt.Felt).SetUint64(1)
+		event := validator.AttestRequired{BlockHash: validator.BlockHash(*blockHashFelt)}
+
+		calls := []rpc.InvokeFunctionCall{{
+			ContractAddress: validationContracts.Attest.Felt(),
+			FunctionName:    "attest",
+			CallData:        []*felt.Felt{blockHashFelt},
+		}}
+		mockAttestationDone(t, mockAccount, &validator.Address{}, false)
+		mockAccount.EXPECT().ValidationContracts().Return(
+			validator.SepoliaValidationContracts(t),
+		).Times(1)
+		mockEstimateAttestFee(mockAccount, calls)
+
+		metricsServer := metrics.NewMockMetricsForTest(logger)
+
+		wg := &conc.WaitGroup{}
+		wg.Go(func() { dispatcher.Dispatch(mockAccount, logger, metricsServer) })
+
+		dispatcher.AttestRequired <- event
+
+		// Fees went down by the next block
+		lowerResourceBounds := attestResourceBounds
+		lowerResourceBounds.L2Gas.MaxPricePerUnit = "0x2f"
+		addTxHash := utils.HexToFelt(t, "0x123")
+		mockAttestationDone(t, mockAccount, &validator.Address{}, false)
+		mockAccount.EXPECT().ValidationContracts().Return(
+			validator.SepoliaValidationContracts(t),
+		).Times(1)
+		mockAccount.EXPECT().
+			EstimateInvokeTxnFee(
+				context.Background(), calls, constants.DEFAULT_FEE_ESTIMATION_MULTIPLIER,
+			).
+			Return(lowerResourceBounds, nil)
+		mockAccount.EXPECT().
+			BuildAndSendInvokeTxn(context.Background(), calls, &lowerResourceBounds).
+			Return(&rpc.AddInvokeTransactionResponse{TransactionHash: addTxHash}, nil)
+
+		dispatcher.AttestRequired <- event
+
+		close(dispatcher.AttestRequired)
+		wg.Wait()
+
+		// Assert
+		expectedAttest := validator.AttestTracker{
+			Event:           event,
+			TransactionHash: *addTxHash,
+			Status:          validator.Ongoing,
+			// 0x80 * 0x20 + 0x100 * 0x2f
+			Fee: new(felt.Felt).SetUint64(0x3f00),
 		}
 		require.Equal(t, expectedAttest, dispatcher.CurrentAttest)
 	})
@@ -549,3 +1088,57 @@ func TestTrackAttest(t *testing.T) {
 		require.Equal(t, validator.Successful, txStatus)
 	})
 }
+
+// Mocks asking the attestation contract whether the staker already attested in the current epoch
+func mockAttestationDone(
+	t *testing.T, mockAccount *mocks.MockSigner, stakerAddress *validator.Address, done bool,
+) {
+	t.Helper()
+
+	result := new(felt.Felt)
+	if done {
+		result.SetUint64(1)
+	}
+
+	mockAccount.EXPECT().ValidationContracts().Return(
+		validator.SepoliaValidationContracts(t),
+	).Times(1)
+	mockAccount.EXPECT().
+		Call(
+			context.Background(),
+			rpc.FunctionCall{
+				ContractAddress: utils.HexToFelt(t, constants.SEPOLIA_ATTEST_CONTRACT_ADDRESS),
+				EntryPointSelector: snGoUtils.GetSelectorFromNameFelt(
+					"is_attestation_done_in_curr_epoch",
+				),
+				Calldata: []*felt.Felt{stakerAddress.Felt()},
+			},
+			rpc.BlockID{Tag: "latest"},
+		).
+		Return([]*felt.Felt{result}, nil).
+		Times(1)
+}
+
+// Resource bounds returned when estimating the fee of an attest transaction
+var attestResourceBounds = rpc.ResourceBoundsMapping{
+	L1Gas:     rpc.ResourceBounds{MaxAmount: "0x0", MaxPricePerUnit: "0x10"},
+	L1DataGas: rpc.ResourceBounds{MaxAmount: "0x80", MaxPricePerUnit: "0x20"},
+	L2Gas:     rpc.ResourceBounds{MaxAmount: "0x100", MaxPricePerUnit: "0x30"},
+}
+
+var (
+	// 0x80 * 0x20 + 0x100 * 0x30
+	attestMaxFee = new(felt.Felt).SetUint64(0x4000)
+	// Once replaced, with 20% higher prices and a tip of 1 per l2 gas unit:
+	// 0x80 * 0x26 + 0x100 * (0x39 + 0x1)
+	replacementMaxFee = new(felt.Felt).SetUint64(0x4d00)
+)
+
+// Mocks estimating the fee of the attest transaction, which is done before each attest
+func mockEstimateAttestFee(mockAccount *mocks.MockSigner, calls any) *gomock.Call {
+	return mockAccount.EXPECT().
+		EstimateInvokeTxnFee(
+			context.Background(), calls, constants.DEFAULT_FEE_ESTIMATION_MULTIPLIER,
+		).
+		Return(attestResourceBounds, nil)
+}
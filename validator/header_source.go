@@ -0,0 +1,184 @@
+package validator
+
+import (
+	"context"
+	"time"
+
+	"github.com/NethermindEth/juno/utils"
+	"github.com/NethermindEth/starknet.go/client"
+	"github.com/NethermindEth/starknet.go/rpc"
+	"github.com/cockroachdb/errors"
+)
+
+// A source of new block headers. Headers are sent through the feed given at `Start`
+type BlockHeaderSource interface {
+	// Starts sending new block headers through the headers feed
+	Start(headersFeed chan *rpc.BlockHeader) error
+	// Receives an error when the source stops working and needs to be restarted
+	Err() <-chan error
+	// Stops sending block headers. After it returns nothing else is sent through the feed
+	Close()
+	// Short description used for logging
+	String() string
+}
+
+var (
+	_ BlockHeaderSource = (*WsHeaderSource)(nil)
+	_ BlockHeaderSource = (*PollingHeaderSource)(nil)
+)
+
+// Receives block headers through a websocket subscription
+type WsHeaderSource struct {
+	url                string
+	logger             *utils.ZapLogger
+	wsProvider         *rpc.WsProvider
+	clientSubscription *client.ClientSubscription
+}
+
+func NewWsHeaderSource(url string, logger *utils.ZapLogger) *WsHeaderSource {
+	return &WsHeaderSource{
+		url:    url,
+		logger: logger,
+	}
+}
+
+func (s *WsHeaderSource) Start(headersFeed chan *rpc.BlockHeader) error {
+	wsProvider, clientSubscription, err := SubscribeToBlockHeaders(s.url, headersFeed, s.logger)
+	if err != nil {
+		return err
+	}
+	s.wsProvider = wsProvider
+	s.clientSubscription = clientSubscription
+	return nil
+}
+
+func (s *WsHeaderSource) Err() <-chan error {
+	return s.clientSubscription.Err()
+}
+
+func (s *WsHeaderSource) Close() {
+	s.wsProvider.Close()
+}
+
+func (s *WsHeaderSource) String() string {
+	return "websocket subscription at " + s.url
+}
+
+// Subset of the RPC methods required to poll for new block headers
+type BlockHeaderProvider interface {
+	BlockNumber(ctx context.Context) (uint64, error)
+	BlockWithTxHashes(ctx context.Context, blockID rpc.BlockID) (interface{}, error)
+}
+
+// Periodically asks an http provider for the latest block and sends its header
+// whenever the chain head changes
+type PollingHeaderSource struct {
+	provider BlockHeaderProvider
+	interval time.Duration
+	logger   *utils.ZapLogger
+	errCh    chan error
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+func NewPollingHeaderSource(
+	provider BlockHeaderProvider, interval time.Duration, logger *utils.ZapLogger,
+) (*PollingHeaderSource, error) {
+	if err := CheckPollInterval(interval); err != nil {
+		return nil, err
+	}
+	return &PollingHeaderSource{
+		provider: provider,
+		interval: interval,
+		logger:   logger,
+		errCh:    make(chan error),
+	}, nil
+}
+
+// Returns an error if the block headers can't be polled every `interval`
+func CheckPollInterval(interval time.Duration) error {
+	if interval <= 0 {
+		return errors.Errorf("the provider poll interval must be positive, got %s", interval)
+	}
+	return nil
+}
+
+func (s *PollingHeaderSource) Start(headersFeed chan *rpc.BlockHeader) error {
+	s.stop = make(chan struct{})
+	s.done = make(chan struct{})
+	go s.poll(headersFeed)
+
+	s.logger.Infof("Polling new block headers every %s", s.interval)
+	return nil
+}
+
+// Polling errors are considered transient and are retried on the next tick,
+// so nothing is ever sent through this channel
+func (s *PollingHeaderSource) Err() <-chan error {
+	return s.errCh
+}
+
+func (s *PollingHeaderSource) Close() {
+	close(s.stop)
+	<-s.done
+}
+
+func (s *PollingHeaderSource) String() string {
+	return "http polling"
+}
+
+func (s *PollingHeaderSource) poll(headersFeed chan *rpc.BlockHeader) {
+	defer close(s.done)
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	var lastBlockHeader *rpc.BlockHeader
+	for {
+		blockHeader, err := s.fetchLatestBlockHeader(lastBlockHeader)
+		if err != nil {
+			s.logger.Warnw("Failed to poll latest block header", "error", err)
+		} else if blockHeader != nil {
+			select {
+			case headersFeed <- blockHeader:
+				lastBlockHeader = blockHeader
+			case <-s.stop:
+				return
+			}
+		}
+
+		select {
+		case <-ticker.C:
+		case <-s.stop:
+			return
+		}
+	}
+}
+
+// Returns the header of the latest block, or nil if it is the same as the last one sent
+func (s *PollingHeaderSource) fetchLatestBlockHeader(
+	lastBlockHeader *rpc.BlockHeader,
+) (*rpc.BlockHeader, error) {
+	blockNumber, err := s.provider.BlockNumber(context.Background())
+	if err != nil {
+		return nil, err
+	}
+
+	if lastBlockHeader != nil && blockNumber == lastBlockHeader.Number {
+		return nil, nil
+	}
+
+	res, err := s.provider.BlockWithTxHashes(
+		context.Background(), rpc.BlockID{Number: &blockNumber},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	block, ok := res.(*rpc.BlockTxHashes)
+	if !ok {
+		// The block is still pending, it will be retried on the next tick
+		return nil, nil
+	}
+	return &block.BlockHeader, nil
+}
@@ -0,0 +1,112 @@
+package validator_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/NethermindEth/juno/core/felt"
+	"github.com/NethermindEth/juno/utils"
+	"github.com/NethermindEth/starknet-staking-v2/validator"
+	"github.com/NethermindEth/starknet.go/rpc"
+	"github.com/stretchr/testify/require"
+)
+
+// Returns the block numbers in `heads` one after the other, repeating the last one
+type mockBlockHeaderProvider struct {
+	mu              sync.Mutex
+	heads           []uint64
+	blockNumberErrs int
+	pendingBlocks   map[uint64]bool
+}
+
+func (p *mockBlockHeaderProvider) BlockNumber(ctx context.Context) (uint64, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.blockNumberErrs > 0 {
+		p.blockNumberErrs--
+		return 0, errors.New("some internal error")
+	}
+
+	head := p.heads[0]
+	if len(p.heads) > 1 {
+		p.heads = p.heads[1:]
+	}
+	return head, nil
+}
+
+func (p *mockBlockHeaderProvider) BlockWithTxHashes(
+	ctx context.Context, blockID rpc.BlockID,
+) (interface{}, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.pendingBlocks[*blockID.Number] {
+		delete(p.pendingBlocks, *blockID.Number)
+		return &rpc.PendingBlockTxHashes{}, nil
+	}
+
+	return &rpc.BlockTxHashes{
+		BlockHeader: rpc.BlockHeader{
+			Number: *blockID.Number,
+			Hash:   new(felt.Felt).SetUint64(*blockID.Number),
+		},
+	}, nil
+}
+
+func TestPollingHeaderSource(t *testing.T) {
+	logger := utils.NewNopZapLogger()
+
+	t.Run("Only sends a header when the chain head changes", func(t *testing.T) {
+		provider := &mockBlockHeaderProvider{
+			heads:           []uint64{10, 10, 11, 11, 11, 13, 13, 14},
+			blockNumberErrs: 2,
+			pendingBlocks:   map[uint64]bool{13: true},
+		}
+		headersFeed := make(chan *rpc.BlockHeader)
+
+		source, err := validator.NewPollingHeaderSource(provider, time.Millisecond, logger)
+		require.NoError(t, err)
+		require.NoError(t, source.Start(headersFeed))
+
+		receivedBlockNumbers := make([]uint64, 0, 4)
+		for range 4 {
+			blockHeader := <-headersFeed
+			receivedBlockNumbers = append(receivedBlockNumbers, blockHeader.Number)
+		}
+		source.Close()
+
+		// Block 13 was pending the first time it was requested
+		require.Equal(t, []uint64{10, 11, 13, 14}, receivedBlockNumbers)
+	})
+
+	t.Run("Closing stops the source even if nobody reads the feed", func(t *testing.T) {
+		provider := &mockBlockHeaderProvider{heads: []uint64{1, 2, 3}}
+		headersFeed := make(chan *rpc.BlockHeader)
+
+		source, err := validator.NewPollingHeaderSource(provider, time.Millisecond, logger)
+		require.NoError(t, err)
+		require.NoError(t, source.Start(headersFeed))
+
+		closed := make(chan struct{})
+		go func() {
+			source.Close()
+			close(closed)
+		}()
+
+		select {
+		case <-closed:
+		case <-time.After(time.Second):
+			require.FailNow(t, "polling header source did not stop")
+		}
+	})
+	t.Run("Poll interval must be positive", func(t *testing.T) {
+		for _, interval := range []time.Duration{0, -time.Second} {
+			_, err := validator.NewPollingHeaderSource(&mockBlockHeaderProvider{}, interval, logger)
+			require.ErrorContains(t, err, "the provider poll interval must be positive")
+		}
+	})
+}
@@ -0,0 +1,150 @@
+package validator
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	"github.com/NethermindEth/juno/core/felt"
+	"github.com/NethermindEth/starknet-staking-v2/validator/types"
+	"github.com/cockroachdb/errors"
+)
+
+// State of the attestation being tracked, as stored on disk
+type JournalEntry struct {
+	EpochId         uint64    `json:"epochId"`
+	TargetBlock     uint64    `json:"targetBlock"`
+	StakerAddress   felt.Felt `json:"stakerAddress"`
+	BlockHash       felt.Felt `json:"blockHash"`
+	TransactionHash felt.Felt `json:"transactionHash"`
+	// Transaction replaced by the one above, zero if it wasn't replaced
+	ReplacedTransactionHash felt.Felt    `json:"replacedTransactionHash"`
+	Status                  AttestStatus `json:"status"`
+	// Max fee the attest transaction was sent with. Empty when unknown
+	Fee *felt.Felt `json:"fee,omitempty"`
+	// Fees spent by the attest transactions of the account in the daily fee budget window,
+	// so the budget isn't reset by a restart
+	SpentFees []types.SpentFee `json:"spentFees,omitempty"`
+}
+
+func JournalEntryFromTracker(tracker *AttestTracker) JournalEntry {
+	return JournalEntry{
+		EpochId:                 tracker.Event.EpochId,
+		TargetBlock:             tracker.Event.TargetBlock.Uint64(),
+		StakerAddress:           *tracker.Event.StakerAddress.Felt(),
+		BlockHash:               *tracker.Event.BlockHash.Felt(),
+		TransactionHash:         tracker.TransactionHash,
+		ReplacedTransactionHash: tracker.ReplacedTransactionHash,
+		Status:                  tracker.Status,
+		Fee:                     tracker.Fee,
+	}
+}
+
+func (e *JournalEntry) Tracker() AttestTracker {
+	return AttestTracker{
+		Event: AttestRequired{
+			BlockHash:     BlockHash(e.BlockHash),
+			EpochId:       e.EpochId,
+			TargetBlock:   BlockNumber(e.TargetBlock),
+			StakerAddress: Address(e.StakerAddress),
+		},
+		TransactionHash:         e.TransactionHash,
+		ReplacedTransactionHash: e.ReplacedTransactionHash,
+		Status:                  e.Status,
+		Fee:                     e.Fee,
+	}
+}
+
+// Keeps the state of the current attestation in a file so that it survives restarts.
+// Every write replaces the file atomically, a crash leaves either the old or the new state
+type AttestJournal struct {
+	path string
+}
+
+// Returns the directory the journals are kept in when none is set: inside the user state
+// directory, `$XDG_STATE_HOME` or `~/.local/state`
+func DefaultJournalDir() (string, error) {
+	stateDir := os.Getenv("XDG_STATE_HOME")
+	if stateDir == "" {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return "", errors.Errorf("cannot find the default journal directory: %s", err)
+		}
+		stateDir = filepath.Join(homeDir, ".local", "state")
+	}
+	return filepath.Join(stateDir, "starknet-staking-v2", "journal"), nil
+}
+
+// Creates a journal for the operational address inside the given directory
+func NewAttestJournal(dir string, operationalAddress *Address) (*AttestJournal, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, errors.Errorf("cannot create journal directory %s: %s", dir, err)
+	}
+
+	fileName := "attest-journal-" + operationalAddress.String() + ".json"
+	return &AttestJournal{path: filepath.Join(dir, fileName)}, nil
+}
+
+func (j *AttestJournal) Path() string {
+	return j.path
+}
+
+// Returns the last entry saved, or nil if nothing has been saved yet
+func (j *AttestJournal) Load() (*JournalEntry, error) {
+	data, err := os.ReadFile(j.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Errorf("cannot read attest journal %s: %s", j.path, err)
+	}
+
+	var entry JournalEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, errors.Errorf("cannot parse attest journal %s: %s", j.path, err)
+	}
+	return &entry, nil
+}
+
+// Replaces the saved entry. The data is written to a temporary file which
+// is synced to disk and then renamed over the journal
+func (j *AttestJournal) Save(entry *JournalEntry) error {
+	data, err := json.MarshalIndent(entry, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(j.path)
+	tmpFile, err := os.CreateTemp(dir, filepath.Base(j.path)+".tmp-*")
+	if err != nil {
+		return errors.Errorf("cannot create temporary journal file: %s", err)
+	}
+	tmpPath := tmpFile.Name()
+	// Only does something if the temporary file wasn't renamed
+	defer func() { _ = os.Remove(tmpPath) }()
+
+	if _, err := tmpFile.Write(data); err != nil {
+		_ = tmpFile.Close()
+		return errors.Errorf("cannot write temporary journal file: %s", err)
+	}
+	if err := tmpFile.Sync(); err != nil {
+		_ = tmpFile.Close()
+		return errors.Errorf("cannot sync temporary journal file: %s", err)
+	}
+	if err := tmpFile.Close(); err != nil {
+		return errors.Errorf("cannot close temporary journal file: %s", err)
+	}
+
+	if err := os.Rename(tmpPath, j.path); err != nil {
+		return errors.Errorf("cannot replace attest journal %s: %s", j.path, err)
+	}
+
+	// Make sure the rename itself is persisted
+	dirFile, err := os.Open(dir)
+	if err != nil {
+		return errors.Errorf("cannot open journal directory %s: %s", dir, err)
+	}
+	err = dirFile.Sync()
+	_ = dirFile.Close()
+	return err
+}
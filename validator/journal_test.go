@@ -0,0 +1,101 @@
+package validator_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/NethermindEth/juno/core/felt"
+	"github.com/NethermindEth/juno/utils"
+	"github.com/NethermindEth/starknet-staking-v2/validator"
+	"github.com/NethermindEth/starknet-staking-v2/validator/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAttestJournal(t *testing.T) {
+	operationalAddress := types.AddressFromString("0x123")
+
+	t.Run("Nothing is loaded when nothing was saved", func(t *testing.T) {
+		journal, err := validator.NewAttestJournal(t.TempDir(), &operationalAddress)
+		require.NoError(t, err)
+
+		entry, err := journal.Load()
+		require.NoError(t, err)
+		require.Nil(t, entry)
+	})
+
+	t.Run("Saved entries are loaded back", func(t *testing.T) {
+		dir := filepath.Join(t.TempDir(), "journal")
+		journal, err := validator.NewAttestJournal(dir, &operationalAddress)
+		require.NoError(t, err)
+
+		entry := validator.JournalEntry{
+			EpochId:         1516,
+			TargetBlock:     639276,
+			BlockHash:       *utils.HexToFelt(t, "0x6d8dc0a8"),
+			TransactionHash: *utils.HexToFelt(t, "0x456"),
+			Status:          validator.Ongoing,
+			Fee:             new(felt.Felt).SetUint64(1000),
+			SpentFees: []types.SpentFee{
+				{At: time.Unix(1_750_000_000, 0).UTC(), Fee: new(felt.Felt).SetUint64(1000)},
+			},
+		}
+		require.NoError(t, journal.Save(&entry))
+
+		entry.Status = validator.Successful
+		require.NoError(t, journal.Save(&entry))
+
+		// A new journal on the same directory, as after a restart
+		journal, err = validator.NewAttestJournal(dir, &operationalAddress)
+		require.NoError(t, err)
+		loadedEntry, err := journal.Load()
+		require.NoError(t, err)
+		require.Equal(t, &entry, loadedEntry)
+
+		// No temporary files are left behind
+		files, err := os.ReadDir(dir)
+		require.NoError(t, err)
+		require.Len(t, files, 1)
+		require.Equal(t, filepath.Base(journal.Path()), files[0].Name())
+	})
+
+	t.Run("Error when the journal is corrupted", func(t *testing.T) {
+		journal, err := validator.NewAttestJournal(t.TempDir(), &operationalAddress)
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(journal.Path(), []byte(`{"epochId": 1,`), 0o600))
+
+		entry, err := journal.Load()
+		require.Nil(t, entry)
+		require.ErrorContains(t, err, "cannot parse attest journal")
+	})
+
+	t.Run("Error when the status is unknown", func(t *testing.T) {
+		journal, err := validator.NewAttestJournal(t.TempDir(), &operationalAddress)
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(journal.Path(), []byte(`{"status": "pending"}`), 0o600))
+
+		entry, err := journal.Load()
+		require.Nil(t, entry)
+		require.ErrorContains(t, err, `invalid attest status: "pending"`)
+	})
+}
+
+func TestDefaultJournalDir(t *testing.T) {
+	t.Run("Inside the XDG state directory", func(t *testing.T) {
+		t.Setenv("XDG_STATE_HOME", "/var/lib/state")
+
+		dir, err := validator.DefaultJournalDir()
+		require.NoError(t, err)
+		require.Equal(t, "/var/lib/state/starknet-staking-v2/journal", dir)
+	})
+
+	t.Run("Inside the home directory", func(t *testing.T) {
+		t.Setenv("XDG_STATE_HOME", "")
+		t.Setenv("HOME", "/home/staker")
+
+		dir, err := validator.DefaultJournalDir()
+		require.NoError(t, err)
+		require.Equal(t, "/home/staker/.local/state/starknet-staking-v2/journal", dir)
+	})
+}
@@ -2,9 +2,11 @@ package metrics
 
 import (
 	"context"
+	"math/big"
 	"net/http"
 	"time"
 
+	"github.com/NethermindEth/juno/core/felt"
 	"github.com/NethermindEth/juno/utils"
 	"github.com/NethermindEth/starknet-staking-v2/validator/types"
 	"github.com/prometheus/client_golang/prometheus"
@@ -25,10 +27,55 @@ type Metrics struct {
 	attestationSubmittedCount       *prometheus.CounterVec
 	attestationFailureCount         *prometheus.CounterVec
 	attestationConfirmedCount       *prometheus.CounterVec
+	chainReorgCount                 *prometheus.CounterVec
+	attestationReplacedCount        *prometheus.CounterVec
+	feeCapExceededCount             *prometheus.CounterVec
+	feesSpent                       *prometheus.GaugeVec
+	activeProvider                  *prometheus.GaugeVec
+	providerHealthy                 *prometheus.GaugeVec
+	providerLatency                 *prometheus.GaugeVec
+	externalSignerLatency           *prometheus.HistogramVec
+	externalSignerErrorCount        *prometheus.CounterVec
+	externalSignerRetryCount        *prometheus.CounterVec
+	externalSignerSignedCount       *prometheus.CounterVec
+	externalSignerAvailable         *prometheus.GaugeVec
+	// Operational address used as label of the account metrics
+	address string
 }
 
 // NewMetrics creates a new metrics server
 func NewMetrics(logger *utils.ZapLogger, address string) *Metrics {
+	m := newMetrics(logger)
+
+	// Create HTTP server
+	mux := http.NewServeMux()
+	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		_, err := w.Write([]byte("OK"))
+		if err != nil {
+			m.logger.Errorf("Failed to write health check response: %v", err)
+		}
+	})
+	mux.Handle("/metrics", promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{}))
+
+	m.server = &http.Server{
+		Addr:    address,
+		Handler: mux,
+	}
+
+	return m
+}
+
+// NewMockMetricsForTest creates a new metrics server for testing purposes
+// It doesn't start an HTTP server but provides all the necessary methods for testing
+func NewMockMetricsForTest(logger *utils.ZapLogger) *Metrics {
+	// For testing, we don't create an HTTP server
+	// This allows tests to run without binding to ports
+	return newMetrics(logger)
+}
+
+// newMetrics creates and registers all the validator metrics
+func newMetrics(logger *utils.ZapLogger) *Metrics {
 	registry := prometheus.NewRegistry()
 
 	m := &Metrics{
@@ -39,168 +86,148 @@ func NewMetrics(logger *utils.ZapLogger, address string) *Metrics {
 				Name: "validator_attestation_starknet_latest_block_number",
 				Help: "The latest block number seen by the validator on the Starknet network",
 			},
-			[]string{"network"},
+			[]string{"network", "address"},
 		),
 		currentEpochID: prometheus.NewGaugeVec(
 			prometheus.GaugeOpts{
 				Name: "validator_attestation_current_epoch_id",
 				Help: "The ID of the current epoch the validator is participating in",
 			},
-			[]string{"network"},
+			[]string{"network", "address"},
 		),
 		currentEpochLength: prometheus.NewGaugeVec(
 			prometheus.GaugeOpts{
 				Name: "validator_attestation_current_epoch_length",
 				Help: "The total length (in blocks) of the current epoch",
 			},
-			[]string{"network"},
+			[]string{"network", "address"},
 		),
 		currentEpochStartingBlockNumber: prometheus.NewGaugeVec(
 			prometheus.GaugeOpts{
 				Name: "validator_attestation_current_epoch_starting_block_number",
 				Help: "The first block number of the current epoch",
 			},
-			[]string{"network"},
+			[]string{"network", "address"},
 		),
 		currentEpochAssignedBlockNumber: prometheus.NewGaugeVec(
 			prometheus.GaugeOpts{
 				Name: "validator_attestation_current_epoch_assigned_block_number",
 				Help: "The specific block number within the current epoch for which the validator is assigned to attest",
 			},
-			[]string{"network"},
+			[]string{"network", "address"},
 		),
 		lastAttestationTimestamp: prometheus.NewGaugeVec(
 			prometheus.GaugeOpts{
 				Name: "validator_attestation_last_attestation_timestamp_seconds",
 				Help: "The Unix timestamp (in seconds) of the last successful attestation submission",
 			},
-			[]string{"network"},
+			[]string{"network", "address"},
 		),
 		attestationSubmittedCount: prometheus.NewCounterVec(
 			prometheus.CounterOpts{
 				Name: "validator_attestation_attestation_submitted_count",
 				Help: "The total number of attestations submitted by the validator since startup",
 			},
-			[]string{"network"},
+			[]string{"network", "address"},
 		),
 		attestationFailureCount: prometheus.NewCounterVec(
 			prometheus.CounterOpts{
 				Name: "validator_attestation_attestation_failure_count",
 				Help: "The total number of attestation transaction submission failures encountered by the validator since startup",
 			},
-			[]string{"network"},
+			[]string{"network", "address"},
 		),
 		attestationConfirmedCount: prometheus.NewCounterVec(
 			prometheus.CounterOpts{
 				Name: "validator_attestation_attestation_confirmed_count",
 				Help: "The total number of attestations that have been confirmed on the network since validator startup",
 			},
-			[]string{"network"},
+			[]string{"network", "address"},
 		),
-	}
-
-	// Register metrics with Prometheus registry
-	registry.MustRegister(
-		m.latestBlockNumber,
-		m.currentEpochID,
-		m.currentEpochLength,
-		m.currentEpochStartingBlockNumber,
-		m.currentEpochAssignedBlockNumber,
-		m.lastAttestationTimestamp,
-		m.attestationSubmittedCount,
-		m.attestationFailureCount,
-		m.attestationConfirmedCount,
-	)
-
-	// Create HTTP server
-	mux := http.NewServeMux()
-	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
-		w.WriteHeader(http.StatusOK)
-		_, err := w.Write([]byte("OK"))
-		if err != nil {
-			m.logger.Errorf("Failed to write health check response: %v", err)
-		}
-	})
-	mux.Handle("/metrics", promhttp.HandlerFor(registry, promhttp.HandlerOpts{}))
-
-	m.server = &http.Server{
-		Addr:    address,
-		Handler: mux,
-	}
-
-	return m
-}
-
-// NewMockMetricsForTest creates a new metrics server for testing purposes
-// It doesn't start an HTTP server but provides all the necessary methods for testing
-func NewMockMetricsForTest(logger *utils.ZapLogger) *Metrics {
-	registry := prometheus.NewRegistry()
-
-	m := &Metrics{
-		logger:   logger,
-		registry: registry,
-		latestBlockNumber: prometheus.NewGaugeVec(
-			prometheus.GaugeOpts{
-				Name: "validator_attestation_starknet_latest_block_number",
-				Help: "The latest block number seen by the validator on the Starknet network",
+		chainReorgCount: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "validator_attestation_chain_reorg_count",
+				Help: "The total number of chain reorganisations detected by the validator since startup",
 			},
-			[]string{"network"},
+			[]string{"network", "address"},
 		),
-		currentEpochID: prometheus.NewGaugeVec(
-			prometheus.GaugeOpts{
-				Name: "validator_attestation_current_epoch_id",
-				Help: "The ID of the current epoch the validator is participating in",
+		attestationReplacedCount: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "validator_attestation_attestation_replaced_count",
+				Help: "The total number of stuck attestation transactions replaced with a higher tip since validator startup",
 			},
-			[]string{"network"},
+			[]string{"network", "address"},
 		),
-		currentEpochLength: prometheus.NewGaugeVec(
+		feeCapExceededCount: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "validator_attestation_fee_cap_exceeded_count",
+				Help: "The total number of attestation transactions not sent because their fee was above the cap since validator startup",
+			},
+			[]string{"network", "address"},
+		),
+		feesSpent: prometheus.NewGaugeVec(
 			prometheus.GaugeOpts{
-				Name: "validator_attestation_current_epoch_length",
-				Help: "The total length (in blocks) of the current epoch",
+				Name: "validator_attestation_fees_spent_fri",
+				Help: "The maximum fee (in fri) the attestation transactions sent in the last 24 hours can pay",
 			},
-			[]string{"network"},
+			[]string{"network", "address"},
 		),
-		currentEpochStartingBlockNumber: prometheus.NewGaugeVec(
+		activeProvider: prometheus.NewGaugeVec(
 			prometheus.GaugeOpts{
-				Name: "validator_attestation_current_epoch_starting_block_number",
-				Help: "The first block number of the current epoch",
+				Name: "validator_attestation_active_provider",
+				Help: "Whether the RPC provider is the one requests are currently routed to (1) or not (0)",
 			},
-			[]string{"network"},
+			[]string{"network", "provider"},
 		),
-		currentEpochAssignedBlockNumber: prometheus.NewGaugeVec(
+		providerHealthy: prometheus.NewGaugeVec(
 			prometheus.GaugeOpts{
-				Name: "validator_attestation_current_epoch_assigned_block_number",
-				Help: "The specific block number within the current epoch for which the validator is assigned to attest",
+				Name: "validator_attestation_provider_healthy",
+				Help: "Whether the RPC provider passed its last health check (1) or not (0)",
 			},
-			[]string{"network"},
+			[]string{"network", "provider"},
 		),
-		lastAttestationTimestamp: prometheus.NewGaugeVec(
+		providerLatency: prometheus.NewGaugeVec(
 			prometheus.GaugeOpts{
-				Name: "validator_attestation_last_attestation_timestamp_seconds",
-				Help: "The Unix timestamp (in seconds) of the last successful attestation submission",
+				Name: "validator_attestation_provider_latency_seconds",
+				Help: "The latency (in seconds) of the RPC provider measured in its last health check",
 			},
-			[]string{"network"},
+			[]string{"network", "provider"},
 		),
-		attestationSubmittedCount: prometheus.NewCounterVec(
+		externalSignerLatency: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name:    "validator_attestation_external_signer_latency_seconds",
+				Help:    "The duration (in seconds) of the requests to the external signer, including retries",
+				Buckets: []float64{0.01, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30},
+			},
+			[]string{"network", "address"},
+		),
+		externalSignerErrorCount: prometheus.NewCounterVec(
 			prometheus.CounterOpts{
-				Name: "validator_attestation_attestation_submitted_count",
-				Help: "The total number of attestations submitted by the validator since startup",
+				Name: "validator_attestation_external_signer_error_count",
+				Help: "The total number of failed requests to the external signer since validator startup, by reason",
 			},
-			[]string{"network"},
+			[]string{"network", "address", "reason"},
 		),
-		attestationFailureCount: prometheus.NewCounterVec(
+		externalSignerRetryCount: prometheus.NewCounterVec(
 			prometheus.CounterOpts{
-				Name: "validator_attestation_attestation_failure_count",
-				Help: "The total number of attestation transaction submission failures encountered by the validator since startup",
+				Name: "validator_attestation_external_signer_retry_count",
+				Help: "The total number of retried requests to the external signer since validator startup",
 			},
-			[]string{"network"},
+			[]string{"network", "address"},
 		),
-		attestationConfirmedCount: prometheus.NewCounterVec(
+		externalSignerSignedCount: prometheus.NewCounterVec(
 			prometheus.CounterOpts{
-				Name: "validator_attestation_attestation_confirmed_count",
-				Help: "The total number of attestations that have been confirmed on the network since validator startup",
+				Name: "validator_attestation_external_signer_signed_count",
+				Help: "The total number of transactions signed by each external signer since validator startup",
 			},
-			[]string{"network"},
+			[]string{"network", "address", "signer"},
+		),
+		externalSignerAvailable: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "validator_attestation_external_signer_available",
+				Help: "Whether the external signer is tried in its configured order (1) or skipped after failing repeatedly (0)",
+			},
+			[]string{"network", "address", "signer"},
 		),
 	}
 
@@ -215,14 +242,31 @@ func NewMockMetricsForTest(logger *utils.ZapLogger) *Metrics {
 		m.attestationSubmittedCount,
 		m.attestationFailureCount,
 		m.attestationConfirmedCount,
+		m.chainReorgCount,
+		m.attestationReplacedCount,
+		m.feeCapExceededCount,
+		m.feesSpent,
+		m.activeProvider,
+		m.providerHealthy,
+		m.providerLatency,
+		m.externalSignerLatency,
+		m.externalSignerErrorCount,
+		m.externalSignerRetryCount,
+		m.externalSignerSignedCount,
+		m.externalSignerAvailable,
 	)
 
-	// For testing, we don't create an HTTP server
-	// This allows tests to run without binding to ports
-
 	return m
 }
 
+// ForAccount returns a view of the metrics which labels every account metric
+// with the given operational address. The metrics themselves are shared
+func (m *Metrics) ForAccount(address string) *Metrics {
+	accountMetrics := *m
+	accountMetrics.address = address
+	return &accountMetrics
+}
+
 // Start starts the metrics server
 func (m *Metrics) Start() error {
 	m.logger.Infof("Starting metrics server on %s", m.server.Addr)
@@ -237,29 +281,95 @@ func (m *Metrics) Stop(ctx context.Context) error {
 
 // UpdateLatestBlockNumber updates the latest block number metric
 func (m *Metrics) UpdateLatestBlockNumber(network string, blockNumber uint64) {
-	m.latestBlockNumber.WithLabelValues(network).Set(float64(blockNumber))
+	m.latestBlockNumber.WithLabelValues(network, m.address).Set(float64(blockNumber))
 }
 
 // UpdateEpochInfo updates the epoch-related metrics
 func (m *Metrics) UpdateEpochInfo(network string, epochInfo *types.EpochInfo, targetBlock uint64) {
-	m.currentEpochID.WithLabelValues(network).Set(float64(epochInfo.EpochId))
-	m.currentEpochLength.WithLabelValues(network).Set(float64(epochInfo.EpochLen))
-	m.currentEpochStartingBlockNumber.WithLabelValues(network).Set(float64(epochInfo.CurrentEpochStartingBlock.Uint64()))
-	m.currentEpochAssignedBlockNumber.WithLabelValues(network).Set(float64(targetBlock))
+	m.currentEpochID.WithLabelValues(network, m.address).Set(float64(epochInfo.EpochId))
+	m.currentEpochLength.WithLabelValues(network, m.address).Set(float64(epochInfo.EpochLen))
+	m.currentEpochStartingBlockNumber.WithLabelValues(network, m.address).Set(float64(epochInfo.CurrentEpochStartingBlock.Uint64()))
+	m.currentEpochAssignedBlockNumber.WithLabelValues(network, m.address).Set(float64(targetBlock))
 }
 
 // RecordAttestationSubmitted increments the attestation submitted counter
 func (m *Metrics) RecordAttestationSubmitted(network string) {
-	m.attestationSubmittedCount.WithLabelValues(network).Inc()
-	m.lastAttestationTimestamp.WithLabelValues(network).Set(float64(time.Now().Unix()))
+	m.attestationSubmittedCount.WithLabelValues(network, m.address).Inc()
+	m.lastAttestationTimestamp.WithLabelValues(network, m.address).Set(float64(time.Now().Unix()))
 }
 
 // RecordAttestationFailure increments the attestation failure counter
 func (m *Metrics) RecordAttestationFailure(network string) {
-	m.attestationFailureCount.WithLabelValues(network).Inc()
+	m.attestationFailureCount.WithLabelValues(network, m.address).Inc()
 }
 
 // RecordAttestationConfirmed increments the attestation confirmed counter
 func (m *Metrics) RecordAttestationConfirmed(network string) {
-	m.attestationConfirmedCount.WithLabelValues(network).Inc()
+	m.attestationConfirmedCount.WithLabelValues(network, m.address).Inc()
+}
+
+// RecordChainReorg increments the chain reorg counter
+func (m *Metrics) RecordChainReorg(network string) {
+	m.chainReorgCount.WithLabelValues(network, m.address).Inc()
+}
+
+// RecordAttestationReplaced increments the replaced attestation counter
+func (m *Metrics) RecordAttestationReplaced(network string) {
+	m.attestationReplacedCount.WithLabelValues(network, m.address).Inc()
+}
+
+// RecordFeeCapExceeded increments the fee cap exceeded counter
+func (m *Metrics) RecordFeeCapExceeded(network string) {
+	m.feeCapExceededCount.WithLabelValues(network, m.address).Inc()
+}
+
+// UpdateFeesSpent updates the fees spent in the last 24 hours
+func (m *Metrics) UpdateFeesSpent(network string, spent *felt.Felt) {
+	spentFri, _ := new(big.Float).SetInt(spent.BigInt(new(big.Int))).Float64()
+	m.feesSpent.WithLabelValues(network, m.address).Set(spentFri)
+}
+
+// SetActiveProvider updates whether the RPC provider is the active one
+func (m *Metrics) SetActiveProvider(network string, provider string, active bool) {
+	m.activeProvider.WithLabelValues(network, provider).Set(boolToFloat(active))
+}
+
+// UpdateProviderHealth updates the health metrics of an RPC provider
+func (m *Metrics) UpdateProviderHealth(
+	network string, provider string, healthy bool, latency time.Duration,
+) {
+	m.providerHealthy.WithLabelValues(network, provider).Set(boolToFloat(healthy))
+	m.providerLatency.WithLabelValues(network, provider).Set(latency.Seconds())
+}
+
+// ObserveExternalSignerLatency records how long a request to the external signer took
+func (m *Metrics) ObserveExternalSignerLatency(network string, latency time.Duration) {
+	m.externalSignerLatency.WithLabelValues(network, m.address).Observe(latency.Seconds())
+}
+
+// RecordExternalSignerError increments the external signer error counter
+func (m *Metrics) RecordExternalSignerError(network string, reason string) {
+	m.externalSignerErrorCount.WithLabelValues(network, m.address, reason).Inc()
+}
+
+// RecordExternalSignerRetry increments the external signer retry counter
+func (m *Metrics) RecordExternalSignerRetry(network string) {
+	m.externalSignerRetryCount.WithLabelValues(network, m.address).Inc()
+}
+
+// RecordExternalSignerSigned increments the counter of transactions signed by an external signer
+func (m *Metrics) RecordExternalSignerSigned(network string, signer string) {
+	m.externalSignerSignedCount.WithLabelValues(network, m.address, signer).Inc()
+}
+
+// SetExternalSignerAvailable updates whether an external signer is skipped for failing
+func (m *Metrics) SetExternalSignerAvailable(network string, signer string, available bool) {
+	m.externalSignerAvailable.WithLabelValues(network, m.address, signer).Set(boolToFloat(available))
+}
+
+func boolToFloat(value bool) float64 {
+	if value {
+		return 1
+	}
+	return 0
 }
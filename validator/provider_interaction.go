@@ -28,10 +28,11 @@ func NewProvider[Logger utils.Logger](providerUrl string, logger Logger) (*rpc.P
 	return provider, nil
 }
 
-// Returns a Go channel where BlockHeaders are received
-func SubscribeToBlockHeaders[Logger utils.Logger](wsProviderUrl string, logger Logger) (
+// Subscribes to new block headers, which are sent through the given channel
+func SubscribeToBlockHeaders[Logger utils.Logger](
+	wsProviderUrl string, headersFeed chan *rpc.BlockHeader, logger Logger,
+) (
 	*rpc.WsProvider,
-	chan *rpc.BlockHeader,
 	*client.ClientSubscription,
 	error,
 ) {
@@ -39,18 +40,18 @@ func SubscribeToBlockHeaders[Logger utils.Logger](wsProviderUrl string, logger L
 	// This needs a timeout or something
 	wsProvider, err := rpc.NewWebsocketProvider(wsProviderUrl)
 	if err != nil {
-		return nil, nil, nil, errors.Errorf("dialling WS provider at %s: %s", wsProviderUrl, err)
+		return nil, nil, errors.Errorf("dialling WS provider at %s: %s", wsProviderUrl, err)
 	}
 
 	logger.Debugw("Subscribing to new block headers...")
-	headersFeed := make(chan *rpc.BlockHeader)
 	clientSubscription, err := wsProvider.SubscribeNewHeads(
 		context.Background(), headersFeed, rpc.BlockID{Tag: "latest"},
 	)
 	if err != nil {
-		return nil, nil, nil, errors.Errorf("subscribing to new block headers: %s", err)
+		wsProvider.Close()
+		return nil, nil, errors.Errorf("subscribing to new block headers: %s", err)
 	}
 
 	logger.Infof("Subscribed to new block header. Subscription ID: %s", clientSubscription.ID())
-	return wsProvider, headersFeed, clientSubscription, nil
+	return wsProvider, clientSubscription, nil
 }
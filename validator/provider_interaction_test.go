@@ -6,6 +6,7 @@ import (
 
 	"github.com/NethermindEth/juno/utils"
 	"github.com/NethermindEth/starknet-staking-v2/validator"
+	"github.com/NethermindEth/starknet.go/rpc"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/mock/gomock"
 )
@@ -64,12 +65,11 @@ func TestBlockHeaderSubscription(t *testing.T) {
 
 	t.Run("Error creating provider", func(t *testing.T) {
 		wsProviderURL := "wrong url"
-		wsProvider, headerFeed, clientSubscription, err := validator.SubscribeToBlockHeaders(
-			wsProviderURL, logger,
+		wsProvider, clientSubscription, err := validator.SubscribeToBlockHeaders(
+			wsProviderURL, make(chan *rpc.BlockHeader), logger,
 		)
 
 		require.Nil(t, wsProvider)
-		require.Nil(t, headerFeed)
 		require.Nil(t, clientSubscription)
 		expectedErrorMsg := fmt.Sprintf(`dialling WS provider at %s`, wsProviderURL)
 		require.ErrorContains(t, err, expectedErrorMsg)
@@ -80,12 +80,12 @@ func TestBlockHeaderSubscription(t *testing.T) {
 	envVars, err := validator.LoadEnv(t)
 	if loadedEnvVars := err == nil; loadedEnvVars {
 		t.Run("Successfully subscribing to new block headers", func(t *testing.T) {
-			wsProvider, headerChannel, clientSubscription, err := validator.SubscribeToBlockHeaders(
-				envVars.WsProviderUrl, logger,
+			headerChannel := make(chan *rpc.BlockHeader)
+			wsProvider, clientSubscription, err := validator.SubscribeToBlockHeaders(
+				envVars.WsProviderUrl, headerChannel, logger,
 			)
 
 			require.NotNil(t, wsProvider)
-			require.NotNil(t, headerChannel)
 			require.NotNil(t, clientSubscription)
 			require.Nil(t, err)
 
@@ -0,0 +1,464 @@
+package validator
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/NethermindEth/juno/core/felt"
+	"github.com/NethermindEth/juno/utils"
+	"github.com/NethermindEth/starknet-staking-v2/validator/config"
+	"github.com/NethermindEth/starknet-staking-v2/validator/metrics"
+	"github.com/NethermindEth/starknet.go/contracts"
+	"github.com/NethermindEth/starknet.go/rpc"
+	"github.com/cockroachdb/errors"
+)
+
+const (
+	// How often the health of every provider is checked
+	providerHealthCheckInterval = 15 * time.Second
+	// Time limit for each of the health check requests
+	providerHealthCheckTimeout = 5 * time.Second
+	// For how long a provider reported as failing is not selected again
+	providerFailureCooldown = time.Minute
+	// How many blocks a provider head can be behind the best known head
+	// before being considered stale
+	maxProviderHeadLag = 3
+	// How much faster a healthy provider must answer to be preferred over
+	// one configured before it
+	providerLatencyMargin = 250 * time.Millisecond
+)
+
+// Result of the last health check of a provider
+type ProviderHealth struct {
+	Reachable bool
+	ChainID   string
+	Latency   time.Duration
+	HeadBlock uint64
+	FailedAt  time.Time
+}
+
+type providerEndpoint struct {
+	config   config.Provider
+	name     string
+	provider *rpc.Provider
+	health   ProviderHealth
+	healthy  bool
+}
+
+var _ rpc.RpcProvider = (*ProviderPool)(nil)
+
+// An ordered set of RPC providers. All requests are routed to the active provider,
+// which is the first healthy one in the configured order unless a later healthy one
+// answers faster by more than `providerLatencyMargin`.
+// Requests failing because of the provider report it as failing
+type ProviderPool struct {
+	mu        sync.RWMutex
+	endpoints []providerEndpoint
+	active    int
+	chainID   string
+
+	logger        *utils.ZapLogger
+	metricsServer *metrics.Metrics
+}
+
+func NewProviderPool(
+	providers []config.Provider, logger *utils.ZapLogger, metricsServer *metrics.Metrics,
+) (*ProviderPool, error) {
+	if len(providers) == 0 {
+		return nil, errors.New("no RPC provider configured")
+	}
+
+	endpoints := make([]providerEndpoint, len(providers))
+	for i := range providers {
+		provider, err := rpc.NewProvider(providers[i].Http)
+		if err != nil {
+			return nil, errors.Errorf(
+				"cannot create RPC provider at %s: %s", providers[i].Http, err,
+			)
+		}
+		endpoints[i] = providerEndpoint{
+			config:   providers[i],
+			name:     providerName(providers[i].Http, i),
+			provider: provider,
+		}
+	}
+
+	pool := &ProviderPool{
+		endpoints:     endpoints,
+		logger:        logger,
+		metricsServer: metricsServer,
+	}
+	pool.CheckHealth()
+
+	if pool.chainID == "" {
+		return nil, errors.New("cannot connect to any of the configured RPC providers")
+	}
+
+	ChainID = pool.chainID
+	return pool, nil
+}
+
+// Periodically checks the health of the providers until the context is cancelled
+func (p *ProviderPool) Run(ctx context.Context) {
+	ticker := time.NewTicker(providerHealthCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			p.CheckHealth()
+		}
+	}
+}
+
+// Checks the latency, chain id and head of every provider and selects the active one
+func (p *ProviderPool) CheckHealth() {
+	healths := make([]ProviderHealth, len(p.endpoints))
+	for i := range p.endpoints {
+		healths[i] = checkProviderHealth(p.endpoints[i].provider)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	// The first provider reached determines the chain we are working with
+	if p.chainID == "" {
+		for i := range healths {
+			if healths[i].Reachable {
+				p.chainID = healths[i].ChainID
+				break
+			}
+		}
+	}
+
+	var bestHead uint64
+	for i := range healths {
+		if healths[i].Reachable && healths[i].ChainID == p.chainID {
+			bestHead = max(bestHead, healths[i].HeadBlock)
+		}
+	}
+
+	for i := range p.endpoints {
+		endpoint := &p.endpoints[i]
+		healths[i].FailedAt = endpoint.health.FailedAt
+		endpoint.health = healths[i]
+		endpoint.healthy = p.isHealthy(&healths[i], bestHead)
+
+		if healths[i].Reachable && healths[i].ChainID != p.chainID {
+			p.logger.Warnw(
+				"RPC provider is on a different chain",
+				"provider", endpoint.name,
+				"expected chain id", p.chainID,
+				"chain id", healths[i].ChainID,
+			)
+		}
+		p.logger.Debugw(
+			"RPC provider health",
+			"provider", endpoint.name,
+			"healthy", endpoint.healthy,
+			"latency", healths[i].Latency,
+			"head block", healths[i].HeadBlock,
+		)
+		p.metricsServer.UpdateProviderHealth(
+			p.chainID, endpoint.name, endpoint.healthy, healths[i].Latency,
+		)
+	}
+
+	p.selectActive()
+}
+
+// Marks the provider as failing so it isn't selected for a while
+func (p *ProviderPool) ReportFailure(index int, reason string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	endpoint := &p.endpoints[index]
+	p.logger.Warnw("RPC provider reported as failing", "provider", endpoint.name, "reason", reason)
+
+	endpoint.health.FailedAt = time.Now()
+	endpoint.healthy = false
+	p.metricsServer.UpdateProviderHealth(
+		p.chainID, endpoint.name, endpoint.healthy, endpoint.health.Latency,
+	)
+
+	p.selectActive()
+}
+
+// Returns the provider requests are currently routed to
+func (p *ProviderPool) Active() *rpc.Provider {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	return p.endpoints[p.active].provider
+}
+
+// Returns the index and client of the provider requests are currently routed to
+func (p *ProviderPool) activeProvider() (int, *rpc.Provider) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	return p.active, p.endpoints[p.active].provider
+}
+
+// Reports the provider at `index` as failing when `err` comes from the provider itself,
+// e.g. it can't be reached, and not from the request, e.g. a contract error
+func (p *ProviderPool) reportRequestError(ctx context.Context, index int, method string, err error) {
+	if err == nil || ctx.Err() != nil {
+		return
+	}
+	// Errors the node answers with are kept, any other error, like a failed connection,
+	// is turned into an internal error by the rpc client
+	var rpcErr *rpc.RPCError
+	if errors.As(err, &rpcErr) && rpcErr.Code != rpc.InternalError {
+		return
+	}
+	p.ReportFailure(index, method+" request failed: "+err.Error())
+}
+
+// Returns the index and configuration of the provider requests are currently routed to
+func (p *ProviderPool) ActiveEndpoint() (int, config.Provider) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	return p.active, p.endpoints[p.active].config
+}
+
+// Returns the result of the last health check of every provider, in the configured order
+func (p *ProviderPool) Health() []ProviderHealth {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	healths := make([]ProviderHealth, len(p.endpoints))
+	for i := range p.endpoints {
+		healths[i] = p.endpoints[i].health
+	}
+	return healths
+}
+
+func (p *ProviderPool) isHealthy(health *ProviderHealth, bestHead uint64) bool {
+	return health.Reachable &&
+		health.ChainID == p.chainID &&
+		health.HeadBlock+maxProviderHeadLag >= bestHead &&
+		time.Since(health.FailedAt) >= providerFailureCooldown
+}
+
+// Must be called with the lock held
+func (p *ProviderPool) selectActive() {
+	newActive := -1
+	for i := range p.endpoints {
+		if !p.endpoints[i].healthy {
+			continue
+		}
+		if newActive == -1 ||
+			p.endpoints[i].health.Latency+providerLatencyMargin <
+				p.endpoints[newActive].health.Latency {
+			newActive = i
+		}
+	}
+
+	if newActive == -1 {
+		p.logger.Warnw(
+			"No healthy RPC provider available, keeping the current one",
+			"provider", p.endpoints[p.active].name,
+		)
+		newActive = p.active
+	} else if newActive != p.active {
+		p.logger.Infow(
+			"Switching active RPC provider",
+			"from", p.endpoints[p.active].name,
+			"to", p.endpoints[newActive].name,
+		)
+	}
+	p.active = newActive
+
+	for i := range p.endpoints {
+		p.metricsServer.SetActiveProvider(p.chainID, p.endpoints[i].name, i == p.active)
+	}
+}
+
+func checkProviderHealth(provider *rpc.Provider) ProviderHealth {
+	ctx, cancel := context.WithTimeout(context.Background(), providerHealthCheckTimeout)
+	defer cancel()
+
+	chainID, err := provider.ChainID(ctx)
+	if err != nil {
+		return ProviderHealth{}
+	}
+
+	// The chain id is cached by the provider after the first request, so only
+	// the block number request reaches the node every time
+	start := time.Now()
+	headBlock, err := provider.BlockNumber(ctx)
+	if err != nil {
+		return ProviderHealth{}
+	}
+	latency := time.Since(start)
+
+	return ProviderHealth{
+		Reachable: true,
+		ChainID:   chainID,
+		Latency:   latency,
+		HeadBlock: headBlock,
+	}
+}
+
+// Only the host is used to identify the provider in logs and metrics,
+// as the full url might contain an API key
+func providerName(providerUrl string, index int) string {
+	u, err := url.Parse(providerUrl)
+	if err != nil || u.Host == "" {
+		return "provider-" + strconv.Itoa(index)
+	}
+	return u.Host
+}
+
+// The methods below route every request to the active provider. The ones the attestations
+// depend on report the provider when it fails
+
+func (p *ProviderPool) AddInvokeTransaction(ctx context.Context, invokeTxn *rpc.BroadcastInvokeTxnV3) (*rpc.AddInvokeTransactionResponse, error) {
+	index, provider := p.activeProvider()
+	resp, err := provider.AddInvokeTransaction(ctx, invokeTxn)
+	p.reportRequestError(ctx, index, "add invoke transaction", err)
+	return resp, err
+}
+
+func (p *ProviderPool) AddDeclareTransaction(ctx context.Context, declareTransaction *rpc.BroadcastDeclareTxnV3) (*rpc.AddDeclareTransactionResponse, error) {
+	return p.Active().AddDeclareTransaction(ctx, declareTransaction)
+}
+
+func (p *ProviderPool) AddDeployAccountTransaction(ctx context.Context, deployAccountTransaction *rpc.BroadcastDeployAccountTxnV3) (*rpc.AddDeployAccountTransactionResponse, error) {
+	return p.Active().AddDeployAccountTransaction(ctx, deployAccountTransaction)
+}
+
+func (p *ProviderPool) BlockHashAndNumber(ctx context.Context) (*rpc.BlockHashAndNumberOutput, error) {
+	return p.Active().BlockHashAndNumber(ctx)
+}
+
+func (p *ProviderPool) BlockNumber(ctx context.Context) (uint64, error) {
+	return p.Active().BlockNumber(ctx)
+}
+
+func (p *ProviderPool) BlockTransactionCount(ctx context.Context, blockID rpc.BlockID) (uint64, error) {
+	return p.Active().BlockTransactionCount(ctx, blockID)
+}
+
+func (p *ProviderPool) BlockWithReceipts(ctx context.Context, blockID rpc.BlockID) (interface{}, error) {
+	return p.Active().BlockWithReceipts(ctx, blockID)
+}
+
+func (p *ProviderPool) BlockWithTxHashes(ctx context.Context, blockID rpc.BlockID) (interface{}, error) {
+	return p.Active().BlockWithTxHashes(ctx, blockID)
+}
+
+func (p *ProviderPool) BlockWithTxs(ctx context.Context, blockID rpc.BlockID) (interface{}, error) {
+	return p.Active().BlockWithTxs(ctx, blockID)
+}
+
+func (p *ProviderPool) Call(ctx context.Context, call rpc.FunctionCall, block rpc.BlockID) ([]*felt.Felt, error) {
+	index, provider := p.activeProvider()
+	result, err := provider.Call(ctx, call, block)
+	p.reportRequestError(ctx, index, "call", err)
+	return result, err
+}
+
+func (p *ProviderPool) ChainID(ctx context.Context) (string, error) {
+	return p.Active().ChainID(ctx)
+}
+
+func (p *ProviderPool) Class(ctx context.Context, blockID rpc.BlockID, classHash *felt.Felt) (rpc.ClassOutput, error) {
+	return p.Active().Class(ctx, blockID, classHash)
+}
+
+func (p *ProviderPool) ClassAt(ctx context.Context, blockID rpc.BlockID, contractAddress *felt.Felt) (rpc.ClassOutput, error) {
+	return p.Active().ClassAt(ctx, blockID, contractAddress)
+}
+
+func (p *ProviderPool) ClassHashAt(ctx context.Context, blockID rpc.BlockID, contractAddress *felt.Felt) (*felt.Felt, error) {
+	return p.Active().ClassHashAt(ctx, blockID, contractAddress)
+}
+
+func (p *ProviderPool) CompiledCasm(ctx context.Context, classHash *felt.Felt) (*contracts.CasmClass, error) {
+	return p.Active().CompiledCasm(ctx, classHash)
+}
+
+func (p *ProviderPool) EstimateFee(ctx context.Context, requests []rpc.BroadcastTxn, simulationFlags []rpc.SimulationFlag, blockID rpc.BlockID) ([]rpc.FeeEstimation, error) {
+	index, provider := p.activeProvider()
+	estimates, err := provider.EstimateFee(ctx, requests, simulationFlags, blockID)
+	p.reportRequestError(ctx, index, "estimate fee", err)
+	return estimates, err
+}
+
+func (p *ProviderPool) EstimateMessageFee(ctx context.Context, msg rpc.MsgFromL1, blockID rpc.BlockID) (*rpc.FeeEstimation, error) {
+	return p.Active().EstimateMessageFee(ctx, msg, blockID)
+}
+
+func (p *ProviderPool) Events(ctx context.Context, input rpc.EventsInput) (*rpc.EventChunk, error) {
+	return p.Active().Events(ctx, input)
+}
+
+func (p *ProviderPool) GetStorageProof(ctx context.Context, storageProofInput rpc.StorageProofInput) (*rpc.StorageProofResult, error) {
+	return p.Active().GetStorageProof(ctx, storageProofInput)
+}
+
+func (p *ProviderPool) GetTransactionStatus(ctx context.Context, transactionHash *felt.Felt) (*rpc.TxnStatusResult, error) {
+	index, provider := p.activeProvider()
+	status, err := provider.GetTransactionStatus(ctx, transactionHash)
+	p.reportRequestError(ctx, index, "get transaction status", err)
+	return status, err
+}
+
+func (p *ProviderPool) GetMessagesStatus(ctx context.Context, transactionHash rpc.NumAsHex) ([]rpc.MessageStatus, error) {
+	return p.Active().GetMessagesStatus(ctx, transactionHash)
+}
+
+func (p *ProviderPool) Nonce(ctx context.Context, blockID rpc.BlockID, contractAddress *felt.Felt) (*felt.Felt, error) {
+	index, provider := p.activeProvider()
+	nonce, err := provider.Nonce(ctx, blockID, contractAddress)
+	p.reportRequestError(ctx, index, "nonce", err)
+	return nonce, err
+}
+
+func (p *ProviderPool) SimulateTransactions(ctx context.Context, blockID rpc.BlockID, txns []rpc.BroadcastTxn, simulationFlags []rpc.SimulationFlag) ([]rpc.SimulatedTransaction, error) {
+	return p.Active().SimulateTransactions(ctx, blockID, txns, simulationFlags)
+}
+
+func (p *ProviderPool) StateUpdate(ctx context.Context, blockID rpc.BlockID) (*rpc.StateUpdateOutput, error) {
+	return p.Active().StateUpdate(ctx, blockID)
+}
+
+func (p *ProviderPool) StorageAt(ctx context.Context, contractAddress *felt.Felt, key string, blockID rpc.BlockID) (string, error) {
+	return p.Active().StorageAt(ctx, contractAddress, key, blockID)
+}
+
+func (p *ProviderPool) SpecVersion(ctx context.Context) (string, error) {
+	return p.Active().SpecVersion(ctx)
+}
+
+func (p *ProviderPool) Syncing(ctx context.Context) (*rpc.SyncStatus, error) {
+	return p.Active().Syncing(ctx)
+}
+
+func (p *ProviderPool) TraceBlockTransactions(ctx context.Context, blockID rpc.BlockID) ([]rpc.Trace, error) {
+	return p.Active().TraceBlockTransactions(ctx, blockID)
+}
+
+func (p *ProviderPool) TransactionByBlockIdAndIndex(ctx context.Context, blockID rpc.BlockID, index uint64) (*rpc.BlockTransaction, error) {
+	return p.Active().TransactionByBlockIdAndIndex(ctx, blockID, index)
+}
+
+func (p *ProviderPool) TransactionByHash(ctx context.Context, hash *felt.Felt) (*rpc.BlockTransaction, error) {
+	return p.Active().TransactionByHash(ctx, hash)
+}
+
+func (p *ProviderPool) TransactionReceipt(ctx context.Context, transactionHash *felt.Felt) (*rpc.TransactionReceiptWithBlockInfo, error) {
+	return p.Active().TransactionReceipt(ctx, transactionHash)
+}
+
+func (p *ProviderPool) TraceTransaction(ctx context.Context, transactionHash *felt.Felt) (rpc.TxnTrace, error) {
+	return p.Active().TraceTransaction(ctx, transactionHash)
+}
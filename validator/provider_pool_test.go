@@ -0,0 +1,249 @@
+package validator_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/NethermindEth/juno/utils"
+	"github.com/NethermindEth/starknet-staking-v2/validator"
+	"github.com/NethermindEth/starknet-staking-v2/validator/config"
+	"github.com/NethermindEth/starknet.go/rpc"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	sepoliaChainID = "0x534e5f5345504f4c4941"
+	mainnetChainID = "0x534e5f4d41494e"
+)
+
+// Answers the requests used in the provider health checks. Calls are answered with
+// a contract error and any other request fails
+func mockHealthRPCServer(t *testing.T, chainID string, head *atomic.Uint64) *httptest.Server {
+	t.Helper()
+
+	return mockDelayedHealthRPCServer(t, chainID, head, 0)
+}
+
+// Same as `mockHealthRPCServer`, taking `delay` to answer the block number requests
+func mockDelayedHealthRPCServer(
+	t *testing.T, chainID string, head *atomic.Uint64, delay time.Duration,
+) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+
+		var req struct {
+			ID     uint64 `json:"id"`
+			Method string `json:"method"`
+		}
+		require.NoError(t, json.Unmarshal(body, &req))
+
+		var result string
+		switch req.Method {
+		case "starknet_chainId":
+			result = fmt.Sprintf("%q", chainID)
+		case "starknet_blockNumber":
+			time.Sleep(delay)
+			result = fmt.Sprintf("%d", head.Load())
+		case "starknet_call":
+			_, err = fmt.Fprintf(
+				w,
+				`{"jsonrpc": "2.0", "error": {"code": 40, "message": "Contract error"}, "id": %d}`,
+				req.ID,
+			)
+			require.NoError(t, err)
+			return
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		_, err = fmt.Fprintf(w, `{"jsonrpc": "2.0", "result": %s, "id": %d}`, result, req.ID)
+		require.NoError(t, err)
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func newHead(blockNumber uint64) *atomic.Uint64 {
+	head := new(atomic.Uint64)
+	head.Store(blockNumber)
+	return head
+}
+
+func TestProviderPool(t *testing.T) {
+	logger := utils.NewNopZapLogger()
+	metricsServer := mockMetricsServer()
+
+	t.Run("Error when no provider is configured", func(t *testing.T) {
+		pool, err := validator.NewProviderPool(nil, logger, metricsServer)
+
+		require.Nil(t, pool)
+		require.ErrorContains(t, err, "no RPC provider configured")
+	})
+
+	t.Run("Error when no provider can be reached", func(t *testing.T) {
+		unreachable := httptest.NewServer(http.NotFoundHandler())
+		unreachable.Close()
+
+		pool, err := validator.NewProviderPool(
+			[]config.Provider{{Http: unreachable.URL}}, logger, metricsServer,
+		)
+
+		require.Nil(t, pool)
+		require.ErrorContains(t, err, "cannot connect to any of the configured RPC providers")
+	})
+
+	t.Run("First healthy provider in order is the active one", func(t *testing.T) {
+		unreachable := httptest.NewServer(http.NotFoundHandler())
+		unreachable.Close()
+		healthy1 := mockHealthRPCServer(t, sepoliaChainID, newHead(100))
+		healthy2 := mockHealthRPCServer(t, sepoliaChainID, newHead(100))
+
+		pool, err := validator.NewProviderPool(
+			[]config.Provider{
+				{Http: unreachable.URL},
+				{Http: healthy1.URL, Ws: "ws://healthy1"},
+				{Http: healthy2.URL},
+			},
+			logger,
+			metricsServer,
+		)
+		require.NoError(t, err)
+
+		index, provider := pool.ActiveEndpoint()
+		require.Equal(t, 1, index)
+		require.Equal(t, config.Provider{Http: healthy1.URL, Ws: "ws://healthy1"}, provider)
+
+		health := pool.Health()
+		require.False(t, health[0].Reachable)
+		require.True(t, health[1].Reachable)
+		require.Equal(t, uint64(100), health[1].HeadBlock)
+	})
+
+	t.Run("Providers on a different chain are skipped", func(t *testing.T) {
+		sepolia := mockHealthRPCServer(t, sepoliaChainID, newHead(100))
+		mainnet := mockHealthRPCServer(t, mainnetChainID, newHead(100))
+		sepoliaFallback := mockHealthRPCServer(t, sepoliaChainID, newHead(100))
+
+		pool, err := validator.NewProviderPool(
+			[]config.Provider{
+				{Http: sepolia.URL}, {Http: mainnet.URL}, {Http: sepoliaFallback.URL},
+			},
+			logger,
+			metricsServer,
+		)
+		require.NoError(t, err)
+		require.Equal(t, "SN_SEPOLIA", validator.ChainID)
+
+		pool.ReportFailure(0, "some failure")
+
+		index, _ := pool.ActiveEndpoint()
+		require.Equal(t, 2, index)
+	})
+
+	t.Run("Providers with a stale head are skipped until they catch up", func(t *testing.T) {
+		staleHead := newHead(90)
+		stale := mockHealthRPCServer(t, sepoliaChainID, staleHead)
+		fresh := mockHealthRPCServer(t, sepoliaChainID, newHead(100))
+
+		pool, err := validator.NewProviderPool(
+			[]config.Provider{{Http: stale.URL}, {Http: fresh.URL}}, logger, metricsServer,
+		)
+		require.NoError(t, err)
+
+		index, _ := pool.ActiveEndpoint()
+		require.Equal(t, 1, index)
+
+		// Requests are routed to the active provider
+		blockNumber, err := pool.BlockNumber(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, uint64(100), blockNumber)
+
+		staleHead.Store(99)
+		pool.CheckHealth()
+
+		index, _ = pool.ActiveEndpoint()
+		require.Equal(t, 0, index)
+	})
+
+	t.Run("Providers reported as failing are not selected during cooldown", func(t *testing.T) {
+		provider1 := mockHealthRPCServer(t, sepoliaChainID, newHead(100))
+		provider2 := mockHealthRPCServer(t, sepoliaChainID, newHead(100))
+
+		pool, err := validator.NewProviderPool(
+			[]config.Provider{{Http: provider1.URL}, {Http: provider2.URL}}, logger, metricsServer,
+		)
+		require.NoError(t, err)
+
+		pool.ReportFailure(0, "block headers stalled")
+		index, _ := pool.ActiveEndpoint()
+		require.Equal(t, 1, index)
+
+		pool.CheckHealth()
+		index, _ = pool.ActiveEndpoint()
+		require.Equal(t, 1, index)
+	})
+
+	t.Run("Much faster provider is preferred over the ones before it", func(t *testing.T) {
+		slow := mockDelayedHealthRPCServer(t, sepoliaChainID, newHead(100), 400*time.Millisecond)
+		fast := mockHealthRPCServer(t, sepoliaChainID, newHead(100))
+
+		pool, err := validator.NewProviderPool(
+			[]config.Provider{{Http: slow.URL}, {Http: fast.URL}}, logger, metricsServer,
+		)
+		require.NoError(t, err)
+
+		index, _ := pool.ActiveEndpoint()
+		require.Equal(t, 1, index)
+		health := pool.Health()
+		require.Greater(t, health[0].Latency, health[1].Latency)
+	})
+
+	t.Run("Providers failing a request are reported", func(t *testing.T) {
+		provider1 := mockHealthRPCServer(t, sepoliaChainID, newHead(100))
+		provider2 := mockHealthRPCServer(t, sepoliaChainID, newHead(100))
+
+		pool, err := validator.NewProviderPool(
+			[]config.Provider{{Http: provider1.URL}, {Http: provider2.URL}}, logger, metricsServer,
+		)
+		require.NoError(t, err)
+
+		// Errors answered by the node are about the request, not the provider
+		_, err = pool.Call(t.Context(), rpc.FunctionCall{}, rpc.BlockID{Tag: "latest"})
+		require.ErrorContains(t, err, "Contract error")
+		index, _ := pool.ActiveEndpoint()
+		require.Equal(t, 0, index)
+
+		_, err = pool.EstimateFee(t.Context(), nil, nil, rpc.BlockID{Tag: "latest"})
+		require.Error(t, err)
+		index, _ = pool.ActiveEndpoint()
+		require.Equal(t, 1, index)
+	})
+
+	t.Run("Current provider is kept when none is healthy", func(t *testing.T) {
+		provider1 := mockHealthRPCServer(t, sepoliaChainID, newHead(100))
+		provider2 := mockHealthRPCServer(t, sepoliaChainID, newHead(100))
+
+		pool, err := validator.NewProviderPool(
+			[]config.Provider{{Http: provider1.URL}, {Http: provider2.URL}}, logger, metricsServer,
+		)
+		require.NoError(t, err)
+
+		pool.ReportFailure(1, "some failure")
+		pool.ReportFailure(0, "some failure")
+
+		index, _ := pool.ActiveEndpoint()
+		require.Equal(t, 0, index)
+	})
+}
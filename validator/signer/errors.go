@@ -1,6 +1,30 @@
 package signer
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+
+	"github.com/NethermindEth/juno/core/felt"
+)
+
+// The signer's key doesn't control the operational account, so its transactions would fail
+var ErrAccountKeyMismatch = errors.New("signer key doesn't match the operational account")
+
+// The signature returned by the external signer isn't valid for the transaction hash and
+// the account public key, so the transaction would be rejected by the network
+type InvalidSignatureError struct {
+	TransactionHash *felt.Felt
+	PublicKey       *felt.Felt
+	Signature       []*felt.Felt
+}
+
+func (e *InvalidSignatureError) Error() string {
+	return fmt.Sprintf(
+		"external signer returned signature %v which is not valid for transaction %s"+
+			" and public key %s",
+		e.Signature, e.TransactionHash, e.PublicKey,
+	)
+}
 
 func entrypointInternalError(entrypointName string, err error) error {
 	return errors.New("Error when calling entrypoint `" + entrypointName + "`: " + err.Error())
@@ -3,38 +3,53 @@ package signer
 import (
 	"bytes"
 	"context"
+	"crypto/tls"
 	"encoding/json"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
+	"net/url"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 
+	junoCrypto "github.com/NethermindEth/juno/core/crypto"
 	"github.com/NethermindEth/juno/core/felt"
 	junoUtils "github.com/NethermindEth/juno/utils"
 	"github.com/NethermindEth/starknet-staking-v2/signer"
 	"github.com/NethermindEth/starknet-staking-v2/validator/config"
+	"github.com/NethermindEth/starknet-staking-v2/validator/constants"
+	"github.com/NethermindEth/starknet-staking-v2/validator/metrics"
 	"github.com/NethermindEth/starknet-staking-v2/validator/types"
 	"github.com/NethermindEth/starknet.go/account"
+	"github.com/NethermindEth/starknet.go/hash"
 	"github.com/NethermindEth/starknet.go/rpc"
 	"github.com/NethermindEth/starknet.go/utils"
+	"github.com/cockroachdb/errors"
 )
 
 var _ Signer = (*ExternalSigner)(nil)
 
 // Used as a wrapper around an exgernal signer implementation
 type ExternalSigner struct {
-	*rpc.Provider
-	operationalAddress  Address
-	chainId             felt.Felt
-	url                 string
+	rpc.RpcProvider
+	operationalAddress Address
+	chainId            felt.Felt
+	remoteSigner       *RemoteSigner
+	// Key of the operational account, every signature is verified against it
+	publicKey           *felt.Felt
 	validationContracts ValidationContracts
+	lastInvokeTxn       *sentInvokeTxn
 }
 
 func NewExternalSigner(
-	provider *rpc.Provider,
+	provider rpc.RpcProvider,
 	logger *junoUtils.ZapLogger,
 	signer *config.Signer,
 	addresses *config.ContractAddresses,
+	metricsServer *metrics.Metrics,
 ) (ExternalSigner, error) {
 	chainIdStr, err := provider.ChainID(context.Background())
 	if err != nil {
@@ -42,23 +57,139 @@ func NewExternalSigner(
 	}
 	chainId := new(felt.Felt).SetBytes([]byte(chainIdStr))
 
+	auth, err := signer.Auth()
+	if err != nil {
+		return ExternalSigner{}, err
+	}
+	tlsConfig, err := signer.TLSConfig()
+	if err != nil {
+		return ExternalSigner{}, err
+	}
+
+	timeout, maxRetries, err := signer.RequestLimits()
+	if err != nil {
+		return ExternalSigner{}, err
+	}
+	protocol, signMode, err := signer.SigningProtocol()
+	if err != nil {
+		return ExternalSigner{}, err
+	}
+
+	remoteSigner := NewRemoteSigner(signer.ExternalURL, auth, tlsConfig, RemoteSignerOptions{
+		FallbackURLs:              signer.FallbackURLs,
+		Timeout:                   timeout,
+		MaxRetries:                maxRetries,
+		Metrics:                   metricsServer,
+		Network:                   chainIdStr,
+		Logger:                    logger,
+		Protocol:                  protocol,
+		SignMode:                  signMode,
+		AllowUnverifiedSignatures: signer.AllowUnverifiedSignatures,
+	})
+	operationalAddress := types.AddressFromString(signer.OperationalAddress)
+	publicKey, err := remoteSigner.PublicKey(context.Background(), operationalAddress.Felt())
+	if err != nil {
+		return ExternalSigner{}, err
+	}
+	if err := checkOperationalAccountKey(
+		context.Background(), provider, operationalAddress.Felt(), publicKey, logger,
+	); err != nil {
+		return ExternalSigner{}, err
+	}
+
 	validationContracts := types.ValidationContractsFromAddresses(addresses.SetDefaults(chainIdStr))
 	logger.Infof("validation contracts: %s", validationContracts.String())
 
 	return ExternalSigner{
-		Provider:            provider,
-		operationalAddress:  types.AddressFromString(signer.OperationalAddress),
-		url:                 signer.ExternalURL,
+		RpcProvider:         provider,
+		operationalAddress:  operationalAddress,
+		remoteSigner:        remoteSigner,
+		publicKey:           publicKey,
 		chainId:             *chainId,
 		validationContracts: validationContracts,
 	}, nil
 }
 
-func (s *ExternalSigner) BuildAndSendInvokeTxn(
+func (s *ExternalSigner) EstimateInvokeTxnFee(
 	ctx context.Context,
 	functionCalls []rpc.InvokeFunctionCall,
 	multiplier float64,
+) (rpc.ResourceBoundsMapping, error) {
+	// The txn needs a signature to estimate the fee
+	broadcastInvokeTxnV3, err := s.buildInvokeTxn(
+		ctx, functionCalls, makeResourceBoundsMapWithZeroValues(),
+	)
+	if err != nil {
+		return rpc.ResourceBoundsMapping{}, err
+	}
+
+	estimateFee, err := s.EstimateFee(
+		ctx,
+		[]rpc.BroadcastTxn{broadcastInvokeTxnV3},
+		[]rpc.SimulationFlag{},
+		rpc.WithBlockTag("pending"),
+	)
+	if err != nil {
+		return rpc.ResourceBoundsMapping{}, err
+	}
+
+	return utils.FeeEstToResBoundsMap(estimateFee[0], multiplier), nil
+}
+
+func (s *ExternalSigner) BuildAndSendInvokeTxn(
+	ctx context.Context,
+	functionCalls []rpc.InvokeFunctionCall,
+	resourceBounds *rpc.ResourceBoundsMapping,
 ) (*rpc.AddInvokeTransactionResponse, error) {
+	broadcastInvokeTxnV3, err := s.buildInvokeTxn(ctx, functionCalls, *resourceBounds)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.sendInvokeTxn(ctx, broadcastInvokeTxnV3)
+}
+
+func (s *ExternalSigner) ReplaceInvokeTxn(
+	ctx context.Context,
+	transactionHash *felt.Felt,
+	bumpPercentage uint64,
+	maxFee *felt.Felt,
+) (*rpc.AddInvokeTransactionResponse, *felt.Felt, error) {
+	broadcastInvokeTxnV3, err := invokeTxnToReplace(
+		ctx, s.RpcProvider, s.lastInvokeTxn, transactionHash,
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := BumpInvokeTxnFees(&broadcastInvokeTxnV3.InvokeTxnV3, bumpPercentage); err != nil {
+		return nil, nil, err
+	}
+	replacementMaxFee, err := checkMaxFee(&broadcastInvokeTxnV3.InvokeTxnV3, maxFee)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// The fees are part of the txn hash, so it has to be signed again
+	if err := SignInvokeTx(
+		ctx, &broadcastInvokeTxnV3.InvokeTxnV3, &s.chainId, s.publicKey, s.remoteSigner,
+	); err != nil {
+		return nil, nil, err
+	}
+
+	resp, err := s.sendInvokeTxn(ctx, &broadcastInvokeTxnV3)
+	if err != nil {
+		return nil, nil, err
+	}
+	return resp, replacementMaxFee, nil
+}
+
+// Builds the invoke txn with the account's next nonce and signs it
+func (s *ExternalSigner) buildInvokeTxn(
+	ctx context.Context,
+	functionCalls []rpc.InvokeFunctionCall,
+	resourceBounds rpc.ResourceBoundsMapping,
+) (*rpc.BroadcastInvokeTxnV3, error) {
 	nonce, err := s.Nonce(ctx, rpc.WithBlockTag("pending"), s.Address().Felt())
 	if err != nil {
 		return nil, err
@@ -67,37 +198,28 @@ func (s *ExternalSigner) BuildAndSendInvokeTxn(
 	fnCallData := utils.InvokeFuncCallsToFunctionCalls(functionCalls)
 	formattedCallData := account.FmtCallDataCairo2(fnCallData)
 
-	// Building and signing the txn, as it needs a signature to estimate the fee
 	broadcastInvokeTxnV3 := utils.BuildInvokeTxn(
-		s.Address().Felt(),
-		nonce,
-		formattedCallData,
-		makeResourceBoundsMapWithZeroValues(),
+		s.Address().Felt(), nonce, formattedCallData, resourceBounds,
 	)
-	if err := SignInvokeTx(&broadcastInvokeTxnV3.InvokeTxnV3, &s.chainId, s.url); err != nil {
+	if err := SignInvokeTx(
+		ctx, &broadcastInvokeTxnV3.InvokeTxnV3, &s.chainId, s.publicKey, s.remoteSigner,
+	); err != nil {
 		return nil, err
 	}
 
-	// Estimate txn fee
-	estimateFee, err := s.EstimateFee(
-		ctx,
-		[]rpc.BroadcastTxn{broadcastInvokeTxnV3},
-		[]rpc.SimulationFlag{},
-		rpc.WithBlockTag("pending"),
-	)
-	if err != nil {
-		return nil, err
-	}
-	txnFee := estimateFee[0]
-	broadcastInvokeTxnV3.ResourceBounds = utils.FeeEstToResBoundsMap(txnFee, multiplier)
+	return broadcastInvokeTxnV3, nil
+}
 
-	// Signing the txn again with the estimated fee,
-	// as the fee value is used in the txn hash calculation
-	if err := SignInvokeTx(&broadcastInvokeTxnV3.InvokeTxnV3, &s.chainId, s.url); err != nil {
+func (s *ExternalSigner) sendInvokeTxn(
+	ctx context.Context, broadcastInvokeTxnV3 *rpc.BroadcastInvokeTxnV3,
+) (*rpc.AddInvokeTransactionResponse, error) {
+	resp, err := s.AddInvokeTransaction(ctx, broadcastInvokeTxnV3)
+	if err != nil {
 		return nil, err
 	}
 
-	return s.AddInvokeTransaction(ctx, broadcastInvokeTxnV3)
+	s.lastInvokeTxn = &sentInvokeTxn{hash: *resp.TransactionHash, txn: *broadcastInvokeTxnV3}
+	return resp, nil
 }
 
 func (s *ExternalSigner) Address() *Address {
@@ -108,49 +230,522 @@ func (s *ExternalSigner) ValidationContracts() *ValidationContracts {
 	return &s.validationContracts
 }
 
-func SignInvokeTx(invokeTxnV3 *rpc.InvokeTxnV3, chainId *felt.Felt, externalSignerUrl string) error {
-	signResp, err := HashAndSignTx(invokeTxnV3, chainId, externalSignerUrl)
+// Wait before the first retry of a request to the remote signer, doubled on each retry.
+// A variable so tests don't have to wait
+var RetryBackoff = constants.SIGNER_RETRY_BACKOFF
+
+// Reasons a request to the remote signer failed, used as label of the error metric
+const (
+	remoteSignerTimeout         = "timeout"
+	remoteSignerConnectionError = "connection"
+	remoteSignerServerError     = "server_error"
+	remoteSignerRejected        = "rejected"
+	remoteSignerInvalidResponse = "invalid_response"
+	remoteSignerCancelled       = "cancelled"
+	remoteSignerInvalidRequest  = "invalid_request"
+	remoteSignerInvalidSig      = "invalid_signature"
+)
+
+// How requests to the remote signer are limited, retried and measured
+type RemoteSignerOptions struct {
+	// Remote signers holding the same key, tried in order when the ones before them fail
+	FallbackURLs []string
+	// Maximum duration of each attempt, no limit when zero
+	Timeout time.Duration
+	// How many times a request failing with a connection or server error, or timing out,
+	// is retried
+	MaxRetries int
+	// Records the latency and errors of the requests, disabled when nil
+	Metrics *metrics.Metrics
+	// Chain the transactions are signed for, used as label of the metrics
+	Network string
+	// Reports which remote signer signed and the ones being skipped, disabled when nil
+	Logger *junoUtils.ZapLogger
+	// Version of the signing protocol, the unversioned endpoint is used when empty
+	Protocol string
+	// Whether the v2 protocol is sent the transaction or only its hash,
+	// defaults to the transaction
+	SignMode string
+	// Accept signatures other than `r` and `s` without verifying them, leaving them to the
+	// account to validate. Two value signatures are always verified
+	AllowUnverifiedSignatures bool
+}
+
+// For how long a remote signer that failed repeatedly is skipped.
+// A variable so tests don't have to wait
+var CircuitBreakerCooldown = constants.SIGNER_CIRCUIT_BREAKER_COOLDOWN
+
+// One of the remote signers holding the key
+type remoteSignerEndpoint struct {
+	url  string
+	name string
+	// Requests failed in a row, and when the last one failed
+	failures int
+	failedAt time.Time
+}
+
+// Whether the remote signer failed too many requests in a row and is skipped while the
+// cooldown lasts. After it, a single failure is enough for the signer to be skipped again
+func (e *remoteSignerEndpoint) circuitOpen(now time.Time) bool {
+	return e.failures >= constants.SIGNER_CIRCUIT_BREAKER_THRESHOLD &&
+		now.Sub(e.failedAt) < CircuitBreakerCooldown
+}
+
+// Connection to the remote signers that sign the transactions of an external signer.
+// Requests go to the first remote signer in the configured order which isn't failing
+type RemoteSigner struct {
+	auth       signer.Auth
+	httpClient *http.Client
+	options    RemoteSignerOptions
+	logger     *junoUtils.ZapLogger
+
+	mu        sync.Mutex
+	endpoints []remoteSignerEndpoint
+}
+
+// Creates the connection to the remote signer at `url` and the fallback ones set in the
+// options. If `tlsConfig` is nil, the default TLS configuration is used for https urls
+func NewRemoteSigner(
+	url string, auth signer.Auth, tlsConfig *tls.Config, options RemoteSignerOptions,
+) *RemoteSigner {
+	transport := http.DefaultTransport
+	if tlsConfig != nil {
+		tlsTransport := http.DefaultTransport.(*http.Transport).Clone()
+		tlsTransport.TLSClientConfig = tlsConfig
+		transport = tlsTransport
+	}
+
+	urls := append([]string{url}, options.FallbackURLs...)
+	endpoints := make([]remoteSignerEndpoint, len(urls))
+	for i := range urls {
+		endpoints[i] = remoteSignerEndpoint{url: urls[i], name: remoteSignerName(urls[i], i)}
+		if options.Metrics != nil {
+			options.Metrics.SetExternalSignerAvailable(options.Network, endpoints[i].name, true)
+		}
+	}
+
+	logger := options.Logger
+	if logger == nil {
+		logger = junoUtils.NewNopZapLogger()
+	}
+
+	return &RemoteSigner{
+		auth:       auth,
+		httpClient: &http.Client{Transport: transport, Timeout: options.Timeout},
+		options:    options,
+		logger:     logger,
+		endpoints:  endpoints,
+	}
+}
+
+// Asks the remote signers for the public key they sign the transactions of `address` with,
+// which selects the key of signers holding one per account. All the remote signers that
+// answer must hold the same key, the ones that can't be reached are reported as failing
+func (r *RemoteSigner) PublicKey(ctx context.Context, address *felt.Felt) (*felt.Felt, error) {
+	var publicKey *felt.Felt
+	var publicKeyOwner string
+	var lastErr error
+	for i := range r.endpoints {
+		endpointKey, err := r.endpointPublicKey(ctx, i, address)
+		if err != nil {
+			if len(r.endpoints) > 1 {
+				r.logger.Warnw(
+					"Cannot get the public key of the external signer",
+					"signer", r.endpoints[i].name,
+					"error", err,
+				)
+			}
+			lastErr = err
+			continue
+		}
+
+		if publicKey == nil {
+			publicKey = endpointKey
+			publicKeyOwner = r.endpoints[i].name
+		} else if !publicKey.Equal(endpointKey) {
+			return nil, errors.Errorf(
+				"external signers %s and %s sign with different keys: %s and %s",
+				publicKeyOwner, r.endpoints[i].name, publicKey, endpointKey,
+			)
+		}
+	}
+
+	if publicKey == nil {
+		return nil, lastErr
+	}
+	return publicKey, nil
+}
+
+func (r *RemoteSigner) endpointPublicKey(
+	ctx context.Context, index int, address *felt.Felt,
+) (*felt.Felt, error) {
+	endpoint := signer.PUBLIC_KEY_ENDPOINT
+	if address != nil {
+		endpoint += "?" + signer.PUBLIC_KEY_ADDRESS_PARAM + "=" + address.String()
+	}
+
+	onlyEndpoint := func() []int { return []int{index} }
+	body, _, err := r.do(ctx, onlyEndpoint, http.MethodGet, endpoint, nil)
 	if err != nil {
-		return err
+		return nil, errors.Errorf("cannot get the public key of the external signer: %w", err)
 	}
 
-	invokeTxnV3.Signature = []*felt.Felt{
-		signResp.Signature[0],
-		signResp.Signature[1],
+	var publicKeyResp signer.PublicKeyResponse
+	if err := json.Unmarshal(body, &publicKeyResp); err != nil {
+		r.recordError(remoteSignerInvalidResponse)
+		return nil, errors.Errorf("invalid public key response from the external signer: %w", err)
+	}
+	if publicKeyResp.PublicKey == nil {
+		r.recordError(remoteSignerInvalidResponse)
+		return nil, errors.New("external signer answered without a public key")
 	}
+	return publicKeyResp.PublicKey, nil
+}
 
-	return nil
+// Sends a request to the remote signers returned by `order` until one of them answers
+// successfully, and returns the body of its response along with the index of the signer.
+// Requests failing with a connection or server error, or timing out, on every signer are
+// retried with an exponential backoff until `ctx` is done. Requests with a body are
+// authenticated
+func (r *RemoteSigner) do(
+	ctx context.Context, order func() []int, method string, endpoint string, body []byte,
+) ([]byte, int, error) {
+	start := time.Now()
+	defer func() {
+		if r.options.Metrics != nil {
+			r.options.Metrics.ObserveExternalSignerLatency(r.options.Network, time.Since(start))
+		}
+	}()
+
+	backoff := RetryBackoff
+	for retries := 0; ; retries++ {
+		respBody, index, reason, err := r.attemptInOrder(ctx, order(), method, endpoint, body)
+		if err == nil {
+			return respBody, index, nil
+		}
+
+		if !isRetryable(reason) || retries >= r.options.MaxRetries {
+			r.recordError(reason)
+			return nil, -1, err
+		}
+
+		select {
+		case <-ctx.Done():
+			r.recordError(remoteSignerCancelled)
+			return nil, -1, errors.Errorf(
+				"%w while retrying the request to the external signer: %s", ctx.Err(), err,
+			)
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+		if r.options.Metrics != nil {
+			r.options.Metrics.RecordExternalSignerRetry(r.options.Network)
+		}
+	}
 }
 
-func HashAndSignTx(invokeTxnV3 *rpc.InvokeTxnV3, chainId *felt.Felt, externalSignerUrl string) (signer.Response, error) {
-	// Create request body
-	reqBody := signer.Request{InvokeTxnV3: invokeTxnV3, ChainId: chainId}
-	jsonData, err := json.Marshal(&reqBody)
+// Makes the request to each of the remote signers in order, until one of them answers.
+// A signer rejecting the request stops it, as the other signers would reject it as well
+func (r *RemoteSigner) attemptInOrder(
+	ctx context.Context, order []int, method string, endpoint string, body []byte,
+) ([]byte, int, string, error) {
+	var lastReason string
+	var lastErr error
+	for _, index := range order {
+		respBody, reason, err := r.attempt(ctx, r.endpoints[index].url, method, endpoint, body)
+		if err == nil {
+			r.reportSuccess(index)
+			return respBody, index, "", nil
+		}
+
+		if len(r.endpoints) > 1 {
+			err = errors.Errorf("external signer %s: %w", r.endpoints[index].name, err)
+		}
+		if !isRetryable(reason) {
+			return nil, index, reason, err
+		}
+		r.reportFailure(index, err)
+		lastReason, lastErr = reason, err
+	}
+	return nil, -1, lastReason, lastErr
+}
+
+// Makes a single request to a remote signer. When it fails, it also returns the reason
+func (r *RemoteSigner) attempt(
+	ctx context.Context, signerUrl string, method string, endpoint string, body []byte,
+) ([]byte, string, error) {
+	req, err := http.NewRequestWithContext(
+		ctx, method, signerUrl+endpoint, bytes.NewReader(body),
+	)
 	if err != nil {
-		return signer.Response{}, err
+		return nil, remoteSignerInvalidRequest, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+		r.auth.Authenticate(req, body, time.Now())
 	}
 
-	signEndPoint := externalSignerUrl + signer.SIGN_ENDPOINT
-	resp, err := http.Post(signEndPoint, "application/json", bytes.NewBuffer(jsonData))
+	resp, err := r.httpClient.Do(req)
 	if err != nil {
-		return signer.Response{}, err
+		if ctx.Err() != nil {
+			return nil, remoteSignerCancelled, err
+		}
+		var netErr net.Error
+		if errors.As(err, &netErr) && netErr.Timeout() {
+			return nil, remoteSignerTimeout, err
+		}
+		return nil, remoteSignerConnectionError, err
 	}
-	defer func() { _ = resp.Body.Close() }() // Intentionally ignoring the error, will fix in future
+	defer func() { _ = resp.Body.Close() }()
 
-	// Read and decode response
-	body, err := io.ReadAll(resp.Body)
+	respBody, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return signer.Response{}, err
+		return nil, remoteSignerConnectionError, err
 	}
 
 	// Check if status code indicates an error (non-2xx)
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return signer.Response{},
-			fmt.Errorf("server error %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+		reason := remoteSignerRejected
+		if resp.StatusCode >= 500 {
+			reason = remoteSignerServerError
+		}
+		return nil, reason, fmt.Errorf(
+			"server error %d: %s", resp.StatusCode, strings.TrimSpace(string(respBody)),
+		)
+	}
+	return respBody, "", nil
+}
+
+// Returns the index of every remote signer, the ones being skipped for failing at the end
+func (r *RemoteSigner) endpointOrder() []int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	order := make([]int, 0, len(r.endpoints))
+	var skipped []int
+	for i := range r.endpoints {
+		if r.endpoints[i].circuitOpen(now) {
+			skipped = append(skipped, i)
+		} else {
+			order = append(order, i)
+		}
+	}
+	return append(order, skipped...)
+}
+
+func (r *RemoteSigner) reportSuccess(index int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	endpoint := &r.endpoints[index]
+	if endpoint.failures >= constants.SIGNER_CIRCUIT_BREAKER_THRESHOLD {
+		r.logger.Infow("External signer is answering again", "signer", endpoint.name)
+		if r.options.Metrics != nil {
+			r.options.Metrics.SetExternalSignerAvailable(r.options.Network, endpoint.name, true)
+		}
+	}
+	endpoint.failures = 0
+}
+
+func (r *RemoteSigner) reportFailure(index int, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	endpoint := &r.endpoints[index]
+	endpoint.failures++
+	endpoint.failedAt = time.Now()
+	if endpoint.failures != constants.SIGNER_CIRCUIT_BREAKER_THRESHOLD {
+		return
+	}
+
+	if len(r.endpoints) > 1 {
+		r.logger.Warnw(
+			"External signer failed too many requests in a row, trying the other signers first",
+			"signer", endpoint.name,
+			"failures", endpoint.failures,
+			"cooldown", CircuitBreakerCooldown,
+			"error", err,
+		)
+	}
+	if r.options.Metrics != nil {
+		r.options.Metrics.SetExternalSignerAvailable(r.options.Network, endpoint.name, false)
+	}
+}
+
+// Logs and records which of the remote signers signed a transaction
+func (r *RemoteSigner) reportSigned(index int, nonce *felt.Felt) {
+	name := r.endpoints[index].name
+	if index > 0 {
+		r.logger.Infow(
+			"Transaction signed by fallback external signer",
+			"signer", name,
+			"nonce", nonce,
+		)
+	} else {
+		r.logger.Debugw(
+			"Transaction signed by external signer",
+			"signer", name,
+			"nonce", nonce,
+		)
 	}
+	if r.options.Metrics != nil {
+		r.options.Metrics.RecordExternalSignerSigned(r.options.Network, name)
+	}
+}
+
+func (r *RemoteSigner) recordError(reason string) {
+	if r.options.Metrics != nil {
+		r.options.Metrics.RecordExternalSignerError(r.options.Network, reason)
+	}
+}
+
+func isRetryable(reason string) bool {
+	return reason == remoteSignerTimeout ||
+		reason == remoteSignerConnectionError ||
+		reason == remoteSignerServerError
+}
+
+// Only the host is used to identify the remote signer in logs and metrics,
+// as the full url might contain credentials
+func remoteSignerName(signerUrl string, index int) string {
+	u, err := url.Parse(signerUrl)
+	if err != nil || u.Host == "" {
+		return "signer-" + strconv.Itoa(index)
+	}
+	return u.Host
+}
+
+// Signs the transaction with the remote signer. The signature is verified against
+// `publicKey` before setting it, so a faulty signer is caught before sending the transaction.
+// Signatures other than `r` and `s` can't be checked against a single public key, so they
+// are only accepted, and left to the account to validate, when the remote signer allows it
+func SignInvokeTx(
+	ctx context.Context,
+	invokeTxnV3 *rpc.InvokeTxnV3,
+	chainId *felt.Felt,
+	publicKey *felt.Felt,
+	remoteSigner *RemoteSigner,
+) error {
+	signResp, err := HashAndSignTx(ctx, invokeTxnV3, chainId, remoteSigner)
+	if err != nil {
+		return err
+	}
+
+	signature := signResp.Signature
+	unverified := remoteSigner.options.AllowUnverifiedSignatures &&
+		len(signature) > 0 && len(signature) != 2
+	if !unverified {
+		if err := VerifyInvokeTxSignature(invokeTxnV3, chainId, publicKey, signature); err != nil {
+			var invalidSignature *InvalidSignatureError
+			if errors.As(err, &invalidSignature) {
+				remoteSigner.recordError(remoteSignerInvalidSig)
+			}
+			return err
+		}
+	}
+
+	invokeTxnV3.Signature = signature
+
+	return nil
+}
+
+// Sends the transaction to the remote signer, or only its hash in the v2 `hash` mode,
+// and returns the signature. The hash returned by v2 signers must match the transaction's
+func HashAndSignTx(
+	ctx context.Context,
+	invokeTxnV3 *rpc.InvokeTxnV3,
+	chainId *felt.Felt,
+	remoteSigner *RemoteSigner,
+) (signer.ResponseV2, error) {
+	var txHash *felt.Felt
+	var endpoint string
+	var reqBody any
+	switch remoteSigner.options.Protocol {
+	case signer.PROTOCOL_V2:
+		var err error
+		txHash, err = hash.TransactionHashInvokeV3(invokeTxnV3, chainId)
+		if err != nil {
+			remoteSigner.recordError(remoteSignerInvalidRequest)
+			return signer.ResponseV2{}, errors.Errorf("cannot compute the transaction hash: %w", err)
+		}
 
-	var signResp signer.Response
-	return signResp, json.Unmarshal(body, &signResp)
+		req := signer.RequestV2{Mode: remoteSigner.options.SignMode}
+		if req.Mode == signer.SIGN_MODE_HASH {
+			req.Hash = txHash
+			req.SenderAddress = invokeTxnV3.SenderAddress
+		} else {
+			req.Transaction = invokeTxnV3
+			req.ChainId = chainId
+		}
+		endpoint = signer.SIGN_V2_ENDPOINT
+		reqBody = &req
+	case signer.PROTOCOL_V1:
+		endpoint = signer.SIGN_V1_ENDPOINT
+		reqBody = &signer.Request{InvokeTxnV3: invokeTxnV3, ChainId: chainId}
+	default:
+		endpoint = signer.SIGN_ENDPOINT
+		reqBody = &signer.Request{InvokeTxnV3: invokeTxnV3, ChainId: chainId}
+	}
+
+	jsonData, err := json.Marshal(reqBody)
+	if err != nil {
+		return signer.ResponseV2{}, err
+	}
+
+	body, index, err := remoteSigner.do(
+		ctx, remoteSigner.endpointOrder, http.MethodPost, endpoint, jsonData,
+	)
+	if err != nil {
+		return signer.ResponseV2{}, err
+	}
+
+	// The v1 response has the same shape, with a signature of two values and no hash
+	var signResp signer.ResponseV2
+	if err := json.Unmarshal(body, &signResp); err != nil {
+		remoteSigner.recordError(remoteSignerInvalidResponse)
+		return signer.ResponseV2{}, err
+	}
+	if txHash != nil && signResp.Hash != nil && !signResp.Hash.Equal(txHash) {
+		remoteSigner.recordError(remoteSignerInvalidResponse)
+		return signer.ResponseV2{}, errors.Errorf(
+			"external signer signed hash %s but the transaction hash is %s", signResp.Hash, txHash,
+		)
+	}
+	remoteSigner.reportSigned(index, invokeTxnV3.Nonce)
+	return signResp, nil
+}
+
+// Recomputes the transaction hash and checks `signature` is a valid `r` and `s` signature
+// of it by the owner of `publicKey`. Returns an `InvalidSignatureError` when it isn't
+func VerifyInvokeTxSignature(
+	invokeTxnV3 *rpc.InvokeTxnV3,
+	chainId *felt.Felt,
+	publicKey *felt.Felt,
+	signature []*felt.Felt,
+) error {
+	txHash, err := hash.TransactionHashInvokeV3(invokeTxnV3, chainId)
+	if err != nil {
+		return errors.Errorf("cannot compute the hash of the transaction to verify: %w", err)
+	}
+
+	invalidSignature := &InvalidSignatureError{
+		TransactionHash: txHash,
+		PublicKey:       publicKey,
+		Signature:       signature,
+	}
+	if len(signature) != 2 || signature[0] == nil || signature[1] == nil {
+		return invalidSignature
+	}
+
+	junoPublicKey := junoCrypto.NewPublicKey(publicKey)
+	valid, err := junoPublicKey.Verify(
+		&junoCrypto.Signature{R: *signature[0], S: *signature[1]}, txHash,
+	)
+	if err != nil || !valid {
+		return invalidSignature
+	}
+	return nil
 }
 
 func makeResourceBoundsMapWithZeroValues() rpc.ResourceBoundsMapping {
@@ -1,10 +1,17 @@
 package signer_test
 
 import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/NethermindEth/juno/core/felt"
 	"github.com/NethermindEth/juno/utils"
@@ -12,8 +19,10 @@ import (
 	"github.com/NethermindEth/starknet-staking-v2/validator"
 	"github.com/NethermindEth/starknet-staking-v2/validator/config"
 	"github.com/NethermindEth/starknet-staking-v2/validator/constants"
+	"github.com/NethermindEth/starknet-staking-v2/validator/metrics"
 	"github.com/NethermindEth/starknet-staking-v2/validator/signer"
 	"github.com/NethermindEth/starknet-staking-v2/validator/types"
+	"github.com/NethermindEth/starknet.go/hash"
 	"github.com/NethermindEth/starknet.go/rpc"
 	snUtils "github.com/NethermindEth/starknet.go/utils"
 	"github.com/stretchr/testify/require"
@@ -37,11 +46,105 @@ func TestNewExternalSigner(t *testing.T) {
 				OperationalAddress: "0x123",
 			},
 			new(config.ContractAddresses).SetDefaults("SN_SEPOLIA"),
+			nil,
 		)
 
 		require.Zero(t, externalSigner)
 		require.Error(t, err)
 	})
+
+	t.Run("Error getting the signer public key", func(t *testing.T) {
+		mockRpc := createMockRPCServer(t, nil)
+		defer mockRpc.Close()
+
+		mockSigner := httptest.NewServer(http.NotFoundHandler())
+		defer mockSigner.Close()
+
+		provider, err := rpc.NewProvider(mockRpc.URL)
+		require.NoError(t, err)
+
+		externalSigner, err := signer.NewExternalSigner(
+			provider,
+			utils.NewNopZapLogger(),
+			&config.Signer{
+				ExternalURL:        mockSigner.URL,
+				OperationalAddress: "0xabc",
+			},
+			new(config.ContractAddresses).SetDefaults("SN_SEPOLIA"),
+			nil,
+		)
+
+		require.Zero(t, externalSigner)
+		require.ErrorContains(
+			t,
+			err,
+			"cannot get the public key of the external signer: server error 404: 404 page not found",
+		)
+	})
+
+	t.Run("Signer key doesn't control the account", func(t *testing.T) {
+		mockRpc := createMockRPCServer(t, nil)
+		defer mockRpc.Close()
+
+		signCount := 0
+		mockSigner := validator.MockSignerServer(
+			t,
+			utils.HexToFelt(t, "0x789"),
+			func(w http.ResponseWriter, r *http.Request) { signCount++ },
+		)
+		defer mockSigner.Close()
+
+		provider, err := rpc.NewProvider(mockRpc.URL)
+		require.NoError(t, err)
+
+		externalSigner, err := signer.NewExternalSigner(
+			provider,
+			utils.NewNopZapLogger(),
+			&config.Signer{
+				ExternalURL:        mockSigner.URL,
+				OperationalAddress: "0xabc",
+			},
+			new(config.ContractAddresses).SetDefaults("SN_SEPOLIA"),
+			nil,
+		)
+
+		require.Zero(t, externalSigner)
+		require.ErrorIs(t, err, signer.ErrAccountKeyMismatch)
+		require.ErrorContains(t, err, fmt.Sprintf(
+			"the signer public key is 0x789 but account 0xabc is controlled by %s",
+			validator.MockPublicKey(t),
+		))
+		require.Zero(t, signCount)
+	})
+
+	t.Run("Public key of the operational account is requested", func(t *testing.T) {
+		mockRpc := createMockRPCServer(t, nil)
+		defer mockRpc.Close()
+
+		var requestedAddress string
+		mockSigner := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requestedAddress = r.URL.Query().Get(s.PUBLIC_KEY_ADDRESS_PARAM)
+			resp := s.PublicKeyResponse{PublicKey: validator.MockPublicKey(t)}
+			require.NoError(t, json.NewEncoder(w).Encode(resp))
+		}))
+		defer mockSigner.Close()
+
+		provider, err := rpc.NewProvider(mockRpc.URL)
+		require.NoError(t, err)
+
+		_, err = signer.NewExternalSigner(
+			provider,
+			utils.NewNopZapLogger(),
+			&config.Signer{
+				ExternalURL:        mockSigner.URL,
+				OperationalAddress: "0xabc",
+			},
+			new(config.ContractAddresses).SetDefaults("SN_SEPOLIA"),
+			nil,
+		)
+		require.NoError(t, err)
+		require.Equal(t, "0xabc", requestedAddress)
+	})
 }
 
 func TestExternalSignerAddress(t *testing.T) {
@@ -56,6 +159,11 @@ func TestExternalSignerAddress(t *testing.T) {
 		mockRpc := validator.MockRPCServer(t, operationalAddress, "")
 		defer mockRpc.Close()
 
+		mockSigner := mockSignerServer(t, func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "not expected to sign", http.StatusInternalServerError)
+		})
+		defer mockSigner.Close()
+
 		provider, err := rpc.NewProvider(mockRpc.URL)
 		require.NoError(t, err)
 
@@ -63,10 +171,11 @@ func TestExternalSignerAddress(t *testing.T) {
 			provider,
 			logger,
 			&config.Signer{
-				ExternalURL:        "http://localhost:1234",
+				ExternalURL:        mockSigner.URL,
 				OperationalAddress: "0x123",
 			},
 			new(config.ContractAddresses).SetDefaults("SN_SEPOLIA"),
+			nil,
 		)
 		require.NoError(t, err)
 
@@ -74,7 +183,21 @@ func TestExternalSignerAddress(t *testing.T) {
 	})
 }
 
-func TestBuildAndSendInvokeTxn(t *testing.T) {
+// Account deployed on Sepolia used by the tests requiring a provider
+const sepoliaAccountAddress = "0x011efbf2806a9f6fe043c91c176ed88c38907379e59d2d3413a00eeeef08aa7e"
+
+// Public key of `sepoliaAccountAddress`, so a mocked signer passes the key check
+func sepoliaAccountPublicKey(t *testing.T, provider rpc.RpcProvider) *felt.Felt {
+	t.Helper()
+
+	publicKey, err := signer.AccountPublicKey(
+		t.Context(), provider, utils.HexToFelt(t, sepoliaAccountAddress),
+	)
+	require.NoError(t, err)
+	return publicKey
+}
+
+func TestExternalSignerEstimateInvokeTxnFee(t *testing.T) {
 	mockCtrl := gomock.NewController(t)
 	t.Cleanup(mockCtrl.Finish)
 
@@ -89,27 +212,34 @@ func TestBuildAndSendInvokeTxn(t *testing.T) {
 		provider, providerErr := rpc.NewProvider(env.HttpProviderUrl)
 		require.NoError(t, providerErr)
 
+		mockSigner := mockSignerServer(t, func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "not expected to sign", http.StatusInternalServerError)
+		})
+		defer mockSigner.Close()
+
+		// There is no account deployed at this address, so its public key can't be checked
 		externalSigner, err := signer.NewExternalSigner(
 			provider,
 			logger,
 			&config.Signer{
-				ExternalURL:        "http://localhost:1234",
+				ExternalURL:        mockSigner.URL,
 				OperationalAddress: "0x123",
 			},
 			new(config.ContractAddresses).SetDefaults("SN_SEPOLIA"),
+			nil,
 		)
 		require.NoError(t, err)
 
-		addInvokeTxRes, err := externalSigner.BuildAndSendInvokeTxn(
-			t.Context(), []rpc.InvokeFunctionCall{}, constants.FEE_ESTIMATION_MULTIPLIER,
+		resourceBounds, err := externalSigner.EstimateInvokeTxnFee(
+			t.Context(), []rpc.InvokeFunctionCall{}, constants.DEFAULT_FEE_ESTIMATION_MULTIPLIER,
 		)
 
-		require.Nil(t, addInvokeTxRes)
+		require.Zero(t, resourceBounds)
 		expectedError := rpc.RPCError{Code: 20, Message: "Contract not found"}
 		require.Equal(t, expectedError.Error(), err.Error())
 	})
 
-	t.Run("Error signing transaction the first time (for estimating fee)", func(t *testing.T) {
+	t.Run("Error signing transaction", func(t *testing.T) {
 		env, err := validator.LoadEnv(t)
 		if err != nil {
 			t.Skipf("Ignoring tests that require env variables: %s", err)
@@ -120,12 +250,14 @@ func TestBuildAndSendInvokeTxn(t *testing.T) {
 
 		const serverError = "some internal error"
 		// Create a mock server
-		mockServer := httptest.NewServer(
-			http.HandlerFunc(
-				func(w http.ResponseWriter, r *http.Request) {
-					// Simulate API response
-					http.Error(w, serverError, http.StatusInternalServerError)
-				}))
+		mockServer := validator.MockSignerServer(
+			t,
+			sepoliaAccountPublicKey(t, provider),
+			func(w http.ResponseWriter, r *http.Request) {
+				// Simulate API response
+				http.Error(w, serverError, http.StatusInternalServerError)
+			},
+		)
 		defer mockServer.Close()
 
 		externalSigner, err := signer.NewExternalSigner(
@@ -133,17 +265,18 @@ func TestBuildAndSendInvokeTxn(t *testing.T) {
 			logger,
 			&config.Signer{
 				ExternalURL:        mockServer.URL,
-				OperationalAddress: "0x011efbf2806a9f6fe043c91c176ed88c38907379e59d2d3413a00eeeef08aa7e",
+				OperationalAddress: sepoliaAccountAddress,
 			},
 			new(config.ContractAddresses).SetDefaults("SN_SEPOLIA"),
+			nil,
 		)
 		require.NoError(t, err)
 
-		addInvokeTxRes, err := externalSigner.BuildAndSendInvokeTxn(
-			t.Context(), []rpc.InvokeFunctionCall{}, constants.FEE_ESTIMATION_MULTIPLIER,
+		resourceBounds, err := externalSigner.EstimateInvokeTxnFee(
+			t.Context(), []rpc.InvokeFunctionCall{}, constants.DEFAULT_FEE_ESTIMATION_MULTIPLIER,
 		)
 
-		require.Nil(t, addInvokeTxRes)
+		require.Zero(t, resourceBounds)
 		expectedErrorMsg := fmt.Sprintf(
 			"server error %d: %s", http.StatusInternalServerError, serverError,
 		)
@@ -160,13 +293,15 @@ func TestBuildAndSendInvokeTxn(t *testing.T) {
 		require.NoError(t, providerErr)
 
 		// Create a mock server
-		mockServer := httptest.NewServer(
-			http.HandlerFunc(
-				func(w http.ResponseWriter, r *http.Request) {
-					// Simulate API response
-					_, err := w.Write([]byte(`{"signature": ["0x123", "0x456"]}`))
-					require.NoError(t, err)
-				}))
+		mockServer := validator.MockSignerServer(
+			t,
+			sepoliaAccountPublicKey(t, provider),
+			func(w http.ResponseWriter, r *http.Request) {
+				// Simulate API response
+				_, err := w.Write([]byte(`{"signature": ["0x123", "0x456"]}`))
+				require.NoError(t, err)
+			},
+		)
 		defer mockServer.Close()
 
 		externalSigner, err := signer.NewExternalSigner(
@@ -174,73 +309,111 @@ func TestBuildAndSendInvokeTxn(t *testing.T) {
 			logger,
 			&config.Signer{
 				ExternalURL:        mockServer.URL,
-				OperationalAddress: "0x011efbf2806a9f6fe043c91c176ed88c38907379e59d2d3413a00eeeef08aa7e",
+				OperationalAddress: sepoliaAccountAddress,
 			},
 			new(config.ContractAddresses).SetDefaults("SN_SEPOLIA"),
+			nil,
 		)
 		require.NoError(t, err)
 
-		addInvokeTxRes, err := externalSigner.BuildAndSendInvokeTxn(
+		resourceBounds, err := externalSigner.EstimateInvokeTxnFee(
 			t.Context(),
 			[]rpc.InvokeFunctionCall{},
-			constants.FEE_ESTIMATION_MULTIPLIER,
+			constants.DEFAULT_FEE_ESTIMATION_MULTIPLIER,
 		)
 
-		require.Nil(t, addInvokeTxRes)
-		require.Contains(t, err.Error(), "Account: invalid signature")
+		// The invalid signature is caught before estimating the fee
+		require.Zero(t, resourceBounds)
+		var invalidSignature *signer.InvalidSignatureError
+		require.ErrorAs(t, err, &invalidSignature)
 	})
 
-	t.Run(
-		"Error signing transaction the second time (for the actual invoke tx)",
-		func(t *testing.T) {
-			mockRpc := createMockRPCServer(t, nil)
-			defer mockRpc.Close()
+	t.Run("Successfully estimated fee", func(t *testing.T) {
+		mockRpc := createMockRPCServer(t, nil)
+		defer mockRpc.Close()
 
-			signerCalledCount := 0
-			signerInternalError := "error when signing the 2nd time"
-			mockSigner := httptest.NewServer(
-				http.HandlerFunc(
-					func(w http.ResponseWriter, r *http.Request) {
-						signerCalledCount++
-						if signerCalledCount == 1 {
-							w.WriteHeader(http.StatusOK)
-							_, err := w.Write([]byte(`{"signature": ["0x111", "0x222"]}`))
-							require.NoError(t, err)
-						} else {
-							w.WriteHeader(http.StatusInternalServerError)
-							_, err := w.Write([]byte(signerInternalError))
-							require.NoError(t, err)
-						}
-					}))
-			defer mockSigner.Close()
+		mockSigner := mockSignerServer(t, func(w http.ResponseWriter, r *http.Request) {
+			validator.MockSign(t, w, r)
+		})
+		defer mockSigner.Close()
 
-			provider, providerErr := rpc.NewProvider(mockRpc.URL)
-			require.NoError(t, providerErr)
+		provider, providerErr := rpc.NewProvider(mockRpc.URL)
+		require.NoError(t, providerErr)
 
-			externalSigner, err := signer.NewExternalSigner(
-				provider,
-				logger,
-				&config.Signer{
-					ExternalURL:        mockSigner.URL,
-					OperationalAddress: "0xabc",
-				},
-				new(config.ContractAddresses).SetDefaults("SN_SEPOLIA"),
-			)
-			require.NoError(t, err)
+		externalSigner, err := signer.NewExternalSigner(
+			provider,
+			logger,
+			&config.Signer{
+				ExternalURL:        mockSigner.URL,
+				OperationalAddress: "0xabc",
+			},
+			new(config.ContractAddresses).SetDefaults("SN_SEPOLIA"),
+			nil,
+		)
+		require.NoError(t, err)
 
-			addInvokeTxRes, err := externalSigner.BuildAndSendInvokeTxn(
-				t.Context(),
-				[]rpc.InvokeFunctionCall{},
-				constants.FEE_ESTIMATION_MULTIPLIER,
-			)
-			require.Nil(t, addInvokeTxRes)
+		resourceBounds, err := externalSigner.EstimateInvokeTxnFee(
+			t.Context(),
+			[]rpc.InvokeFunctionCall{},
+			constants.DEFAULT_FEE_ESTIMATION_MULTIPLIER,
+		)
 
-			expectedErrorMsg := fmt.Sprintf(
-				"server error %d: %s", http.StatusInternalServerError, signerInternalError,
-			)
-			require.EqualError(t, err, expectedErrorMsg)
-			require.Equal(t, 2, signerCalledCount)
+		require.NoError(t, err)
+		require.Equal(t, mockedResourceBounds(t), resourceBounds)
+	})
+}
+
+func TestBuildAndSendInvokeTxn(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	t.Cleanup(mockCtrl.Finish)
+
+	logger := utils.NewNopZapLogger()
+
+	t.Run("Error signing transaction", func(t *testing.T) {
+		mockRpc := createMockRPCServer(t, nil)
+		defer mockRpc.Close()
+
+		signer.RetryBackoff = 0
+		defer func() { signer.RetryBackoff = constants.SIGNER_RETRY_BACKOFF }()
+
+		signerCalledCount := 0
+		signerInternalError := "error when signing"
+		mockSigner := mockSignerServer(t, func(w http.ResponseWriter, r *http.Request) {
+			signerCalledCount++
+			w.WriteHeader(http.StatusInternalServerError)
+			_, err := w.Write([]byte(signerInternalError))
+			require.NoError(t, err)
 		})
+		defer mockSigner.Close()
+
+		provider, providerErr := rpc.NewProvider(mockRpc.URL)
+		require.NoError(t, providerErr)
+
+		externalSigner, err := signer.NewExternalSigner(
+			provider,
+			logger,
+			&config.Signer{
+				ExternalURL:        mockSigner.URL,
+				OperationalAddress: "0xabc",
+			},
+			new(config.ContractAddresses).SetDefaults("SN_SEPOLIA"),
+			nil,
+		)
+		require.NoError(t, err)
+
+		resourceBounds := mockedResourceBounds(t)
+		addInvokeTxRes, err := externalSigner.BuildAndSendInvokeTxn(
+			t.Context(), []rpc.InvokeFunctionCall{}, &resourceBounds,
+		)
+		require.Nil(t, addInvokeTxRes)
+
+		expectedErrorMsg := fmt.Sprintf(
+			"server error %d: %s", http.StatusInternalServerError, signerInternalError,
+		)
+		require.EqualError(t, err, expectedErrorMsg)
+		// Server errors are retried
+		require.Equal(t, 1+constants.DEFAULT_SIGNER_MAX_RETRIES, signerCalledCount)
+	})
 
 	t.Run("Error invoking transaction", func(t *testing.T) {
 		serverInternalError := "Error processing invoke transaction"
@@ -253,13 +426,9 @@ func TestBuildAndSendInvokeTxn(t *testing.T) {
 		mockRpc := createMockRPCServer(t, addInvoke)
 		defer mockRpc.Close()
 
-		mockSigner := httptest.NewServer(
-			http.HandlerFunc(
-				func(w http.ResponseWriter, r *http.Request) {
-					w.WriteHeader(http.StatusOK)
-					_, err := w.Write([]byte(`{"signature": ["0x111", "0x222"]}`))
-					require.NoError(t, err)
-				}))
+		mockSigner := mockSignerServer(t, func(w http.ResponseWriter, r *http.Request) {
+			validator.MockSign(t, w, r)
+		})
 		defer mockSigner.Close()
 
 		provider, providerErr := rpc.NewProvider(mockRpc.URL)
@@ -273,13 +442,13 @@ func TestBuildAndSendInvokeTxn(t *testing.T) {
 				OperationalAddress: "0xabc",
 			},
 			new(config.ContractAddresses).SetDefaults("SN_SEPOLIA"),
+			nil,
 		)
 		require.NoError(t, err)
 
+		resourceBounds := mockedResourceBounds(t)
 		addInvokeTxRes, err := externalSigner.BuildAndSendInvokeTxn(
-			t.Context(),
-			[]rpc.InvokeFunctionCall{},
-			constants.FEE_ESTIMATION_MULTIPLIER,
+			t.Context(), []rpc.InvokeFunctionCall{}, &resourceBounds,
 		)
 
 		require.Nil(t, addInvokeTxRes)
@@ -309,13 +478,9 @@ func TestBuildAndSendInvokeTxn(t *testing.T) {
 		mockRpc := createMockRPCServer(t, addInvoke)
 		defer mockRpc.Close()
 
-		mockSigner := httptest.NewServer(
-			http.HandlerFunc(
-				func(w http.ResponseWriter, r *http.Request) {
-					w.WriteHeader(http.StatusOK)
-					_, err := w.Write([]byte(`{"signature": ["0x111", "0x222"]}`))
-					require.NoError(t, err)
-				}))
+		mockSigner := mockSignerServer(t, func(w http.ResponseWriter, r *http.Request) {
+			validator.MockSign(t, w, r)
+		})
 		defer mockSigner.Close()
 
 		provider, providerErr := rpc.NewProvider(mockRpc.URL)
@@ -329,13 +494,13 @@ func TestBuildAndSendInvokeTxn(t *testing.T) {
 				OperationalAddress: "0xabc",
 			},
 			new(config.ContractAddresses).SetDefaults("SN_SEPOLIA"),
+			nil,
 		)
 		require.NoError(t, err)
 
+		resourceBounds := mockedResourceBounds(t)
 		addInvokeTxRes, err := externalSigner.BuildAndSendInvokeTxn(
-			t.Context(),
-			[]rpc.InvokeFunctionCall{},
-			constants.FEE_ESTIMATION_MULTIPLIER,
+			t.Context(), []rpc.InvokeFunctionCall{}, &resourceBounds,
 		)
 
 		require.Equal(t, &rpc.AddInvokeTransactionResponse{TransactionHash: utils.HexToFelt(t, expectedInvokeTxHash)}, addInvokeTxRes)
@@ -343,6 +508,103 @@ func TestBuildAndSendInvokeTxn(t *testing.T) {
 	})
 }
 
+func TestExternalSignerReplaceInvokeTxn(t *testing.T) {
+	logger := utils.NewNopZapLogger()
+
+	newMockSigner := func(t *testing.T, signCount *int) *httptest.Server {
+		t.Helper()
+
+		return mockSignerServer(t, func(w http.ResponseWriter, r *http.Request) {
+			*signCount++
+			validator.MockSign(t, w, r)
+		})
+	}
+
+	t.Run("Error getting the transaction to replace", func(t *testing.T) {
+		mockRpc := createMockRPCServer(t, nil)
+		defer mockRpc.Close()
+
+		signCount := 0
+		mockSigner := newMockSigner(t, &signCount)
+		defer mockSigner.Close()
+
+		provider, providerErr := rpc.NewProvider(mockRpc.URL)
+		require.NoError(t, providerErr)
+
+		externalSigner, err := signer.NewExternalSigner(
+			provider,
+			logger,
+			&config.Signer{
+				ExternalURL:        mockSigner.URL,
+				OperationalAddress: "0xabc",
+			},
+			new(config.ContractAddresses).SetDefaults("SN_SEPOLIA"),
+			nil,
+		)
+		require.NoError(t, err)
+
+		addInvokeTxRes, _, err := externalSigner.ReplaceInvokeTxn(
+			t.Context(), utils.HexToFelt(t, "0x789"), 20, nil,
+		)
+
+		require.Nil(t, addInvokeTxRes)
+		require.ErrorContains(t, err, "cannot get transaction 0x789")
+		require.Equal(t, 0, signCount)
+	})
+
+	t.Run("Sent transaction is replaced keeping its nonce", func(t *testing.T) {
+		var sent []rpc.InvokeTxnV3
+		mockRpc := createMockRPCServer(t, recordAddInvoke(t, &sent))
+		defer mockRpc.Close()
+
+		signCount := 0
+		mockSigner := newMockSigner(t, &signCount)
+		defer mockSigner.Close()
+
+		provider, providerErr := rpc.NewProvider(mockRpc.URL)
+		require.NoError(t, providerErr)
+
+		externalSigner, err := signer.NewExternalSigner(
+			provider,
+			logger,
+			&config.Signer{
+				ExternalURL:        mockSigner.URL,
+				OperationalAddress: "0xabc",
+			},
+			new(config.ContractAddresses).SetDefaults("SN_SEPOLIA"),
+			nil,
+		)
+		require.NoError(t, err)
+
+		resourceBounds := mockedResourceBounds(t)
+		addInvokeTxRes, err := externalSigner.BuildAndSendInvokeTxn(
+			t.Context(), []rpc.InvokeFunctionCall{}, &resourceBounds,
+		)
+		require.NoError(t, err)
+
+		addInvokeTxRes, replacementMaxFee, err := externalSigner.ReplaceInvokeTxn(
+			t.Context(), addInvokeTxRes.TransactionHash, 20, nil,
+		)
+		require.NoError(t, err)
+		require.Equal(t, utils.HexToFelt(t, "0x78a"), addInvokeTxRes.TransactionHash)
+
+		// Signed once when building the transaction and once more when replacing it
+		require.Equal(t, 2, signCount)
+		require.Len(t, sent, 2)
+		requireReplacement(t, &sent[0], &sent[1])
+		expectedMaxFee, err := types.MaxFee(&sent[1].ResourceBounds, sent[1].Tip)
+		require.NoError(t, err)
+		require.Equal(t, expectedMaxFee, replacementMaxFee)
+	})
+}
+
+// Mocks an external signer whose key controls the accounts of `createMockRPCServer`
+func mockSignerServer(t *testing.T, sign http.HandlerFunc) *httptest.Server {
+	t.Helper()
+
+	return validator.MockSignerServer(t, validator.MockPublicKey(t), sign)
+}
+
 func TestHashAndSignTx(t *testing.T) {
 	t.Run("Error making request", func(t *testing.T) {
 		externalSignerURL := "http://localhost:1234"
@@ -354,7 +616,12 @@ func TestHashAndSignTx(t *testing.T) {
 			rpc.ResourceBoundsMapping{},
 		)
 		chainID := new(felt.Felt).SetUint64(1)
-		res, err := signer.HashAndSignTx(&invokeTxnV3.InvokeTxnV3, chainID, externalSignerURL)
+		remoteSigner := signer.NewRemoteSigner(
+			externalSignerURL, s.Auth{}, nil, signer.RemoteSignerOptions{},
+		)
+		res, err := signer.HashAndSignTx(
+			t.Context(), &invokeTxnV3.InvokeTxnV3, chainID, remoteSigner,
+		)
 
 		require.Zero(t, res)
 		require.ErrorContains(t, err, "connection refused")
@@ -381,7 +648,12 @@ func TestHashAndSignTx(t *testing.T) {
 			rpc.ResourceBoundsMapping{},
 		)
 		chainID := new(felt.Felt).SetUint64(1)
-		res, err := signer.HashAndSignTx(&invokeTxnV3.InvokeTxnV3, chainID, mockServer.URL)
+		remoteSigner := signer.NewRemoteSigner(
+			mockServer.URL, s.Auth{}, nil, signer.RemoteSignerOptions{},
+		)
+		res, err := signer.HashAndSignTx(
+			t.Context(), &invokeTxnV3.InvokeTxnV3, chainID, remoteSigner,
+		)
 
 		require.Zero(t, res)
 		expectedErrorMsg := fmt.Sprintf("server error %d: %s", http.StatusInternalServerError, serverError)
@@ -407,7 +679,12 @@ func TestHashAndSignTx(t *testing.T) {
 			rpc.ResourceBoundsMapping{},
 		)
 		chainID := new(felt.Felt).SetUint64(1)
-		res, err := signer.HashAndSignTx(&invokeTxnV3.InvokeTxnV3, chainID, mockServer.URL)
+		remoteSigner := signer.NewRemoteSigner(
+			mockServer.URL, s.Auth{}, nil, signer.RemoteSignerOptions{},
+		)
+		res, err := signer.HashAndSignTx(
+			t.Context(), &invokeTxnV3.InvokeTxnV3, chainID, remoteSigner,
+		)
 
 		require.Zero(t, res)
 		require.ErrorContains(t, err, "invalid character")
@@ -432,10 +709,15 @@ func TestHashAndSignTx(t *testing.T) {
 			rpc.ResourceBoundsMapping{},
 		)
 		chainID := new(felt.Felt).SetUint64(1)
-		res, err := signer.HashAndSignTx(&invokeTxnV3.InvokeTxnV3, chainID, mockServer.URL)
+		remoteSigner := signer.NewRemoteSigner(
+			mockServer.URL, s.Auth{}, nil, signer.RemoteSignerOptions{},
+		)
+		res, err := signer.HashAndSignTx(
+			t.Context(), &invokeTxnV3.InvokeTxnV3, chainID, remoteSigner,
+		)
 
-		expectedResult := s.Response{
-			Signature: [2]*felt.Felt{
+		expectedResult := s.ResponseV2{
+			Signature: []*felt.Felt{
 				new(felt.Felt).SetUint64(0x123),
 				new(felt.Felt).SetUint64(0x456),
 			},
@@ -443,4 +725,538 @@ func TestHashAndSignTx(t *testing.T) {
 		require.NoError(t, err)
 		require.Equal(t, expectedResult, res)
 	})
+	t.Run("Credentials are sent with the request", func(t *testing.T) {
+		tests := []struct {
+			auth         s.Auth
+			checkRequest func(t *testing.T, r *http.Request)
+		}{
+			{
+				auth: s.Auth{Scheme: s.AuthBearer, Secret: "some secret"},
+				checkRequest: func(t *testing.T, r *http.Request) {
+					t.Helper()
+					require.Equal(t, "Bearer some secret", r.Header.Get("Authorization"))
+				},
+			},
+			{
+				auth: s.Auth{Scheme: s.AuthHMAC, Secret: "some secret"},
+				checkRequest: func(t *testing.T, r *http.Request) {
+					t.Helper()
+					require.Empty(t, r.Header.Get("Authorization"))
+					timestamp := r.Header.Get(s.TIMESTAMP_HEADER)
+					require.NotEmpty(t, timestamp)
+
+					// The method and path of the request are signed along with its body
+					body, err := io.ReadAll(r.Body)
+					require.NoError(t, err)
+					mac := hmac.New(sha256.New, []byte("some secret"))
+					mac.Write([]byte(timestamp + "\nPOST\n" + s.SIGN_ENDPOINT + "\n"))
+					mac.Write(body)
+					require.Equal(
+						t, hex.EncodeToString(mac.Sum(nil)), r.Header.Get(s.SIGNATURE_HEADER),
+					)
+				},
+			},
+		}
+		for _, test := range tests {
+			mockServer := httptest.NewServer(
+				http.HandlerFunc(
+					func(w http.ResponseWriter, r *http.Request) {
+						test.checkRequest(t, r)
+						w.WriteHeader(http.StatusOK)
+						_, err := w.Write([]byte(`{"signature": ["0x123", "0x456"]}`))
+						require.NoError(t, err)
+					}))
+
+			invokeTxnV3 := snUtils.BuildInvokeTxn(
+				utils.HexToFelt(t, "0x123"),
+				new(felt.Felt).SetUint64(1),
+				[]*felt.Felt{},
+				rpc.ResourceBoundsMapping{},
+			)
+			chainID := new(felt.Felt).SetUint64(1)
+			remoteSigner := signer.NewRemoteSigner(
+				mockServer.URL, test.auth, nil, signer.RemoteSignerOptions{},
+			)
+			_, err := signer.HashAndSignTx(
+				t.Context(), &invokeTxnV3.InvokeTxnV3, chainID, remoteSigner,
+			)
+			mockServer.Close()
+
+			require.NoError(t, err)
+		}
+	})
+}
+
+func TestRemoteSignerProtocols(t *testing.T) {
+	invokeTxn := func() *rpc.InvokeTxnV3 {
+		return &rpc.InvokeTxnV3{
+			Type:          rpc.TransactionType_Invoke,
+			SenderAddress: new(felt.Felt).SetUint64(0xabc),
+			Calldata:      []*felt.Felt{new(felt.Felt).SetUint64(0xcba)},
+			Version:       rpc.TransactionV3,
+			Signature:     []*felt.Felt{},
+			Nonce:         utils.HexToFelt(t, "0x1"),
+			ResourceBounds: rpc.ResourceBoundsMapping{
+				L1Gas:     rpc.ResourceBounds{MaxAmount: "0x1", MaxPricePerUnit: "0x1"},
+				L2Gas:     rpc.ResourceBounds{MaxAmount: "0x1", MaxPricePerUnit: "0x1"},
+				L1DataGas: rpc.ResourceBounds{MaxAmount: "0x1", MaxPricePerUnit: "0x1"},
+			},
+			Tip:                   "0x0",
+			PayMasterData:         []*felt.Felt{},
+			AccountDeploymentData: []*felt.Felt{},
+			NonceDataMode:         rpc.DAModeL1,
+			FeeMode:               rpc.DAModeL1,
+		}
+	}
+	chainID := new(felt.Felt).SetUint64(1)
+	txHash, err := hash.TransactionHashInvokeV3(invokeTxn(), chainID)
+	require.NoError(t, err)
+
+	// Answers every request with `response`, recording the path and body of the last one
+	var path string
+	var reqBody map[string]any
+	mockSigner := func(t *testing.T, response string) *httptest.Server {
+		t.Helper()
+
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			path = r.URL.Path
+			reqBody = nil
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&reqBody))
+			_, err := w.Write([]byte(response))
+			require.NoError(t, err)
+		}))
+	}
+
+	t.Run("Unversioned and v1 endpoints", func(t *testing.T) {
+		server := mockSigner(t, `{"signature": ["0x1", "0x2"]}`)
+		defer server.Close()
+
+		for protocol, expectedPath := range map[string]string{
+			"":            s.SIGN_ENDPOINT,
+			s.PROTOCOL_V1: s.SIGN_V1_ENDPOINT,
+		} {
+			remoteSigner := signer.NewRemoteSigner(
+				server.URL, s.Auth{}, nil, signer.RemoteSignerOptions{Protocol: protocol},
+			)
+			_, err := signer.HashAndSignTx(t.Context(), invokeTxn(), chainID, remoteSigner)
+			require.NoError(t, err)
+			require.Equal(t, expectedPath, path)
+			require.Contains(t, reqBody, "transaction")
+			require.Contains(t, reqBody, "chain_id")
+		}
+	})
+
+	t.Run("v2 transaction mode", func(t *testing.T) {
+		server := mockSigner(
+			t, fmt.Sprintf(`{"hash": "%s", "signature": ["0x1", "0x2", "0x3"]}`, txHash),
+		)
+		defer server.Close()
+
+		remoteSigner := signer.NewRemoteSigner(
+			server.URL,
+			s.Auth{},
+			nil,
+			signer.RemoteSignerOptions{Protocol: s.PROTOCOL_V2, SignMode: s.SIGN_MODE_TRANSACTION},
+		)
+		res, err := signer.HashAndSignTx(t.Context(), invokeTxn(), chainID, remoteSigner)
+		require.NoError(t, err)
+		require.Equal(t, s.SIGN_V2_ENDPOINT, path)
+		require.Equal(t, s.SIGN_MODE_TRANSACTION, reqBody["mode"])
+		require.Contains(t, reqBody, "transaction")
+		require.NotContains(t, reqBody, "hash")
+		require.Equal(t, txHash, res.Hash)
+		require.Len(t, res.Signature, 3)
+	})
+
+	t.Run("v2 hash mode", func(t *testing.T) {
+		server := mockSigner(t, `{"signature": ["0x1", "0x2"]}`)
+		defer server.Close()
+
+		remoteSigner := signer.NewRemoteSigner(
+			server.URL,
+			s.Auth{},
+			nil,
+			signer.RemoteSignerOptions{Protocol: s.PROTOCOL_V2, SignMode: s.SIGN_MODE_HASH},
+		)
+		_, err := signer.HashAndSignTx(t.Context(), invokeTxn(), chainID, remoteSigner)
+		require.NoError(t, err)
+		require.Equal(t, s.SIGN_V2_ENDPOINT, path)
+		require.Equal(t, s.SIGN_MODE_HASH, reqBody["mode"])
+		require.Equal(t, txHash.String(), reqBody["hash"])
+		require.Equal(t, "0xabc", reqBody["sender_address"])
+		require.NotContains(t, reqBody, "transaction")
+	})
+
+	t.Run("v2 signer hashing to something else", func(t *testing.T) {
+		server := mockSigner(t, `{"hash": "0x1234", "signature": ["0x1", "0x2"]}`)
+		defer server.Close()
+
+		remoteSigner := signer.NewRemoteSigner(
+			server.URL, s.Auth{}, nil, signer.RemoteSignerOptions{Protocol: s.PROTOCOL_V2},
+		)
+		_, err := signer.HashAndSignTx(t.Context(), invokeTxn(), chainID, remoteSigner)
+		require.EqualError(t, err, fmt.Sprintf(
+			"external signer signed hash 0x1234 but the transaction hash is %s", txHash,
+		))
+	})
+
+	t.Run("Signatures of other lengths are only accepted from v2 signers allowing it", func(t *testing.T) {
+		server := mockSigner(t, `{"signature": ["0x1", "0x2", "0x3"]}`)
+		defer server.Close()
+
+		// Verified, and refused, unless the signer is allowed to skip verification
+		for _, options := range []signer.RemoteSignerOptions{
+			{Protocol: s.PROTOCOL_V1},
+			{Protocol: s.PROTOCOL_V2},
+		} {
+			txn := invokeTxn()
+			remoteSigner := signer.NewRemoteSigner(server.URL, s.Auth{}, nil, options)
+			err := signer.SignInvokeTx(
+				t.Context(), txn, chainID, validator.MockPublicKey(t), remoteSigner,
+			)
+			var invalidSignature *signer.InvalidSignatureError
+			require.ErrorAs(t, err, &invalidSignature, options.Protocol)
+			require.Empty(t, txn.Signature, options.Protocol)
+		}
+
+		txn := invokeTxn()
+		remoteSigner := signer.NewRemoteSigner(
+			server.URL,
+			s.Auth{},
+			nil,
+			signer.RemoteSignerOptions{Protocol: s.PROTOCOL_V2, AllowUnverifiedSignatures: true},
+		)
+		err := signer.SignInvokeTx(
+			t.Context(), txn, chainID, validator.MockPublicKey(t), remoteSigner,
+		)
+		require.NoError(t, err)
+		require.Equal(t, []*felt.Felt{
+			new(felt.Felt).SetUint64(1), new(felt.Felt).SetUint64(2), new(felt.Felt).SetUint64(3),
+		}, txn.Signature)
+	})
+
+	t.Run("Two value signatures are always verified", func(t *testing.T) {
+		server := mockSigner(t, `{"signature": ["0x1", "0x2"]}`)
+		defer server.Close()
+
+		txn := invokeTxn()
+		remoteSigner := signer.NewRemoteSigner(
+			server.URL,
+			s.Auth{},
+			nil,
+			signer.RemoteSignerOptions{Protocol: s.PROTOCOL_V2, AllowUnverifiedSignatures: true},
+		)
+		err := signer.SignInvokeTx(
+			t.Context(), txn, chainID, validator.MockPublicKey(t), remoteSigner,
+		)
+		var invalidSignature *signer.InvalidSignatureError
+		require.ErrorAs(t, err, &invalidSignature)
+		require.Empty(t, txn.Signature)
+	})
+}
+
+func TestRemoteSignerRetries(t *testing.T) {
+	signer.RetryBackoff = 0
+	defer func() { signer.RetryBackoff = constants.SIGNER_RETRY_BACKOFF }()
+
+	invokeTxnV3 := snUtils.BuildInvokeTxn(
+		utils.HexToFelt(t, "0x123"),
+		new(felt.Felt).SetUint64(1),
+		[]*felt.Felt{},
+		rpc.ResourceBoundsMapping{},
+	)
+	chainID := new(felt.Felt).SetUint64(1)
+	metricsServer := metrics.NewMockMetricsForTest(utils.NewNopZapLogger())
+
+	// Answers the first `failures` requests with `status` and then signs
+	newMockServer := func(t *testing.T, failures int, status int, calls *int) *httptest.Server {
+		t.Helper()
+
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			*calls++
+			if *calls <= failures {
+				http.Error(w, "failed", status)
+				return
+			}
+			_, err := w.Write([]byte(`{"signature": ["0x123", "0x456"]}`))
+			require.NoError(t, err)
+		}))
+	}
+
+	t.Run("Server errors are retried", func(t *testing.T) {
+		calls := 0
+		mockServer := newMockServer(t, 2, http.StatusBadGateway, &calls)
+		defer mockServer.Close()
+
+		remoteSigner := signer.NewRemoteSigner(
+			mockServer.URL,
+			s.Auth{},
+			nil,
+			signer.RemoteSignerOptions{MaxRetries: 3, Metrics: metricsServer, Network: "SN_SEPOLIA"},
+		)
+		res, err := signer.HashAndSignTx(
+			t.Context(), &invokeTxnV3.InvokeTxnV3, chainID, remoteSigner,
+		)
+		require.NoError(t, err)
+		require.Equal(t, new(felt.Felt).SetUint64(0x123), res.Signature[0])
+		require.Equal(t, 3, calls)
+	})
+
+	t.Run("Error after the max retries", func(t *testing.T) {
+		calls := 0
+		mockServer := newMockServer(t, 10, http.StatusServiceUnavailable, &calls)
+		defer mockServer.Close()
+
+		remoteSigner := signer.NewRemoteSigner(
+			mockServer.URL, s.Auth{}, nil, signer.RemoteSignerOptions{MaxRetries: 2},
+		)
+		res, err := signer.HashAndSignTx(
+			t.Context(), &invokeTxnV3.InvokeTxnV3, chainID, remoteSigner,
+		)
+		require.Zero(t, res)
+		require.EqualError(t, err, "server error 503: failed")
+		require.Equal(t, 3, calls)
+	})
+
+	t.Run("Rejected requests are not retried", func(t *testing.T) {
+		calls := 0
+		mockServer := newMockServer(t, 10, http.StatusForbidden, &calls)
+		defer mockServer.Close()
+
+		remoteSigner := signer.NewRemoteSigner(
+			mockServer.URL, s.Auth{}, nil, signer.RemoteSignerOptions{MaxRetries: 3},
+		)
+		_, err := signer.HashAndSignTx(
+			t.Context(), &invokeTxnV3.InvokeTxnV3, chainID, remoteSigner,
+		)
+		require.EqualError(t, err, "server error 403: failed")
+		require.Equal(t, 1, calls)
+	})
+
+	t.Run("Requests taking too long time out and are retried", func(t *testing.T) {
+		calls := 0
+		mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls++
+			select {
+			case <-r.Context().Done():
+			case <-time.After(200 * time.Millisecond):
+			}
+		}))
+		defer mockServer.Close()
+
+		remoteSigner := signer.NewRemoteSigner(
+			mockServer.URL,
+			s.Auth{},
+			nil,
+			signer.RemoteSignerOptions{Timeout: 50 * time.Millisecond, MaxRetries: 1},
+		)
+		_, err := signer.HashAndSignTx(
+			t.Context(), &invokeTxnV3.InvokeTxnV3, chainID, remoteSigner,
+		)
+		require.ErrorContains(t, err, "Client.Timeout exceeded")
+		require.Equal(t, 2, calls)
+	})
+
+	t.Run("Retries stop when the context is done", func(t *testing.T) {
+		signer.RetryBackoff = time.Hour
+		defer func() { signer.RetryBackoff = 0 }()
+
+		ctx, cancel := context.WithCancel(t.Context())
+		calls := 0
+		mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls++
+			cancel()
+			http.Error(w, "failed", http.StatusInternalServerError)
+		}))
+		defer mockServer.Close()
+
+		remoteSigner := signer.NewRemoteSigner(
+			mockServer.URL, s.Auth{}, nil, signer.RemoteSignerOptions{MaxRetries: 3},
+		)
+		_, err := signer.HashAndSignTx(ctx, &invokeTxnV3.InvokeTxnV3, chainID, remoteSigner)
+		require.ErrorIs(t, err, context.Canceled)
+		require.Equal(t, 1, calls)
+	})
+}
+
+func TestRemoteSignerFailover(t *testing.T) {
+	signer.RetryBackoff = 0
+	defer func() { signer.RetryBackoff = constants.SIGNER_RETRY_BACKOFF }()
+
+	invokeTxnV3 := snUtils.BuildInvokeTxn(
+		utils.HexToFelt(t, "0x123"),
+		new(felt.Felt).SetUint64(1),
+		[]*felt.Felt{},
+		rpc.ResourceBoundsMapping{},
+	)
+	chainID := new(felt.Felt).SetUint64(1)
+
+	// Answers signing requests with `status`, or signs with `signature` when it's 200
+	newMockServer := func(
+		t *testing.T, status *int, signature string, calls *int,
+	) *httptest.Server {
+		t.Helper()
+
+		return mockSignerServer(t, func(w http.ResponseWriter, r *http.Request) {
+			*calls++
+			if *status != http.StatusOK {
+				http.Error(w, "failed", *status)
+				return
+			}
+			_, err := fmt.Fprintf(w, `{"signature": ["%s", "0x456"]}`, signature)
+			require.NoError(t, err)
+		})
+	}
+
+	t.Run("Signers are tried in order", func(t *testing.T) {
+		primaryStatus, fallbackStatus := http.StatusBadGateway, http.StatusOK
+		primaryCalls, fallbackCalls := 0, 0
+		primary := newMockServer(t, &primaryStatus, "0x1", &primaryCalls)
+		defer primary.Close()
+		fallback := newMockServer(t, &fallbackStatus, "0x2", &fallbackCalls)
+		defer fallback.Close()
+
+		remoteSigner := signer.NewRemoteSigner(
+			primary.URL,
+			s.Auth{},
+			nil,
+			signer.RemoteSignerOptions{FallbackURLs: []string{fallback.URL}},
+		)
+		res, err := signer.HashAndSignTx(
+			t.Context(), &invokeTxnV3.InvokeTxnV3, chainID, remoteSigner,
+		)
+		require.NoError(t, err)
+		require.Equal(t, new(felt.Felt).SetUint64(0x2), res.Signature[0])
+		require.Equal(t, 1, primaryCalls)
+		require.Equal(t, 1, fallbackCalls)
+
+		primaryStatus = http.StatusOK
+		res, err = signer.HashAndSignTx(
+			t.Context(), &invokeTxnV3.InvokeTxnV3, chainID, remoteSigner,
+		)
+		require.NoError(t, err)
+		require.Equal(t, new(felt.Felt).SetUint64(0x1), res.Signature[0])
+		require.Equal(t, 2, primaryCalls)
+		require.Equal(t, 1, fallbackCalls)
+	})
+
+	t.Run("Failing signer is skipped until the cooldown is over", func(t *testing.T) {
+		primaryStatus, fallbackStatus := http.StatusServiceUnavailable, http.StatusOK
+		primaryCalls, fallbackCalls := 0, 0
+		primary := newMockServer(t, &primaryStatus, "0x1", &primaryCalls)
+		defer primary.Close()
+		fallback := newMockServer(t, &fallbackStatus, "0x2", &fallbackCalls)
+		defer fallback.Close()
+
+		remoteSigner := signer.NewRemoteSigner(
+			primary.URL,
+			s.Auth{},
+			nil,
+			signer.RemoteSignerOptions{FallbackURLs: []string{fallback.URL}},
+		)
+		sign := func() {
+			t.Helper()
+
+			res, err := signer.HashAndSignTx(
+				t.Context(), &invokeTxnV3.InvokeTxnV3, chainID, remoteSigner,
+			)
+			require.NoError(t, err)
+			require.Equal(t, new(felt.Felt).SetUint64(0x2), res.Signature[0])
+		}
+
+		for range constants.SIGNER_CIRCUIT_BREAKER_THRESHOLD + 2 {
+			sign()
+		}
+		require.Equal(t, constants.SIGNER_CIRCUIT_BREAKER_THRESHOLD, primaryCalls)
+		require.Equal(t, constants.SIGNER_CIRCUIT_BREAKER_THRESHOLD+2, fallbackCalls)
+
+		// Once the cooldown is over, a single failure skips the signer again
+		signer.CircuitBreakerCooldown = 0
+		sign()
+		signer.CircuitBreakerCooldown = constants.SIGNER_CIRCUIT_BREAKER_COOLDOWN
+		sign()
+		require.Equal(t, constants.SIGNER_CIRCUIT_BREAKER_THRESHOLD+1, primaryCalls)
+	})
+
+	t.Run("Skipped signers are tried when every other one fails", func(t *testing.T) {
+		primaryStatus, fallbackStatus := http.StatusServiceUnavailable, http.StatusBadGateway
+		primaryCalls, fallbackCalls := 0, 0
+		primary := newMockServer(t, &primaryStatus, "0x1", &primaryCalls)
+		defer primary.Close()
+		fallback := newMockServer(t, &fallbackStatus, "0x2", &fallbackCalls)
+		defer fallback.Close()
+
+		remoteSigner := signer.NewRemoteSigner(
+			primary.URL,
+			s.Auth{},
+			nil,
+			signer.RemoteSignerOptions{
+				FallbackURLs: []string{fallback.URL},
+				MaxRetries:   constants.SIGNER_CIRCUIT_BREAKER_THRESHOLD,
+			},
+		)
+		_, err := signer.HashAndSignTx(
+			t.Context(), &invokeTxnV3.InvokeTxnV3, chainID, remoteSigner,
+		)
+		require.ErrorContains(t, err, "server error 502: failed")
+		require.Equal(t, constants.SIGNER_CIRCUIT_BREAKER_THRESHOLD+1, primaryCalls)
+		require.Equal(t, constants.SIGNER_CIRCUIT_BREAKER_THRESHOLD+1, fallbackCalls)
+
+		primaryStatus = http.StatusOK
+		res, err := signer.HashAndSignTx(
+			t.Context(), &invokeTxnV3.InvokeTxnV3, chainID, remoteSigner,
+		)
+		require.NoError(t, err)
+		require.Equal(t, new(felt.Felt).SetUint64(0x1), res.Signature[0])
+	})
+
+	t.Run("Rejected requests are not sent to the other signers", func(t *testing.T) {
+		primaryStatus, fallbackStatus := http.StatusForbidden, http.StatusOK
+		primaryCalls, fallbackCalls := 0, 0
+		primary := newMockServer(t, &primaryStatus, "0x1", &primaryCalls)
+		defer primary.Close()
+		fallback := newMockServer(t, &fallbackStatus, "0x2", &fallbackCalls)
+		defer fallback.Close()
+
+		remoteSigner := signer.NewRemoteSigner(
+			primary.URL,
+			s.Auth{},
+			nil,
+			signer.RemoteSignerOptions{FallbackURLs: []string{fallback.URL}},
+		)
+		_, err := signer.HashAndSignTx(
+			t.Context(), &invokeTxnV3.InvokeTxnV3, chainID, remoteSigner,
+		)
+		require.ErrorContains(t, err, "server error 403: failed")
+		require.Equal(t, 1, primaryCalls)
+		require.Zero(t, fallbackCalls)
+	})
+
+	t.Run("Every signer must hold the same key", func(t *testing.T) {
+		sign := func(w http.ResponseWriter, r *http.Request) {}
+		primary := mockSignerServer(t, sign)
+		defer primary.Close()
+		other := validator.MockSignerServer(t, new(felt.Felt).SetUint64(0x789), sign)
+		defer other.Close()
+
+		remoteSigner := signer.NewRemoteSigner(
+			primary.URL,
+			s.Auth{},
+			nil,
+			signer.RemoteSignerOptions{FallbackURLs: []string{"http://localhost:1234", other.URL}},
+		)
+		publicKey, err := remoteSigner.PublicKey(t.Context(), nil)
+		require.Nil(t, publicKey)
+		require.ErrorContains(t, err, "sign with different keys")
+
+		remoteSigner = signer.NewRemoteSigner(
+			"http://localhost:1234",
+			s.Auth{},
+			nil,
+			signer.RemoteSignerOptions{FallbackURLs: []string{primary.URL}},
+		)
+		publicKey, err = remoteSigner.PublicKey(t.Context(), nil)
+		require.NoError(t, err)
+		require.Equal(t, validator.MockPublicKey(t), publicKey)
+	})
 }
@@ -11,6 +11,7 @@ import (
 	"github.com/NethermindEth/starknet.go/account"
 	"github.com/NethermindEth/starknet.go/curve"
 	"github.com/NethermindEth/starknet.go/rpc"
+	"github.com/NethermindEth/starknet.go/utils"
 	"github.com/cockroachdb/errors"
 )
 
@@ -19,15 +20,20 @@ var _ Signer = (*InternalSigner)(nil)
 type InternalSigner struct {
 	Account             account.Account
 	validationContracts ValidationContracts
+	lastInvokeTxn       *sentInvokeTxn
 }
 
 func NewInternalSigner(
-	provider *rpc.Provider,
+	provider rpc.RpcProvider,
 	logger *junoUtils.ZapLogger,
 	signer *config.Signer,
 	addresses *config.ContractAddresses,
 ) (InternalSigner, error) {
-	privateKey, ok := new(big.Int).SetString(signer.PrivKey, 0)
+	privateKeyStr, err := signer.PrivateKey()
+	if err != nil {
+		return InternalSigner{}, err
+	}
+	privateKey, ok := new(big.Int).SetString(privateKeyStr, 0)
 	if !ok {
 		return InternalSigner{},
 			errors.Errorf("cannot turn private key %s into a big int", privateKey)
@@ -51,6 +57,16 @@ func NewInternalSigner(
 	if err != nil {
 		return InternalSigner{}, err
 	}
+	if err := checkOperationalAccountKey(
+		context.Background(),
+		provider,
+		accountAddr.Felt(),
+		new(felt.Felt).SetBigInt(publicKey),
+		logger,
+	); err != nil {
+		return InternalSigner{}, err
+	}
+
 	validationContracts := types.ValidationContractsFromAddresses(
 		addresses.SetDefaults(chainIdStr),
 	)
@@ -69,12 +85,114 @@ func (v *InternalSigner) GetTransactionStatus(
 	return v.Account.Provider.GetTransactionStatus(ctx, transactionHash)
 }
 
-func (v *InternalSigner) BuildAndSendInvokeTxn(
+func (v *InternalSigner) EstimateInvokeTxnFee(
 	ctx context.Context,
 	functionCalls []rpc.InvokeFunctionCall,
 	multiplier float64,
+) (rpc.ResourceBoundsMapping, error) {
+	// The txn needs a signature to estimate the fee
+	broadcastInvokeTxnV3, err := v.buildInvokeTxn(
+		ctx, functionCalls, makeResourceBoundsMapWithZeroValues(),
+	)
+	if err != nil {
+		return rpc.ResourceBoundsMapping{}, err
+	}
+
+	estimateFee, err := v.Account.Provider.EstimateFee(
+		ctx,
+		[]rpc.BroadcastTxn{broadcastInvokeTxnV3},
+		[]rpc.SimulationFlag{},
+		rpc.WithBlockTag("pending"),
+	)
+	if err != nil {
+		return rpc.ResourceBoundsMapping{}, err
+	}
+
+	return utils.FeeEstToResBoundsMap(estimateFee[0], multiplier), nil
+}
+
+func (v *InternalSigner) BuildAndSendInvokeTxn(
+	ctx context.Context,
+	functionCalls []rpc.InvokeFunctionCall,
+	resourceBounds *rpc.ResourceBoundsMapping,
 ) (*rpc.AddInvokeTransactionResponse, error) {
-	return v.Account.BuildAndSendInvokeTxn(ctx, functionCalls, multiplier, false)
+	broadcastInvokeTxnV3, err := v.buildInvokeTxn(ctx, functionCalls, *resourceBounds)
+	if err != nil {
+		return nil, err
+	}
+
+	return v.sendInvokeTxn(ctx, broadcastInvokeTxnV3)
+}
+
+func (v *InternalSigner) ReplaceInvokeTxn(
+	ctx context.Context,
+	transactionHash *felt.Felt,
+	bumpPercentage uint64,
+	maxFee *felt.Felt,
+) (*rpc.AddInvokeTransactionResponse, *felt.Felt, error) {
+	broadcastInvokeTxnV3, err := invokeTxnToReplace(
+		ctx, v.Account.Provider, v.lastInvokeTxn, transactionHash,
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := BumpInvokeTxnFees(&broadcastInvokeTxnV3.InvokeTxnV3, bumpPercentage); err != nil {
+		return nil, nil, err
+	}
+	replacementMaxFee, err := checkMaxFee(&broadcastInvokeTxnV3.InvokeTxnV3, maxFee)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// The fees are part of the txn hash, so it has to be signed again
+	if err := v.Account.SignInvokeTransaction(ctx, &broadcastInvokeTxnV3.InvokeTxnV3); err != nil {
+		return nil, nil, err
+	}
+
+	resp, err := v.sendInvokeTxn(ctx, &broadcastInvokeTxnV3)
+	if err != nil {
+		return nil, nil, err
+	}
+	return resp, replacementMaxFee, nil
+}
+
+// Builds the invoke txn with the account's next nonce and signs it
+func (v *InternalSigner) buildInvokeTxn(
+	ctx context.Context,
+	functionCalls []rpc.InvokeFunctionCall,
+	resourceBounds rpc.ResourceBoundsMapping,
+) (*rpc.BroadcastInvokeTxnV3, error) {
+	nonce, err := v.Account.Nonce(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	callData, err := v.Account.FmtCalldata(utils.InvokeFuncCallsToFunctionCalls(functionCalls))
+	if err != nil {
+		return nil, err
+	}
+
+	broadcastInvokeTxnV3 := utils.BuildInvokeTxn(
+		v.Account.Address, nonce, callData, resourceBounds,
+	)
+	if err := v.Account.SignInvokeTransaction(ctx, &broadcastInvokeTxnV3.InvokeTxnV3); err != nil {
+		return nil, err
+	}
+
+	return broadcastInvokeTxnV3, nil
+}
+
+func (v *InternalSigner) sendInvokeTxn(
+	ctx context.Context, broadcastInvokeTxnV3 *rpc.BroadcastInvokeTxnV3,
+) (*rpc.AddInvokeTransactionResponse, error) {
+	resp, err := v.Account.Provider.AddInvokeTransaction(ctx, broadcastInvokeTxnV3)
+	if err != nil {
+		return nil, err
+	}
+
+	v.lastInvokeTxn = &sentInvokeTxn{hash: *resp.TransactionHash, txn: *broadcastInvokeTxnV3}
+	return resp, nil
 }
 
 func (v *InternalSigner) Call(
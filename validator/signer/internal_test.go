@@ -1,6 +1,7 @@
 package signer_test
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
@@ -21,6 +22,8 @@ import (
 	"github.com/NethermindEth/starknet-staking-v2/validator/signer"
 	"github.com/NethermindEth/starknet-staking-v2/validator/types"
 	"github.com/NethermindEth/starknet.go/account"
+	"github.com/NethermindEth/starknet.go/curve"
+	"github.com/NethermindEth/starknet.go/hash"
 	"github.com/NethermindEth/starknet.go/rpc"
 	snGoUtils "github.com/NethermindEth/starknet.go/utils"
 	"github.com/stretchr/testify/require"
@@ -57,11 +60,10 @@ func TestNewInternalSigner(t *testing.T) {
 		require.Equal(t, expectedErrorMsg, err.Error())
 	})
 	t.Run("Successful account creation", func(t *testing.T) {
-		if !loadedEnvVars {
-			t.Skipf("couldn't load env vars: %s", envVarsErr.Error())
-		}
+		mockRpc := createMockRPCServer(t, nil)
+		defer mockRpc.Close()
 
-		provider, err := rpc.NewProvider(envVars.HttpProviderUrl)
+		provider, err := rpc.NewProvider(mockRpc.URL)
 		require.NoError(t, err)
 
 		configSigner := config.Signer{
@@ -113,6 +115,133 @@ func TestNewInternalSigner(t *testing.T) {
 		require.Equal(t, signer.InternalSigner{}, validatorAccount)
 		require.ErrorContains(t, err, "cannot create validator account:")
 	})
+
+	t.Run("Error: private key doesn't control the account", func(t *testing.T) {
+		mockRpc := createMockRPCServer(t, nil)
+		defer mockRpc.Close()
+
+		provider, err := rpc.NewProvider(mockRpc.URL)
+		require.NoError(t, err)
+
+		validatorAccount, err := signer.NewInternalSigner(
+			provider,
+			logger,
+			&config.Signer{
+				PrivKey:            "0x456",
+				OperationalAddress: "0x789",
+			},
+			contractAddresses,
+		)
+
+		require.Equal(t, signer.InternalSigner{}, validatorAccount)
+		require.ErrorIs(t, err, signer.ErrAccountKeyMismatch)
+		require.ErrorContains(
+			t, err, "account 0x789 is controlled by "+validator.MockPublicKey(t).String(),
+		)
+	})
+
+	t.Run("Account not exposing its public key is not checked", func(t *testing.T) {
+		mockRpc := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var req validator.Method
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+
+			switch req.Name {
+			case "starknet_chainId":
+				_, err := w.Write([]byte(`{"jsonrpc": "2.0", "result": "0x1", "id": 1}`))
+				require.NoError(t, err)
+			case "starknet_call":
+				_, err := w.Write([]byte(
+					`{"jsonrpc": "2.0", "error": {"code": 21, "message": "Invalid message selector"}, "id": 1}`,
+				))
+				require.NoError(t, err)
+			default:
+				http.Error(w, "Should not get here", http.StatusMethodNotAllowed)
+			}
+		}))
+		defer mockRpc.Close()
+
+		provider, err := rpc.NewProvider(mockRpc.URL)
+		require.NoError(t, err)
+
+		validatorAccount, err := signer.NewInternalSigner(
+			provider,
+			logger,
+			&config.Signer{
+				PrivKey:            "0x456",
+				OperationalAddress: "0x789",
+			},
+			contractAddresses,
+		)
+
+		require.NoError(t, err)
+		require.Equal(t, "0x789", validatorAccount.Address().String())
+	})
+}
+
+func TestAccountPublicKey(t *testing.T) {
+	accountAddress := utils.HexToFelt(t, "0x456")
+	publicKey := utils.HexToFelt(t, "0x789")
+
+	// Mocks an account contract only exposing `entryPoint`
+	mockAccount := func(t *testing.T, entryPoint string) *httptest.Server {
+		t.Helper()
+
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var req struct {
+				Params []json.RawMessage `json:"params"`
+			}
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			var fnCall rpc.FunctionCall
+			require.NoError(t, json.Unmarshal(req.Params[0], &fnCall))
+			require.Equal(t, accountAddress, fnCall.ContractAddress)
+
+			selector := snGoUtils.GetSelectorFromNameFelt(entryPoint)
+			if !fnCall.EntryPointSelector.Equal(selector) {
+				_, err := w.Write([]byte(
+					`{"jsonrpc": "2.0", "error": {"code": 21, "message": "Invalid message selector"}, "id": 1}`,
+				))
+				require.NoError(t, err)
+				return
+			}
+			_, err := fmt.Fprintf(w, `{"jsonrpc": "2.0", "result": ["%s"], "id": 1}`, publicKey)
+			require.NoError(t, err)
+		}))
+	}
+
+	for _, entryPoint := range []string{"get_public_key", "getPublicKey", "get_owner", "getSigner"} {
+		t.Run("Read with "+entryPoint, func(t *testing.T) {
+			mockRpc := mockAccount(t, entryPoint)
+			defer mockRpc.Close()
+
+			provider, err := rpc.NewProvider(mockRpc.URL)
+			require.NoError(t, err)
+
+			accountPublicKey, err := signer.AccountPublicKey(t.Context(), provider, accountAddress)
+			require.NoError(t, err)
+			require.Equal(t, publicKey, accountPublicKey)
+
+			require.NoError(
+				t, signer.CheckAccountPublicKey(t.Context(), provider, accountAddress, publicKey),
+			)
+		})
+	}
+
+	t.Run("Error: no entry point returns the public key", func(t *testing.T) {
+		mockRpc := mockAccount(t, "get_signers")
+		defer mockRpc.Close()
+
+		provider, err := rpc.NewProvider(mockRpc.URL)
+		require.NoError(t, err)
+
+		accountPublicKey, err := signer.AccountPublicKey(t.Context(), provider, accountAddress)
+		require.Nil(t, accountPublicKey)
+		require.ErrorContains(
+			t,
+			err,
+			"cannot read the public key of account 0x456, tried entry points"+
+				" get_public_key, getPublicKey, get_owner, getSigner",
+		)
+	})
 }
 
 func createMockRPCServer(
@@ -139,6 +268,15 @@ func createMockRPCServer(
 			w.WriteHeader(http.StatusOK)
 			_, err := w.Write([]byte(`{"jsonrpc": "2.0", "result": "0x2", "id": 1}`))
 			require.NoError(t, err)
+		case "starknet_call":
+			// Only the public key of the account is read
+			w.WriteHeader(http.StatusOK)
+			_, err := fmt.Fprintf(
+				w,
+				`{"jsonrpc": "2.0", "result": ["%s"], "id": 1}`,
+				validator.MockPublicKey(t),
+			)
+			require.NoError(t, err)
 		case "starknet_estimateFee":
 			mockFeeEstimate := []rpc.FeeEstimation{{
 				L1GasConsumed:     utils.HexToFelt(t, "0x123"),
@@ -160,6 +298,7 @@ func createMockRPCServer(
 			)
 			require.NoError(t, err)
 		case "starknet_addInvokeTransaction":
+			r.Body = io.NopCloser(bytes.NewReader(bodyBytes))
 			addInvoke(w, r)
 		default:
 			w.WriteHeader(http.StatusMethodNotAllowed)
@@ -171,6 +310,238 @@ func createMockRPCServer(
 	return mockRpc
 }
 
+// Resource bounds resulting from the fee estimation answered by `createMockRPCServer`
+func mockedResourceBounds(t *testing.T) rpc.ResourceBoundsMapping {
+	t.Helper()
+
+	return snGoUtils.FeeEstToResBoundsMap(rpc.FeeEstimation{
+		L1GasConsumed:     utils.HexToFelt(t, "0x123"),
+		L1GasPrice:        utils.HexToFelt(t, "0x456"),
+		L2GasConsumed:     utils.HexToFelt(t, "0x123"),
+		L2GasPrice:        utils.HexToFelt(t, "0x456"),
+		L1DataGasConsumed: utils.HexToFelt(t, "0x123"),
+		L1DataGasPrice:    utils.HexToFelt(t, "0x456"),
+		OverallFee:        utils.HexToFelt(t, "0x123"),
+		FeeUnit:           rpc.UnitStrk,
+	}, constants.DEFAULT_FEE_ESTIMATION_MULTIPLIER)
+}
+
+// Answers every invoke transaction received with a different hash and keeps it in `sent`
+func recordAddInvoke(
+	t *testing.T, sent *[]rpc.InvokeTxnV3,
+) func(w http.ResponseWriter, r *http.Request) {
+	t.Helper()
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Params []rpc.InvokeTxnV3 `json:"params"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		require.Len(t, req.Params, 1)
+		*sent = append(*sent, req.Params[0])
+
+		w.WriteHeader(http.StatusOK)
+		_, err := fmt.Fprintf(
+			w,
+			`{"jsonrpc": "2.0", "result": {"transaction_hash": "0x%x"}, "id": 1}`,
+			0x788+len(*sent),
+		)
+		require.NoError(t, err)
+	}
+}
+
+// Checks `replacement` is `original` with its tip and resource prices raised by 20%
+func requireReplacement(t *testing.T, original, replacement *rpc.InvokeTxnV3) {
+	t.Helper()
+
+	require.Equal(t, original.Nonce, replacement.Nonce)
+	require.Equal(t, original.Calldata, replacement.Calldata)
+	require.Equal(t, original.SenderAddress, replacement.SenderAddress)
+	require.NotEqual(t, original.Signature, replacement.Signature)
+
+	expectedTxn := *original
+	require.NoError(t, signer.BumpInvokeTxnFees(&expectedTxn, 20))
+	require.Equal(t, expectedTxn.Tip, replacement.Tip)
+	require.Equal(t, expectedTxn.ResourceBounds, replacement.ResourceBounds)
+}
+
+func TestInternalSignerReplaceInvokeTxn(t *testing.T) {
+	logger := utils.NewNopZapLogger()
+	contractAddresses := new(config.ContractAddresses).SetDefaults("SN_SEPOLIA")
+	configSigner := config.Signer{
+		PrivKey:            "0x123",
+		OperationalAddress: "0x456",
+	}
+
+	t.Run("Error getting the transaction to replace", func(t *testing.T) {
+		mockRpc := createMockRPCServer(t, nil)
+		defer mockRpc.Close()
+
+		provider, err := rpc.NewProvider(mockRpc.URL)
+		require.NoError(t, err)
+
+		internalSigner, err := signer.NewInternalSigner(
+			provider, logger, &configSigner, contractAddresses,
+		)
+		require.NoError(t, err)
+
+		resp, _, err := internalSigner.ReplaceInvokeTxn(
+			t.Context(), utils.HexToFelt(t, "0x789"), 20, nil,
+		)
+
+		require.Nil(t, resp)
+		require.ErrorContains(t, err, "cannot get transaction 0x789")
+	})
+
+	t.Run("Sent transaction is replaced keeping its nonce", func(t *testing.T) {
+		var sent []rpc.InvokeTxnV3
+		mockRpc := createMockRPCServer(t, recordAddInvoke(t, &sent))
+		defer mockRpc.Close()
+
+		provider, err := rpc.NewProvider(mockRpc.URL)
+		require.NoError(t, err)
+
+		internalSigner, err := signer.NewInternalSigner(
+			provider, logger, &configSigner, contractAddresses,
+		)
+		require.NoError(t, err)
+
+		resourceBounds := mockedResourceBounds(t)
+		resp, err := internalSigner.BuildAndSendInvokeTxn(
+			t.Context(), []rpc.InvokeFunctionCall{}, &resourceBounds,
+		)
+		require.NoError(t, err)
+		require.Equal(t, utils.HexToFelt(t, "0x789"), resp.TransactionHash)
+
+		resp, replacementMaxFee, err := internalSigner.ReplaceInvokeTxn(
+			t.Context(), resp.TransactionHash, 20, nil,
+		)
+		require.NoError(t, err)
+		require.Equal(t, utils.HexToFelt(t, "0x78a"), resp.TransactionHash)
+
+		require.Len(t, sent, 2)
+		requireReplacement(t, &sent[0], &sent[1])
+		expectedMaxFee, err := types.MaxFee(&sent[1].ResourceBounds, sent[1].Tip)
+		require.NoError(t, err)
+		require.Equal(t, expectedMaxFee, replacementMaxFee)
+	})
+
+	t.Run("Replacement above the max fee is not sent", func(t *testing.T) {
+		var sent []rpc.InvokeTxnV3
+		mockRpc := createMockRPCServer(t, recordAddInvoke(t, &sent))
+		defer mockRpc.Close()
+
+		provider, err := rpc.NewProvider(mockRpc.URL)
+		require.NoError(t, err)
+
+		internalSigner, err := signer.NewInternalSigner(
+			provider, logger, &configSigner, contractAddresses,
+		)
+		require.NoError(t, err)
+
+		resourceBounds := mockedResourceBounds(t)
+		resp, err := internalSigner.BuildAndSendInvokeTxn(
+			t.Context(), []rpc.InvokeFunctionCall{}, &resourceBounds,
+		)
+		require.NoError(t, err)
+
+		// The original transaction is within the limit but its replacement isn't
+		maxFee, err := types.MaxFee(&resourceBounds, types.AttestTip)
+		require.NoError(t, err)
+		resp, replacementMaxFee, err := internalSigner.ReplaceInvokeTxn(
+			t.Context(), resp.TransactionHash, 20, maxFee,
+		)
+
+		require.Nil(t, resp)
+		require.Nil(t, replacementMaxFee)
+		require.ErrorIs(t, err, types.ErrFeeCapExceeded)
+		require.Len(t, sent, 1)
+	})
+
+	t.Run("Replacement whose bumped tip is above the max fee is not sent", func(t *testing.T) {
+		var sent []rpc.InvokeTxnV3
+		mockRpc := createMockRPCServer(t, recordAddInvoke(t, &sent))
+		defer mockRpc.Close()
+
+		provider, err := rpc.NewProvider(mockRpc.URL)
+		require.NoError(t, err)
+
+		internalSigner, err := signer.NewInternalSigner(
+			provider, logger, &configSigner, contractAddresses,
+		)
+		require.NoError(t, err)
+
+		resourceBounds := mockedResourceBounds(t)
+		resp, err := internalSigner.BuildAndSendInvokeTxn(
+			t.Context(), []rpc.InvokeFunctionCall{}, &resourceBounds,
+		)
+		require.NoError(t, err)
+
+		// The bumped resource prices fit in the limit, but not once the tip paid
+		// for each unit of l2 gas is added
+		bumped := sent[0]
+		require.NoError(t, signer.BumpInvokeTxnFees(&bumped, 20))
+		require.NotEqual(t, types.AttestTip, bumped.Tip)
+		maxFee, err := types.MaxFee(&bumped.ResourceBounds, types.AttestTip)
+		require.NoError(t, err)
+		resp, replacementMaxFee, err := internalSigner.ReplaceInvokeTxn(
+			t.Context(), resp.TransactionHash, 20, maxFee,
+		)
+
+		require.Nil(t, resp)
+		require.Nil(t, replacementMaxFee)
+		require.ErrorIs(t, err, types.ErrFeeCapExceeded)
+		require.Len(t, sent, 1)
+	})
+}
+
+func TestBumpInvokeTxnFees(t *testing.T) {
+	resourceBounds := func(price string) rpc.ResourceBoundsMapping {
+		return rpc.ResourceBoundsMapping{
+			L1Gas:     rpc.ResourceBounds{MaxAmount: "0x10", MaxPricePerUnit: rpc.U128(price)},
+			L1DataGas: rpc.ResourceBounds{MaxAmount: "0x20", MaxPricePerUnit: rpc.U128(price)},
+			L2Gas:     rpc.ResourceBounds{MaxAmount: "0x30", MaxPricePerUnit: rpc.U128(price)},
+		}
+	}
+
+	t.Run("Tip and prices are raised by the percentage", func(t *testing.T) {
+		txn := rpc.InvokeTxnV3{Tip: "0x64", ResourceBounds: resourceBounds("0x3e8")}
+
+		require.NoError(t, signer.BumpInvokeTxnFees(&txn, 20))
+
+		require.Equal(t, rpc.U64("0x78"), txn.Tip)
+		require.Equal(t, resourceBounds("0x4b0"), txn.ResourceBounds)
+	})
+
+	t.Run("Values are raised by at least one", func(t *testing.T) {
+		txn := rpc.InvokeTxnV3{Tip: "0x0", ResourceBounds: resourceBounds("0x2")}
+
+		require.NoError(t, signer.BumpInvokeTxnFees(&txn, 20))
+
+		require.Equal(t, rpc.U64("0x1"), txn.Tip)
+		require.Equal(t, resourceBounds("0x3"), txn.ResourceBounds)
+	})
+
+	t.Run("Error when the tip overflows", func(t *testing.T) {
+		txn := rpc.InvokeTxnV3{Tip: "0xffffffffffffffff", ResourceBounds: resourceBounds("0x2")}
+
+		err := signer.BumpInvokeTxnFees(&txn, 20)
+
+		require.ErrorContains(t, err, "cannot bump tip")
+	})
+
+	t.Run("Error when a price is not a hex value", func(t *testing.T) {
+		txn := rpc.InvokeTxnV3{Tip: "0x0", ResourceBounds: resourceBounds("0xabc")}
+		txn.ResourceBounds.L2Gas.MaxPricePerUnit = "0xnothex"
+
+		err := signer.BumpInvokeTxnFees(&txn, 20)
+
+		require.EqualError(
+			t, err, `cannot bump l2 gas max price per unit: invalid hex value "0xnothex"`,
+		)
+	})
+}
+
 func TestSignInvokeTx(t *testing.T) {
 	t.Run("Error signing tx", func(t *testing.T) {
 		invokeTx := rpc.InvokeTxnV3{
@@ -202,7 +573,12 @@ func TestSignInvokeTx(t *testing.T) {
 				}))
 		defer mockServer.Close()
 
-		err := signer.SignInvokeTx(&invokeTx, &felt.Felt{}, mockServer.URL)
+		remoteSigner := signer.NewRemoteSigner(
+			mockServer.URL, signerP.Auth{}, nil, signer.RemoteSignerOptions{},
+		)
+		err := signer.SignInvokeTx(
+			t.Context(), &invokeTx, &felt.Felt{}, validator.MockPublicKey(t), remoteSigner,
+		)
 
 		require.Equal(t, []*felt.Felt{}, invokeTx.Signature)
 		expectedErrorMsg := fmt.Sprintf(
@@ -235,8 +611,10 @@ func TestSignInvokeTx(t *testing.T) {
 
 		chainID := new(felt.Felt).SetUint64(1)
 
-		sigR := new(felt.Felt).SetUint64(0x123)
-		sigS := new(felt.Felt).SetUint64(0x456)
+		txHash, err := hash.TransactionHashInvokeV3(&invokeTx, chainID)
+		require.NoError(t, err)
+		sigR, sigS, err := curve.Curve.SignFelt(txHash, new(felt.Felt).SetUint64(0x123))
+		require.NoError(t, err)
 
 		mockServer := httptest.NewServer(
 			http.HandlerFunc(
@@ -262,13 +640,108 @@ func TestSignInvokeTx(t *testing.T) {
 				}))
 		defer mockServer.Close()
 
-		err := signer.SignInvokeTx(&invokeTx, chainID, mockServer.URL)
+		remoteSigner := signer.NewRemoteSigner(
+			mockServer.URL, signerP.Auth{}, nil, signer.RemoteSignerOptions{},
+		)
+		err = signer.SignInvokeTx(
+			t.Context(), &invokeTx, chainID, validator.MockPublicKey(t), remoteSigner,
+		)
 
 		expectedSignature := []*felt.Felt{sigR, sigS}
 		require.Equal(t, expectedSignature, invokeTx.Signature)
 
 		require.NoError(t, err)
 	})
+
+	t.Run("Invalid signature", func(t *testing.T) {
+		invokeTx := rpc.InvokeTxnV3{
+			Type:          rpc.TransactionType_Invoke,
+			SenderAddress: new(felt.Felt).SetUint64(0xabc),
+			Calldata:      []*felt.Felt{new(felt.Felt).SetUint64(0xcba)},
+			Version:       rpc.TransactionV3,
+			Signature:     []*felt.Felt{},
+			Nonce:         utils.HexToFelt(t, "0x1"),
+			ResourceBounds: rpc.ResourceBoundsMapping{
+				L1Gas:     rpc.ResourceBounds{MaxAmount: "0x1", MaxPricePerUnit: "0x1"},
+				L2Gas:     rpc.ResourceBounds{MaxAmount: "0x1", MaxPricePerUnit: "0x1"},
+				L1DataGas: rpc.ResourceBounds{MaxAmount: "0x1", MaxPricePerUnit: "0x1"},
+			},
+			Tip:                   "0x0",
+			PayMasterData:         []*felt.Felt{},
+			AccountDeploymentData: []*felt.Felt{},
+			NonceDataMode:         rpc.DAModeL1,
+			FeeMode:               rpc.DAModeL1,
+		}
+		chainID := new(felt.Felt).SetUint64(1)
+		txHash, err := hash.TransactionHashInvokeV3(&invokeTx, chainID)
+		require.NoError(t, err)
+
+		for name, response := range map[string]string{
+			"Wrong signature":   `{"signature": ["0x123", "0x456"]}`,
+			"Missing signature": `{"signature": []}`,
+		} {
+			t.Run(name, func(t *testing.T) {
+				mockServer := httptest.NewServer(
+					http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+						_, err := w.Write([]byte(response))
+						require.NoError(t, err)
+					}))
+				defer mockServer.Close()
+
+				remoteSigner := signer.NewRemoteSigner(
+					mockServer.URL, signerP.Auth{}, nil, signer.RemoteSignerOptions{},
+				)
+				err := signer.SignInvokeTx(
+					t.Context(), &invokeTx, chainID, validator.MockPublicKey(t), remoteSigner,
+				)
+
+				var invalidSignature *signer.InvalidSignatureError
+				require.ErrorAs(t, err, &invalidSignature)
+				require.Equal(t, txHash, invalidSignature.TransactionHash)
+				require.Equal(t, validator.MockPublicKey(t), invalidSignature.PublicKey)
+				require.Equal(t, []*felt.Felt{}, invokeTx.Signature)
+			})
+		}
+	})
+
+	t.Run("Signature by another key", func(t *testing.T) {
+		invokeTx := rpc.InvokeTxnV3{
+			Type:          rpc.TransactionType_Invoke,
+			SenderAddress: new(felt.Felt).SetUint64(0xabc),
+			Calldata:      []*felt.Felt{new(felt.Felt).SetUint64(0xcba)},
+			Version:       rpc.TransactionV3,
+			Signature:     []*felt.Felt{},
+			Nonce:         utils.HexToFelt(t, "0x1"),
+			ResourceBounds: rpc.ResourceBoundsMapping{
+				L1Gas:     rpc.ResourceBounds{MaxAmount: "0x1", MaxPricePerUnit: "0x1"},
+				L2Gas:     rpc.ResourceBounds{MaxAmount: "0x1", MaxPricePerUnit: "0x1"},
+				L1DataGas: rpc.ResourceBounds{MaxAmount: "0x1", MaxPricePerUnit: "0x1"},
+			},
+			Tip:                   "0x0",
+			PayMasterData:         []*felt.Felt{},
+			AccountDeploymentData: []*felt.Felt{},
+			NonceDataMode:         rpc.DAModeL1,
+			FeeMode:               rpc.DAModeL1,
+		}
+		chainID := new(felt.Felt).SetUint64(1)
+		txHash, err := hash.TransactionHashInvokeV3(&invokeTx, chainID)
+		require.NoError(t, err)
+		sigR, sigS, err := curve.Curve.SignFelt(txHash, new(felt.Felt).SetUint64(0x456))
+		require.NoError(t, err)
+
+		err = signer.VerifyInvokeTxSignature(
+			&invokeTx, chainID, validator.MockPublicKey(t), []*felt.Felt{sigR, sigS},
+		)
+		var invalidSignature *signer.InvalidSignatureError
+		require.ErrorAs(t, err, &invalidSignature)
+
+		publicKey, _, err := curve.Curve.PrivateToPoint(big.NewInt(0x456))
+		require.NoError(t, err)
+		err = signer.VerifyInvokeTxSignature(
+			&invokeTx, chainID, new(felt.Felt).SetBigInt(publicKey), []*felt.Felt{sigR, sigS},
+		)
+		require.NoError(t, err)
+	})
 }
 
 func TestFetchEpochInfo(t *testing.T) {
@@ -464,6 +937,94 @@ func TestFetchAttestWindow(t *testing.T) {
 	})
 }
 
+func TestFetchAttestationDone(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	t.Cleanup(mockCtrl.Finish)
+
+	mockSigner := mocks.NewMockSigner(mockCtrl)
+
+	stakerAddress := types.AddressFromString("0x123")
+	expectedFnCall := rpc.FunctionCall{
+		ContractAddress: utils.HexToFelt(t, constants.SEPOLIA_ATTEST_CONTRACT_ADDRESS),
+		EntryPointSelector: snGoUtils.GetSelectorFromNameFelt(
+			"is_attestation_done_in_curr_epoch",
+		),
+		Calldata: []*felt.Felt{stakerAddress.Felt()},
+	}
+
+	t.Run("Return error: contract internal error", func(t *testing.T) {
+		mockSigner.
+			EXPECT().
+			Call(context.Background(), expectedFnCall, rpc.BlockID{Tag: "latest"}).
+			Return(nil, errors.New("some contract error"))
+
+		mockSigner.EXPECT().ValidationContracts().Return(
+			validator.SepoliaValidationContracts(t),
+		).Times(1)
+
+		done, err := signer.FetchAttestationDone(mockSigner, &stakerAddress)
+
+		require.False(t, done)
+		require.Equal(
+			t,
+			errors.New(
+				"Error when calling entrypoint `is_attestation_done_in_curr_epoch`: some contract error",
+			),
+			err,
+		)
+	})
+
+	t.Run("Return error: wrong contract response length", func(t *testing.T) {
+		mockSigner.
+			EXPECT().
+			Call(context.Background(), expectedFnCall, rpc.BlockID{Tag: "latest"}).
+			Return([]*felt.Felt{}, nil)
+
+		mockSigner.EXPECT().ValidationContracts().Return(
+			validator.SepoliaValidationContracts(t),
+		).Times(1)
+
+		done, err := signer.FetchAttestationDone(mockSigner, &stakerAddress)
+
+		require.False(t, done)
+		require.Equal(
+			t, errors.New("Invalid response from entrypoint `is_attestation_done_in_curr_epoch`"), err,
+		)
+	})
+
+	t.Run("Epoch is not attested yet", func(t *testing.T) {
+		mockSigner.
+			EXPECT().
+			Call(context.Background(), expectedFnCall, rpc.BlockID{Tag: "latest"}).
+			Return([]*felt.Felt{new(felt.Felt)}, nil)
+
+		mockSigner.EXPECT().ValidationContracts().Return(
+			validator.SepoliaValidationContracts(t),
+		).Times(1)
+
+		done, err := signer.FetchAttestationDone(mockSigner, &stakerAddress)
+
+		require.False(t, done)
+		require.Nil(t, err)
+	})
+
+	t.Run("Epoch is already attested", func(t *testing.T) {
+		mockSigner.
+			EXPECT().
+			Call(context.Background(), expectedFnCall, rpc.BlockID{Tag: "latest"}).
+			Return([]*felt.Felt{new(felt.Felt).SetUint64(1)}, nil)
+
+		mockSigner.EXPECT().ValidationContracts().Return(
+			validator.SepoliaValidationContracts(t),
+		).Times(1)
+
+		done, err := signer.FetchAttestationDone(mockSigner, &stakerAddress)
+
+		require.True(t, done)
+		require.Nil(t, err)
+	})
+}
+
 func TestFetchValidatorBalance(t *testing.T) {
 	mockCtrl := gomock.NewController(t)
 	t.Cleanup(mockCtrl.Finish)
@@ -712,63 +1273,218 @@ func TestInvokeAttest(t *testing.T) {
 
 	mockSigner := mocks.NewMockSigner(mockCtrl)
 
-	t.Run("Return error", func(t *testing.T) {
-		blockHash := new(felt.Felt).SetUint64(123)
-
-		expectedFnCall := []rpc.InvokeFunctionCall{{
-			ContractAddress: utils.HexToFelt(t, constants.SEPOLIA_ATTEST_CONTRACT_ADDRESS),
-			FunctionName:    "attest",
-			CallData:        []*felt.Felt{blockHash},
-		}}
-
+	blockHash := new(felt.Felt).SetUint64(123)
+	expectedFnCall := []rpc.InvokeFunctionCall{{
+		ContractAddress: utils.HexToFelt(t, constants.SEPOLIA_ATTEST_CONTRACT_ADDRESS),
+		FunctionName:    "attest",
+		CallData:        []*felt.Felt{blockHash},
+	}}
+	attestRequired := signer.AttestRequired{BlockHash: validator.BlockHash(*blockHash)}
+
+	resourceBounds := rpc.ResourceBoundsMapping{
+		L1Gas:     rpc.ResourceBounds{MaxAmount: "0x0", MaxPricePerUnit: "0x10"},
+		L1DataGas: rpc.ResourceBounds{MaxAmount: "0x80", MaxPricePerUnit: "0x20"},
+		L2Gas:     rpc.ResourceBounds{MaxAmount: "0x100", MaxPricePerUnit: "0x30"},
+	}
+	// 0x80 * 0x20 + 0x100 * 0x30
+	expectedMaxFee := new(felt.Felt).SetUint64(0x4000)
+
+	t.Run("Return error: fee estimation fails", func(t *testing.T) {
+		mockSigner.EXPECT().ValidationContracts().Return(
+			validator.SepoliaValidationContracts(t),
+		).Times(1)
 		mockSigner.
 			EXPECT().
-			BuildAndSendInvokeTxn(
-				context.Background(), expectedFnCall, constants.FEE_ESTIMATION_MULTIPLIER,
+			EstimateInvokeTxnFee(
+				context.Background(), expectedFnCall, constants.DEFAULT_FEE_ESTIMATION_MULTIPLIER,
 			).
-			Return(nil, errors.New("some sending error"))
+			Return(rpc.ResourceBoundsMapping{}, errors.New("some estimation error"))
+
+		invokeRes, maxFee, err := signer.InvokeAttest(
+			mockSigner, &attestRequired, &types.AttestFee{}, &types.FeeCap{},
+		)
+
+		require.Nil(t, invokeRes)
+		require.Nil(t, maxFee)
+		require.EqualError(t, err, "some estimation error")
+	})
 
+	t.Run("Return error: sending fails", func(t *testing.T) {
 		mockSigner.EXPECT().ValidationContracts().Return(
 			validator.SepoliaValidationContracts(t),
 		).Times(1)
+		mockSigner.
+			EXPECT().
+			EstimateInvokeTxnFee(
+				context.Background(), expectedFnCall, constants.DEFAULT_FEE_ESTIMATION_MULTIPLIER,
+			).
+			Return(resourceBounds, nil)
+		mockSigner.
+			EXPECT().
+			BuildAndSendInvokeTxn(context.Background(), expectedFnCall, &resourceBounds).
+			Return(nil, errors.New("some sending error"))
 
-		attestRequired := signer.AttestRequired{BlockHash: validator.BlockHash(*blockHash)}
-		invokeRes, err := signer.InvokeAttest(mockSigner, &attestRequired)
+		invokeRes, maxFee, err := signer.InvokeAttest(
+			mockSigner, &attestRequired, &types.AttestFee{}, &types.FeeCap{},
+		)
 
 		require.Nil(t, invokeRes)
+		require.Nil(t, maxFee)
 		require.EqualError(t, err, "some sending error")
 	})
 
-	t.Run("Invoke tx successfully sent", func(t *testing.T) {
-		blockHash := new(felt.Felt).SetUint64(123)
+	t.Run("Invoke tx successfully sent with the estimated fee", func(t *testing.T) {
+		response := rpc.AddInvokeTransactionResponse{
+			TransactionHash: utils.HexToFelt(t, "0x123"),
+		}
+		mockSigner.EXPECT().ValidationContracts().Return(
+			validator.SepoliaValidationContracts(t),
+		).Times(2)
+		mockSigner.
+			EXPECT().
+			EstimateInvokeTxnFee(context.Background(), expectedFnCall, 2.0).
+			Return(resourceBounds, nil).
+			Times(2)
+		mockSigner.
+			EXPECT().
+			BuildAndSendInvokeTxn(context.Background(), expectedFnCall, &resourceBounds).
+			Return(&response, nil).
+			Times(2)
+
+		// The fee is estimated again for every attest
+		attestFee, err := types.AttestFeeFromString(types.AttestFeeAlways, 2)
+		require.NoError(t, err)
+		for range 2 {
+			invokeRes, maxFee, err := signer.InvokeAttest(
+				mockSigner, &attestRequired, &attestFee, &types.FeeCap{},
+			)
 
-		expectedFnCall := []rpc.InvokeFunctionCall{{
-			ContractAddress: utils.HexToFelt(t, constants.SEPOLIA_ATTEST_CONTRACT_ADDRESS),
-			FunctionName:    "attest",
-			CallData:        []*felt.Felt{blockHash},
-		}}
+			require.NoError(t, err)
+			require.Equal(t, &response, invokeRes)
+			require.Equal(t, expectedMaxFee, maxFee)
+		}
+	})
 
+	t.Run("Fee is estimated only for the first attest", func(t *testing.T) {
 		response := rpc.AddInvokeTransactionResponse{
 			TransactionHash: utils.HexToFelt(t, "0x123"),
 		}
+		mockSigner.EXPECT().ValidationContracts().Return(
+			validator.SepoliaValidationContracts(t),
+		).Times(2)
 		mockSigner.
 			EXPECT().
-			BuildAndSendInvokeTxn(
-				context.Background(),
-				expectedFnCall,
-				constants.FEE_ESTIMATION_MULTIPLIER,
+			EstimateInvokeTxnFee(
+				context.Background(), expectedFnCall, constants.DEFAULT_FEE_ESTIMATION_MULTIPLIER,
 			).
+			Return(resourceBounds, nil).
+			Times(1)
+		mockSigner.
+			EXPECT().
+			BuildAndSendInvokeTxn(context.Background(), expectedFnCall, &resourceBounds).
+			Return(&response, nil).
+			Times(2)
+
+		attestFee, err := types.AttestFeeFromString(types.AttestFeeOnce, 0)
+		require.NoError(t, err)
+		for range 2 {
+			invokeRes, maxFee, err := signer.InvokeAttest(
+				mockSigner, &attestRequired, &attestFee, &types.FeeCap{},
+			)
+
+			require.NoError(t, err)
+			require.Equal(t, &response, invokeRes)
+			require.Equal(t, expectedMaxFee, maxFee)
+		}
+		require.Equal(t, &resourceBounds, attestFee.ResourceBounds())
+	})
+
+	t.Run("Fixed resource bounds are never estimated", func(t *testing.T) {
+		response := rpc.AddInvokeTransactionResponse{
+			TransactionHash: utils.HexToFelt(t, "0x123"),
+		}
+		mockSigner.EXPECT().ValidationContracts().Return(
+			validator.SepoliaValidationContracts(t),
+		).Times(1)
+		mockSigner.
+			EXPECT().
+			BuildAndSendInvokeTxn(context.Background(), expectedFnCall, &resourceBounds).
 			Return(&response, nil)
 
+		attestFee, err := types.AttestFeeFromString(
+			"l1_gas=0:0x10,l1_data_gas=128:0x20,l2_gas=0x100:48", 0,
+		)
+		require.NoError(t, err)
+		invokeRes, maxFee, err := signer.InvokeAttest(
+			mockSigner, &attestRequired, &attestFee, &types.FeeCap{},
+		)
+
+		require.NoError(t, err)
+		require.Equal(t, &response, invokeRes)
+		require.Equal(t, expectedMaxFee, maxFee)
+	})
+
+	t.Run("Return error: fee above the max per attestation", func(t *testing.T) {
 		mockSigner.EXPECT().ValidationContracts().Return(
 			validator.SepoliaValidationContracts(t),
 		).Times(1)
+		mockSigner.
+			EXPECT().
+			EstimateInvokeTxnFee(
+				context.Background(), expectedFnCall, constants.DEFAULT_FEE_ESTIMATION_MULTIPLIER,
+			).
+			Return(resourceBounds, nil)
 
-		attestRequired := signer.AttestRequired{BlockHash: validator.BlockHash(*blockHash)}
-		invokeRes, err := signer.InvokeAttest(mockSigner, &attestRequired)
+		// No call to BuildAndSendInvokeTxn is expected
+		feeCap, err := types.FeeCapFromStrings("0x3fff", "")
+		require.NoError(t, err)
+		invokeRes, maxFee, err := signer.InvokeAttest(
+			mockSigner, &attestRequired, &types.AttestFee{}, &feeCap,
+		)
 
+		require.Nil(t, invokeRes)
+		require.Nil(t, maxFee)
+		require.ErrorIs(t, err, types.ErrFeeCapExceeded)
+		require.ErrorContains(t, err, "above the 16383 fri allowed per attestation")
+	})
+
+	t.Run("Return error: daily fee budget spent", func(t *testing.T) {
+		response := rpc.AddInvokeTransactionResponse{
+			TransactionHash: utils.HexToFelt(t, "0x123"),
+		}
+		mockSigner.EXPECT().ValidationContracts().Return(
+			validator.SepoliaValidationContracts(t),
+		).Times(2)
+		mockSigner.
+			EXPECT().
+			EstimateInvokeTxnFee(
+				context.Background(), expectedFnCall, constants.DEFAULT_FEE_ESTIMATION_MULTIPLIER,
+			).
+			Return(resourceBounds, nil).
+			Times(2)
+		mockSigner.
+			EXPECT().
+			BuildAndSendInvokeTxn(context.Background(), expectedFnCall, &resourceBounds).
+			Return(&response, nil).
+			Times(1)
+
+		// The budget is enough for one attest only
+		feeCap, err := types.FeeCapFromStrings("", "0x7fff")
+		require.NoError(t, err)
+		invokeRes, maxFee, err := signer.InvokeAttest(
+			mockSigner, &attestRequired, &types.AttestFee{}, &feeCap,
+		)
+		require.NoError(t, err)
 		require.Equal(t, &response, invokeRes)
-		require.Nil(t, err)
+		require.Equal(t, expectedMaxFee, maxFee)
+
+		invokeRes, maxFee, err = signer.InvokeAttest(
+			mockSigner, &attestRequired, &types.AttestFee{}, &feeCap,
+		)
+		require.Nil(t, invokeRes)
+		require.Nil(t, maxFee)
+		require.ErrorIs(t, err, types.ErrFeeCapExceeded)
+		require.ErrorContains(t, err, "above the 16383 fri left in the daily budget")
 	})
 }
 
@@ -3,6 +3,8 @@ package signer
 import (
 	"context"
 	"math/big"
+	"strings"
+	"time"
 
 	"github.com/NethermindEth/juno/core/crypto"
 	"github.com/NethermindEth/juno/core/felt"
@@ -11,6 +13,7 @@ import (
 	"github.com/NethermindEth/starknet-staking-v2/validator/types"
 	"github.com/NethermindEth/starknet.go/rpc"
 	"github.com/NethermindEth/starknet.go/utils"
+	"github.com/cockroachdb/errors"
 	"lukechampine.com/uint128"
 )
 
@@ -21,14 +24,27 @@ type Signer interface {
 		ctx context.Context, transactionHash *felt.Felt,
 	) (*rpc.TxnStatusResult, error)
 	BuildAndSendInvokeTxn(
-		ctx context.Context, functionCalls []rpc.InvokeFunctionCall, multiplier float64,
+		ctx context.Context,
+		functionCalls []rpc.InvokeFunctionCall,
+		resourceBounds *rpc.ResourceBoundsMapping,
 	) (*rpc.AddInvokeTransactionResponse, error)
 	Call(ctx context.Context, call rpc.FunctionCall, blockId rpc.BlockID) ([]*felt.Felt, error)
 	BlockWithTxHashes(ctx context.Context, blockID rpc.BlockID) (interface{}, error)
 
 	// Custom Methods
+	// Returns the resource bounds of the estimated fee multiplied by `multiplier`
+	EstimateInvokeTxnFee(
+		ctx context.Context, functionCalls []rpc.InvokeFunctionCall, multiplier float64,
+	) (rpc.ResourceBoundsMapping, error)
 	Address() *Address
 	ValidationContracts() *ValidationContracts
+	// Sends again the invoke transaction with the given hash, keeping its nonce but raising
+	// its tip and resource prices so it replaces the original one if it's stuck.
+	// If the replacement could pay more than `maxFee`, its tip included, it fails before
+	// signing it. Returns the maximum fee the replacement can pay
+	ReplaceInvokeTxn(
+		ctx context.Context, transactionHash *felt.Felt, bumpPercentage uint64, maxFee *felt.Felt,
+	) (*rpc.AddInvokeTransactionResponse, *felt.Felt, error)
 }
 
 // I believe all these functions down here should be methods
@@ -84,6 +100,30 @@ func FetchAttestWindow[S Signer](signer S) (uint64, error) {
 	return result[0].Uint64(), nil
 }
 
+// Asks the attestation contract whether the staker already attested in the current epoch
+func FetchAttestationDone[S Signer](signer S, stakerAddress *Address) (bool, error) {
+	result, err := signer.Call(
+		context.Background(),
+		rpc.FunctionCall{
+			ContractAddress: signer.ValidationContracts().Attest.Felt(),
+			EntryPointSelector: utils.GetSelectorFromNameFelt(
+				"is_attestation_done_in_curr_epoch",
+			),
+			Calldata: []*felt.Felt{stakerAddress.Felt()},
+		},
+		rpc.BlockID{Tag: "latest"},
+	)
+	if err != nil {
+		return false, entrypointInternalError("is_attestation_done_in_curr_epoch", err)
+	}
+
+	if len(result) != 1 {
+		return false, entrypointResponseError("is_attestation_done_in_curr_epoch")
+	}
+
+	return !result[0].IsZero(), nil
+}
+
 // For near future when tracking validator's balance
 func FetchValidatorBalance[Account Signer](account Account) (Balance, error) {
 	StrkTokenContract := types.AddressFromString(constants.STRK_CONTRACT_ADDRESS)
@@ -148,18 +188,240 @@ func FetchEpochAndAttestInfo[S Signer](
 	return epochInfo, attestInfo, nil
 }
 
-func InvokeAttest[S Signer](signer S, attest *AttestRequired) (
-	*rpc.AddInvokeTransactionResponse, error,
-) {
+// Sends the attest transaction with the resource bounds decided by `attestFee`, as long
+// as the fee it can pay is within `feeCap`. Returns that maximum fee along with the response
+func InvokeAttest[S Signer](
+	signer S, attest *AttestRequired, attestFee *AttestFee, feeCap *FeeCap,
+) (*rpc.AddInvokeTransactionResponse, *felt.Felt, error) {
 	calls := []rpc.InvokeFunctionCall{{
 		ContractAddress: signer.ValidationContracts().Attest.Felt(),
 		FunctionName:    "attest",
 		CallData:        []*felt.Felt{attest.BlockHash.Felt()},
 	}}
 
-	return signer.BuildAndSendInvokeTxn(
-		context.Background(), calls, constants.FEE_ESTIMATION_MULTIPLIER,
+	resourceBounds := attestFee.ResourceBounds()
+	if resourceBounds == nil {
+		estimated, err := signer.EstimateInvokeTxnFee(
+			context.Background(), calls, attestFee.Multiplier(),
+		)
+		if err != nil {
+			return nil, nil, err
+		}
+		attestFee.SetEstimated(&estimated)
+		resourceBounds = &estimated
+	}
+
+	maxFee, err := types.MaxFee(resourceBounds, types.AttestTip)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	now := time.Now()
+	if err := feeCap.Check(now, maxFee, nil); err != nil {
+		return nil, nil, err
+	}
+
+	resp, err := signer.BuildAndSendInvokeTxn(context.Background(), calls, resourceBounds)
+	if err != nil {
+		return nil, nil, err
+	}
+	feeCap.Spend(now, maxFee, nil)
+	return resp, maxFee, nil
+}
+
+// Replaces a stuck attest transaction by one with the same nonce and a higher tip, as long
+// as the fee it can pay, the raised tip included, is within `feeCap`. `replacedFee` is the maximum fee of the stuck
+// transaction. Returns the maximum fee the replacement can pay along with the response
+func ReplaceAttest[S Signer](
+	signer S, transactionHash *felt.Felt, replacedFee *felt.Felt, feeCap *FeeCap,
+) (*rpc.AddInvokeTransactionResponse, *felt.Felt, error) {
+	now := time.Now()
+	resp, maxFee, err := signer.ReplaceInvokeTxn(
+		context.Background(),
+		transactionHash,
+		constants.REPLACEMENT_FEE_BUMP_PERCENTAGE,
+		feeCap.Allowance(now, replacedFee),
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+	feeCap.Spend(now, maxFee, replacedFee)
+	return resp, maxFee, nil
+}
+
+// Returns the maximum fee the transaction can pay, its tip included, failing when it's
+// above `maxFee`. A nil `maxFee` means no limit
+func checkMaxFee(txn *rpc.InvokeTxnV3, maxFee *felt.Felt) (*felt.Felt, error) {
+	fee, err := types.MaxFee(&txn.ResourceBounds, txn.Tip)
+	if err != nil {
+		return nil, err
+	}
+	if maxFee != nil && fee.Cmp(maxFee) > 0 {
+		return nil, errors.Errorf(
+			"%w: max fee of %s fri is above the %s fri allowed",
+			types.ErrFeeCapExceeded, fee.Text(10), maxFee.Text(10),
+		)
+	}
+	return fee, nil
+}
+
+// Raises the tip and the max price per unit of every resource by the given percentage.
+// Each value grows by at least one so the replacement always pays more than the original
+func BumpInvokeTxnFees(txn *rpc.InvokeTxnV3, percentage uint64) error {
+	tip, err := bumpHexValue(string(txn.Tip), percentage, 64)
+	if err != nil {
+		return errors.Errorf("cannot bump tip: %s", err)
+	}
+	txn.Tip = rpc.U64(tip)
+
+	resources := []struct {
+		name   string
+		bounds *rpc.ResourceBounds
+	}{
+		{"l1 gas", &txn.ResourceBounds.L1Gas},
+		{"l1 data gas", &txn.ResourceBounds.L1DataGas},
+		{"l2 gas", &txn.ResourceBounds.L2Gas},
+	}
+	for _, resource := range resources {
+		price, err := bumpHexValue(string(resource.bounds.MaxPricePerUnit), percentage, 128)
+		if err != nil {
+			return errors.Errorf("cannot bump %s max price per unit: %s", resource.name, err)
+		}
+		resource.bounds.MaxPricePerUnit = rpc.U128(price)
+	}
+
+	return nil
+}
+
+func bumpHexValue(value string, percentage uint64, maxBits int) (string, error) {
+	current := new(big.Int)
+	if trimmed := strings.TrimPrefix(value, "0x"); trimmed != "" {
+		if _, ok := current.SetString(trimmed, 16); !ok {
+			return "", errors.Errorf("invalid hex value %q", value)
+		}
+	}
+
+	bumped := new(big.Int).Mul(current, new(big.Int).SetUint64(100+percentage))
+	bumped.Div(bumped, big.NewInt(100))
+	if bumped.Cmp(current) <= 0 {
+		bumped.Add(current, big.NewInt(1))
+	}
+
+	if bumped.BitLen() > maxBits {
+		return "", errors.Errorf("%s does not fit in %d bits once raised", value, maxBits)
+	}
+	return "0x" + bumped.Text(16), nil
+}
+
+// An invoke transaction sent by a signer, kept in case it has to be replaced
+type sentInvokeTxn struct {
+	hash felt.Felt
+	txn  rpc.BroadcastInvokeTxnV3
+}
+
+// Returns the invoke transaction with the given hash so it can be sent again. The one last
+// sent by the signer is used if it matches, otherwise it is requested to the provider
+func invokeTxnToReplace(
+	ctx context.Context,
+	provider rpc.RpcProvider,
+	lastSent *sentInvokeTxn,
+	transactionHash *felt.Felt,
+) (rpc.BroadcastInvokeTxnV3, error) {
+	if lastSent != nil && lastSent.hash.Equal(transactionHash) {
+		return lastSent.txn, nil
+	}
+
+	blockTxn, err := provider.TransactionByHash(ctx, transactionHash)
+	if err != nil {
+		return rpc.BroadcastInvokeTxnV3{},
+			errors.Errorf("cannot get transaction %s: %s", transactionHash, err)
+	}
+
+	invokeTxn, ok := blockTxn.IBlockTransaction.(rpc.BlockInvokeTxnV3)
+	if !ok {
+		return rpc.BroadcastInvokeTxnV3{},
+			errors.Errorf("transaction %s is not an invoke v3 transaction", transactionHash)
+	}
+	return rpc.BroadcastInvokeTxnV3{InvokeTxnV3: invokeTxn.InvokeTxnV3}, nil
+}
+
+// Entry points returning the public key of the most common account contracts,
+// tried in order: OpenZeppelin and Braavos, OpenZeppelin legacy, Argent, Argent legacy
+var publicKeyEntryPoints = []string{"get_public_key", "getPublicKey", "get_owner", "getSigner"}
+
+// Reads the public key controlling the account at `accountAddress`
+func AccountPublicKey(
+	ctx context.Context, provider rpc.RpcProvider, accountAddress *felt.Felt,
+) (*felt.Felt, error) {
+	var lastErr error
+	for _, entryPoint := range publicKeyEntryPoints {
+		result, err := provider.Call(ctx, rpc.FunctionCall{
+			ContractAddress:    accountAddress,
+			EntryPointSelector: utils.GetSelectorFromNameFelt(entryPoint),
+			Calldata:           []*felt.Felt{},
+		}, rpc.BlockID{Tag: "latest"})
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if len(result) != 1 {
+			lastErr = entrypointResponseError(entryPoint)
+			continue
+		}
+		return result[0], nil
+	}
+	return nil, errors.Errorf(
+		"cannot read the public key of account %s, tried entry points %s: %w",
+		accountAddress,
+		strings.Join(publicKeyEntryPoints, ", "),
+		lastErr,
+	)
+}
+
+// Fails when `publicKey` is not the key controlling the account at `accountAddress`,
+// so a misconfigured signer is caught before any attestation is signed
+func CheckAccountPublicKey(
+	ctx context.Context,
+	provider rpc.RpcProvider,
+	accountAddress *felt.Felt,
+	publicKey *felt.Felt,
+) error {
+	accountPublicKey, err := AccountPublicKey(ctx, provider, accountAddress)
+	if err != nil {
+		return err
+	}
+	if !accountPublicKey.Equal(publicKey) {
+		return errors.Errorf(
+			"%w: the signer public key is %s but account %s is controlled by %s",
+			ErrAccountKeyMismatch,
+			publicKey,
+			accountAddress,
+			accountPublicKey,
+		)
+	}
+	return nil
+}
+
+// Runs CheckAccountPublicKey when setting up a signer. Only a confirmed mismatch is an
+// error: when the account key can't be read, e.g. because the account doesn't expose it
+// through any of the known entry points, the check is skipped with a warning
+func checkOperationalAccountKey(
+	ctx context.Context,
+	provider rpc.RpcProvider,
+	accountAddress *felt.Felt,
+	publicKey *felt.Felt,
+	logger *junoUtils.ZapLogger,
+) error {
+	err := CheckAccountPublicKey(ctx, provider, accountAddress, publicKey)
+	if err == nil || errors.Is(err, ErrAccountKeyMismatch) {
+		return err
+	}
+	logger.Warnw(
+		"Cannot check the signer key controls the operational account, attestations will"+
+			" fail if it doesn't",
+		"error", err,
 	)
+	return nil
 }
 
 func ComputeBlockNumberToAttestTo(epochInfo *EpochInfo, attestWindow uint64) BlockNumber {
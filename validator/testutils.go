@@ -12,9 +12,12 @@ import (
 
 	"github.com/NethermindEth/juno/core/felt"
 	"github.com/NethermindEth/juno/utils"
+	"github.com/NethermindEth/starknet-staking-v2/signer"
 	"github.com/NethermindEth/starknet-staking-v2/validator/config"
 	"github.com/NethermindEth/starknet-staking-v2/validator/constants"
 	"github.com/NethermindEth/starknet-staking-v2/validator/types"
+	"github.com/NethermindEth/starknet.go/curve"
+	"github.com/NethermindEth/starknet.go/hash"
 	"github.com/NethermindEth/starknet.go/rpc"
 	snGoUtils "github.com/NethermindEth/starknet.go/utils"
 	"github.com/joho/godotenv"
@@ -81,6 +84,10 @@ func MockRPCServer(
 			w.WriteHeader(http.StatusOK)
 			_, err := w.Write([]byte(chainIdResponse))
 			require.NoError(t, err)
+		case "starknet_blockNumber":
+			w.WriteHeader(http.StatusOK)
+			_, err := w.Write([]byte(`{"jsonrpc": "2.0", "result": 1, "id": 1}`))
+			require.NoError(t, err)
 		case "starknet_call":
 			// Marshal the `Params` back into JSON
 			paramsBytes, err := json.Marshal(req.Params[0])
@@ -91,6 +98,16 @@ func MockRPCServer(
 			err = json.Unmarshal(paramsBytes, &fnCall)
 			require.NoError(t, err)
 
+			// The signer checks its key controls the account when it's created
+			if fnCall.EntryPointSelector.Equal(snGoUtils.GetSelectorFromNameFelt("get_public_key")) {
+				w.WriteHeader(http.StatusOK)
+				_, err := fmt.Fprintf(
+					w, `{"jsonrpc": "2.0", "result": ["%s"], "id": 1}`, MockPublicKey(t),
+				)
+				require.NoError(t, err)
+				return
+			}
+
 			// Just making sure it's the call expected
 			expectedEpochInfoFnCall := rpc.FunctionCall{
 				ContractAddress: utils.HexToFelt(t, constants.SEPOLIA_STAKING_CONTRACT_ADDRESS),
@@ -115,6 +132,49 @@ func MockRPCServer(
 	return mockRpc
 }
 
+// Public key of the private key "0x123" used by the tests, controlling every mocked account
+func MockPublicKey(t *testing.T) *felt.Felt {
+	t.Helper()
+
+	return utils.HexToFelt(
+		t, "0x566d69d8c99f62bc71118399bab25c1f03719463eab8d6a444cd11ece131616",
+	)
+}
+
+// Mocks an external signer serving `publicKey` and answering signing requests with `sign`
+func MockSignerServer(
+	t *testing.T, publicKey *felt.Felt, sign http.HandlerFunc,
+) *httptest.Server {
+	t.Helper()
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != signer.PUBLIC_KEY_ENDPOINT {
+			sign(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		err := json.NewEncoder(w).Encode(signer.PublicKeyResponse{PublicKey: publicKey})
+		require.NoError(t, err)
+	}))
+}
+
+// Answers a signing request with a valid signature by the private key of `MockPublicKey`
+func MockSign(t *testing.T, w http.ResponseWriter, r *http.Request) {
+	t.Helper()
+
+	var req signer.Request
+	require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+	txHash, err := hash.TransactionHashInvokeV3(req.InvokeTxnV3, req.ChainId)
+	require.NoError(t, err)
+
+	sigR, sigS, err := curve.Curve.SignFelt(txHash, new(felt.Felt).SetUint64(0x123))
+	require.NoError(t, err)
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(signer.Response{Signature: [2]*felt.Felt{sigR, sigS}})
+	require.NoError(t, err)
+}
+
 func SepoliaValidationContracts(t *testing.T) *ValidationContracts {
 	t.Helper()
 
@@ -11,5 +11,6 @@ type (
 	BlockHash           = types.BlockHash
 	BlockNumber         = types.BlockNumber
 	EpochInfo           = types.EpochInfo
+	FeeCap              = types.FeeCap
 	ValidationContracts = types.ValidationContracts
 )
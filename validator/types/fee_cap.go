@@ -0,0 +1,208 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/NethermindEth/juno/core/felt"
+)
+
+// Returned when an attest transaction would pay more than the fee cap allows
+var ErrFeeCapExceeded = errors.New("attest fee cap exceeded")
+
+// Period over which the fee budget is spent
+const FeeBudgetWindow = 24 * time.Hour
+
+// Amount of fri in one STRK
+var friPerStrk = new(big.Int).Exp(big.NewInt(10), big.NewInt(18), nil)
+
+type spentFee struct {
+	at  time.Time
+	fee *big.Int
+}
+
+// Fee spent by the attest transactions sent at a given time, as saved to
+// keep the budget across restarts
+type SpentFee struct {
+	At  time.Time  `json:"at"`
+	Fee *felt.Felt `json:"fee"`
+}
+
+// Limits the fee the attest transactions can pay, both per attestation and over a
+// rolling 24 hours budget. Fees are accounted by the max fee of the transactions, which
+// is the most they can pay. Nil limits are not enforced, so the zero value limits nothing.
+// The fees spent are kept in memory, `SpentFees` and `RestoreSpent` carry them over restarts
+type FeeCap struct {
+	maxPerAttest *big.Int
+	dailyBudget  *big.Int
+	spent        []spentFee
+}
+
+// Creates the fee cap from the maximum fee per attestation and the daily budget.
+// Empty values mean no limit
+func FeeCapFromStrings(maxPerAttest, dailyBudget string) (FeeCap, error) {
+	var feeCap FeeCap
+	if maxPerAttest != "" {
+		value, err := FeeAmountFromString(maxPerAttest)
+		if err != nil {
+			return FeeCap{}, fmt.Errorf("invalid max fee per attestation: %w", err)
+		}
+		feeCap.maxPerAttest = value
+	}
+	if dailyBudget != "" {
+		value, err := FeeAmountFromString(dailyBudget)
+		if err != nil {
+			return FeeCap{}, fmt.Errorf("invalid daily fee budget: %w", err)
+		}
+		feeCap.dailyBudget = value
+	}
+	return feeCap, nil
+}
+
+// Parses a fee amount given either in fri, as a decimal or hexadecimal integer,
+// or in STRK with the "strk" suffix, e.g. "0.5strk"
+func FeeAmountFromString(s string) (*big.Int, error) {
+	s = strings.TrimSpace(s)
+	if strkStr, found := strings.CutSuffix(strings.ToLower(s), "strk"); found {
+		strk, ok := new(big.Rat).SetString(strings.TrimSpace(strkStr))
+		if !ok || strk.Sign() < 0 {
+			return nil, fmt.Errorf("`%s` is not a valid positive STRK amount", s)
+		}
+		fri := strk.Mul(strk, new(big.Rat).SetInt(friPerStrk))
+		if !fri.IsInt() {
+			return nil, fmt.Errorf("`%s` has more decimals than a fri", s)
+		}
+		return fri.Num(), nil
+	}
+
+	fri, ok := new(big.Int).SetString(s, 0)
+	if !ok || fri.Sign() < 0 {
+		return nil, fmt.Errorf("`%s` is not a valid positive fri amount", s)
+	}
+	return fri, nil
+}
+
+// Whether neither the fee per attestation nor the daily budget are limited
+func (c *FeeCap) IsZero() bool {
+	return c.maxPerAttest == nil && c.dailyBudget == nil
+}
+
+// Returns how much has been spent in the fee budget window ending at `now`
+func (c *FeeCap) Spent(now time.Time) *felt.Felt {
+	return new(felt.Felt).SetBigInt(c.spentSince(now.Add(-FeeBudgetWindow)))
+}
+
+// Checks a transaction paying up to `maxFee` can be sent at `now`. If it replaces another
+// transaction, `replacedFee` is the max fee of the replaced one, which is given back
+// to the budget since only one of them can be included. Otherwise it's nil
+func (c *FeeCap) Check(now time.Time, maxFee, replacedFee *felt.Felt) error {
+	fee := maxFee.BigInt(new(big.Int))
+	if c.maxPerAttest != nil && fee.Cmp(c.maxPerAttest) > 0 {
+		return fmt.Errorf(
+			"%w: max fee of %s fri is above the %s fri allowed per attestation",
+			ErrFeeCapExceeded, fee, c.maxPerAttest,
+		)
+	}
+
+	if c.dailyBudget != nil {
+		left := c.budgetLeft(now, replacedFee)
+		if fee.Cmp(left) > 0 {
+			return fmt.Errorf(
+				"%w: max fee of %s fri is above the %s fri left in the daily budget",
+				ErrFeeCapExceeded, fee, left,
+			)
+		}
+	}
+	return nil
+}
+
+// Returns the max fee a transaction sent at `now` can pay, nil if there is no limit.
+// `replacedFee` works just like in `Check`
+func (c *FeeCap) Allowance(now time.Time, replacedFee *felt.Felt) *felt.Felt {
+	var allowance *big.Int
+	if c.maxPerAttest != nil {
+		allowance = c.maxPerAttest
+	}
+	if c.dailyBudget != nil {
+		left := c.budgetLeft(now, replacedFee)
+		if allowance == nil || left.Cmp(allowance) < 0 {
+			allowance = left
+		}
+	}
+
+	if allowance == nil {
+		return nil
+	}
+	return new(felt.Felt).SetBigInt(allowance)
+}
+
+// Records a transaction paying up to `maxFee` was sent at `now`.
+// `replacedFee` works just like in `Check`
+func (c *FeeCap) Spend(now time.Time, maxFee, replacedFee *felt.Felt) {
+	fee := maxFee.BigInt(new(big.Int))
+	if replacedFee != nil {
+		fee.Sub(fee, replacedFee.BigInt(new(big.Int)))
+	}
+	if fee.Sign() <= 0 {
+		return
+	}
+
+	// Fees out of the window won't be needed anymore
+	windowStart := now.Add(-FeeBudgetWindow)
+	firstInWindow := 0
+	for firstInWindow < len(c.spent) && !c.spent[firstInWindow].at.After(windowStart) {
+		firstInWindow++
+	}
+	c.spent = append(c.spent[firstInWindow:], spentFee{at: now, fee: fee})
+}
+
+// Returns the fees spent in the fee budget window ending at `now`, oldest first
+func (c *FeeCap) SpentFees(now time.Time) []SpentFee {
+	windowStart := now.Add(-FeeBudgetWindow)
+	var spent []SpentFee
+	for i := range c.spent {
+		if c.spent[i].at.After(windowStart) {
+			spent = append(spent, SpentFee{
+				At: c.spent[i].at, Fee: new(felt.Felt).SetBigInt(c.spent[i].fee),
+			})
+		}
+	}
+	return spent
+}
+
+// Replaces the fees spent by the ones returned by `SpentFees`, usually in a previous run
+func (c *FeeCap) RestoreSpent(spent []SpentFee) {
+	c.spent = make([]spentFee, 0, len(spent))
+	for i := range spent {
+		if spent[i].Fee == nil {
+			continue
+		}
+		c.spent = append(c.spent, spentFee{at: spent[i].At, fee: spent[i].Fee.BigInt(new(big.Int))})
+	}
+	sort.Slice(c.spent, func(i, j int) bool { return c.spent[i].at.Before(c.spent[j].at) })
+}
+
+func (c *FeeCap) budgetLeft(now time.Time, replacedFee *felt.Felt) *big.Int {
+	left := new(big.Int).Sub(c.dailyBudget, c.spentSince(now.Add(-FeeBudgetWindow)))
+	if replacedFee != nil {
+		left.Add(left, replacedFee.BigInt(new(big.Int)))
+	}
+	if left.Sign() < 0 {
+		left.SetUint64(0)
+	}
+	return left
+}
+
+func (c *FeeCap) spentSince(windowStart time.Time) *big.Int {
+	spent := new(big.Int)
+	for i := range c.spent {
+		if c.spent[i].at.After(windowStart) {
+			spent.Add(spent, c.spent[i].fee)
+		}
+	}
+	return spent
+}
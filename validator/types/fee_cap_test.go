@@ -0,0 +1,199 @@
+package types_test
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/NethermindEth/juno/core/felt"
+	"github.com/NethermindEth/starknet-staking-v2/validator/types"
+	"github.com/NethermindEth/starknet.go/rpc"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFeeAmountFromString(t *testing.T) {
+	invalidStr := []string{
+		"",
+		"-1",
+		"ten",
+		"1.5",
+		"-1strk",
+		"0.0000000000000000001strk",
+	}
+	for _, s := range invalidStr {
+		amount, err := types.FeeAmountFromString(s)
+		require.Nil(t, amount, s)
+		require.ErrorContains(t, err, s)
+	}
+
+	correctStr := map[string]string{
+		"0":         "0",
+		"1000":      "1000",
+		"0x10":      "16",
+		"1strk":     "1000000000000000000",
+		"0.5 STRK":  "500000000000000000",
+		"1e-18strk": "1",
+	}
+	for s, expected := range correctStr {
+		amount, err := types.FeeAmountFromString(s)
+		require.NoError(t, err, s)
+		require.Equal(t, expected, amount.String(), s)
+	}
+}
+
+func TestFeeCapFromStrings(t *testing.T) {
+	feeCap, err := types.FeeCapFromStrings("", "")
+	require.NoError(t, err)
+	require.True(t, feeCap.IsZero())
+
+	feeCap, err = types.FeeCapFromStrings("ten", "")
+	require.Zero(t, feeCap)
+	require.ErrorContains(t, err, "invalid max fee per attestation")
+
+	feeCap, err = types.FeeCapFromStrings("", "ten")
+	require.Zero(t, feeCap)
+	require.ErrorContains(t, err, "invalid daily fee budget")
+}
+
+func TestFeeCap(t *testing.T) {
+	now := time.Now()
+	fee := func(value uint64) *felt.Felt {
+		return new(felt.Felt).SetUint64(value)
+	}
+
+	t.Run("Zero value limits nothing", func(t *testing.T) {
+		var feeCap types.FeeCap
+
+		require.NoError(t, feeCap.Check(now, fee(1_000_000), nil))
+		require.Nil(t, feeCap.Allowance(now, nil))
+
+		feeCap.Spend(now, fee(10), nil)
+		require.Equal(t, fee(10), feeCap.Spent(now))
+	})
+
+	t.Run("Fee per attestation is limited", func(t *testing.T) {
+		feeCap, err := types.FeeCapFromStrings("100", "")
+		require.NoError(t, err)
+
+		require.NoError(t, feeCap.Check(now, fee(100), nil))
+		err = feeCap.Check(now, fee(101), nil)
+		require.ErrorIs(t, err, types.ErrFeeCapExceeded)
+		require.ErrorContains(t, err, "max fee of 101 fri is above the 100 fri allowed per attestation")
+		require.Equal(t, fee(100), feeCap.Allowance(now, nil))
+	})
+
+	t.Run("Daily budget is spent over a rolling window", func(t *testing.T) {
+		feeCap, err := types.FeeCapFromStrings("", "100")
+		require.NoError(t, err)
+
+		feeCap.Spend(now, fee(60), nil)
+		later := now.Add(time.Hour)
+		feeCap.Spend(later, fee(30), nil)
+
+		require.Equal(t, fee(90), feeCap.Spent(later))
+		require.Equal(t, fee(10), feeCap.Allowance(later, nil))
+		require.NoError(t, feeCap.Check(later, fee(10), nil))
+		err = feeCap.Check(later, fee(11), nil)
+		require.ErrorIs(t, err, types.ErrFeeCapExceeded)
+		require.ErrorContains(t, err, "max fee of 11 fri is above the 10 fri left in the daily budget")
+
+		// The first fee is out of the window a day after it was spent
+		nextDay := now.Add(types.FeeBudgetWindow)
+		require.Equal(t, fee(30), feeCap.Spent(nextDay))
+		require.Equal(t, fee(70), feeCap.Allowance(nextDay, nil))
+	})
+
+	t.Run("Replaced fee is given back to the budget", func(t *testing.T) {
+		feeCap, err := types.FeeCapFromStrings("", "100")
+		require.NoError(t, err)
+
+		feeCap.Spend(now, fee(60), nil)
+		require.Equal(t, fee(40), feeCap.Allowance(now, nil))
+		require.Equal(t, fee(100), feeCap.Allowance(now, fee(60)))
+		require.NoError(t, feeCap.Check(now, fee(72), fee(60)))
+
+		// Only the difference with the replaced transaction is spent
+		feeCap.Spend(now, fee(72), fee(60))
+		require.Equal(t, fee(72), feeCap.Spent(now))
+	})
+
+	t.Run("Allowance is the lowest of both limits", func(t *testing.T) {
+		feeCap, err := types.FeeCapFromStrings("50", "100")
+		require.NoError(t, err)
+
+		require.Equal(t, fee(50), feeCap.Allowance(now, nil))
+		feeCap.Spend(now, fee(80), nil)
+		require.Equal(t, fee(20), feeCap.Allowance(now, nil))
+		feeCap.Spend(now, fee(50), nil)
+		require.Equal(t, fee(0), feeCap.Allowance(now, nil))
+	})
+
+	t.Run("Spent fees are restored in another fee cap", func(t *testing.T) {
+		feeCap, err := types.FeeCapFromStrings("", "100")
+		require.NoError(t, err)
+
+		feeCap.Spend(now.Add(-types.FeeBudgetWindow), fee(20), nil)
+		feeCap.Spend(now.Add(-time.Hour), fee(30), nil)
+		feeCap.Spend(now, fee(40), nil)
+
+		// Only the fees in the window are kept
+		spent := feeCap.SpentFees(now)
+		require.Equal(t, []types.SpentFee{
+			{At: now.Add(-time.Hour), Fee: fee(30)},
+			{At: now, Fee: fee(40)},
+		}, spent)
+
+		restored, err := types.FeeCapFromStrings("", "100")
+		require.NoError(t, err)
+		restored.RestoreSpent([]types.SpentFee{spent[1], spent[0]})
+		require.Equal(t, fee(70), restored.Spent(now))
+		require.Equal(t, fee(30), restored.Allowance(now, nil))
+		require.Equal(t, spent, restored.SpentFees(now))
+	})
+
+	t.Run("Tip counts against both limits", func(t *testing.T) {
+		boundsWithL2 := func(amount string) *rpc.ResourceBoundsMapping {
+			return &rpc.ResourceBoundsMapping{
+				L1Gas:     rpc.ResourceBounds{MaxAmount: "0x0", MaxPricePerUnit: "0x1"},
+				L1DataGas: rpc.ResourceBounds{MaxAmount: "0x0", MaxPricePerUnit: "0x1"},
+				L2Gas:     rpc.ResourceBounds{MaxAmount: rpc.U64(amount), MaxPricePerUnit: "0x1"},
+			}
+		}
+		feeCap, err := types.FeeCapFromStrings("32", "48")
+		require.NoError(t, err)
+
+		// 0x10 * (0x1 + 0x1) is right at the cap per attestation, one more tip unit is above it
+		maxFee, err := types.MaxFee(boundsWithL2("0x10"), "0x1")
+		require.NoError(t, err)
+		require.Equal(t, fee(32), maxFee)
+		require.NoError(t, feeCap.Check(now, maxFee, nil))
+		aboveCap, err := types.MaxFee(boundsWithL2("0x10"), "0x2")
+		require.NoError(t, err)
+		err = feeCap.Check(now, aboveCap, nil)
+		require.ErrorIs(t, err, types.ErrFeeCapExceeded)
+		require.ErrorContains(t, err, "max fee of 48 fri is above the 32 fri allowed per attestation")
+
+		// 0x8 * (0x1 + 0x1) spends the 16 fri left in the budget, one more tip unit is above it
+		feeCap.Spend(now, maxFee, nil)
+		maxFee, err = types.MaxFee(boundsWithL2("0x8"), "0x1")
+		require.NoError(t, err)
+		require.NoError(t, feeCap.Check(now, maxFee, nil))
+		aboveBudget, err := types.MaxFee(boundsWithL2("0x8"), "0x2")
+		require.NoError(t, err)
+		err = feeCap.Check(now, aboveBudget, nil)
+		require.ErrorIs(t, err, types.ErrFeeCapExceeded)
+		require.ErrorContains(t, err, "max fee of 24 fri is above the 16 fri left in the daily budget")
+	})
+
+	t.Run("Amounts above 64 bits are supported", func(t *testing.T) {
+		feeCap, err := types.FeeCapFromStrings("", "1000strk")
+		require.NoError(t, err)
+
+		oneStrk := new(felt.Felt).SetBigInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(18), nil))
+		feeCap.Spend(now, oneStrk, nil)
+
+		expected, ok := new(big.Int).SetString("999000000000000000000", 10)
+		require.True(t, ok)
+		require.Equal(t, new(felt.Felt).SetBigInt(expected), feeCap.Allowance(now, nil))
+	})
+}
@@ -3,14 +3,22 @@ package types
 import (
 	"encoding/json"
 	"fmt"
-	"strconv"
+	"math/big"
+	"slices"
+	"strings"
 
+	"github.com/NethermindEth/juno/core/felt"
 	"github.com/NethermindEth/starknet-staking-v2/validator/config"
+	"github.com/NethermindEth/starknet-staking-v2/validator/constants"
+	"github.com/NethermindEth/starknet.go/rpc"
 	"lukechampine.com/uint128"
 )
 
 type AttestRequired struct {
-	BlockHash BlockHash
+	BlockHash     BlockHash
+	EpochId       uint64
+	TargetBlock   BlockNumber
+	StakerAddress Address
 }
 
 type AttestInfo struct {
@@ -40,60 +48,182 @@ func (e *EpochInfo) String() string {
 type recalculate int
 
 const (
-	once recalculate = iota
-	always
+	always recalculate = iota
+	once
 	never
 )
 
+const (
+	AttestFeeAlways = "always"
+	AttestFeeOnce   = "once"
+)
+
+// Decides the resource bounds of the attest transactions. They are either estimated
+// before each attest, estimated for the first attest and reused, or fixed.
+// The zero value estimates them before each attest with the default multiplier
 type AttestFee struct {
-	recalculate recalculate
-	value       uint64
+	recalculate    recalculate
+	multiplier     float64
+	resourceBounds *rpc.ResourceBoundsMapping
 }
 
-func AttestFeeFromString(attestOption string) (AttestFee, error) {
-	if attestOption == "always" {
-		return AttestFee{
-			recalculate: always,
-			value:       0,
-		}, nil
+// Parses the attest fee option, which is either "always", "once" or fixed resource bounds
+// written as "l1_gas=<max amount>:<max price per unit>,l1_data_gas=...,l2_gas=...".
+// Empty values fall back to "always" and the default fee estimation multiplier
+func AttestFeeFromString(attestOption string, multiplier float64) (AttestFee, error) {
+	if multiplier != 0 && multiplier < 1 {
+		return AttestFee{},
+			fmt.Errorf("fee estimation multiplier must be at least 1, got %v", multiplier)
 	}
-	if attestOption == "once" {
-		return AttestFee{
-			recalculate: once,
-			value:       0,
-		}, nil
+
+	switch attestOption {
+	case "", AttestFeeAlways:
+		return AttestFee{recalculate: always, multiplier: multiplier}, nil
+	case AttestFeeOnce:
+		return AttestFee{recalculate: once, multiplier: multiplier}, nil
 	}
-	val, err := strconv.ParseUint(attestOption, 10, 64)
+
+	resourceBounds, err := ResourceBoundsFromString(attestOption)
 	if err != nil {
 		return AttestFee{},
 			fmt.Errorf(
-				"cannot parse attest options :"+
-					" `%s` is not a valid number nor a valid option",
+				"cannot parse attest fee: `%s` is not a valid option nor valid resource bounds: %w",
 				attestOption,
+				err,
 			)
 	}
 	return AttestFee{
-		recalculate: never,
-		value:       val,
+		recalculate:    never,
+		multiplier:     multiplier,
+		resourceBounds: &resourceBounds,
 	}, nil
 }
 
-func (a *AttestFee) Get() uint64 {
-	switch a.recalculate {
-	case always:
-		// do the recalculation
-		return 1000
-	case once:
-		// do the recalculation
+// Multiplier applied to the fee estimation
+func (a *AttestFee) Multiplier() float64 {
+	if a.multiplier == 0 {
+		return constants.DEFAULT_FEE_ESTIMATION_MULTIPLIER
+	}
+	return a.multiplier
+}
+
+// Returns the resource bounds the attest has to be sent with,
+// or nil if they have to be estimated
+func (a *AttestFee) ResourceBounds() *rpc.ResourceBoundsMapping {
+	if a.recalculate == never {
+		return a.resourceBounds
+	}
+	return nil
+}
+
+// Keeps the estimated resource bounds if they are to be estimated only once
+func (a *AttestFee) SetEstimated(resourceBounds *rpc.ResourceBoundsMapping) {
+	if a.recalculate == once {
+		a.resourceBounds = resourceBounds
 		a.recalculate = never
-		return a.value
-	case never:
-		return a.value
-	default:
-		panic("Unknown recalculate option for AttestFee")
 	}
 }
 
+// Parses resource bounds written as
+// "l1_gas=<max amount>:<max price per unit>,l1_data_gas=...,l2_gas=...".
+// Values can be either decimal or hexadecimal, all three resources are required
+func ResourceBoundsFromString(s string) (rpc.ResourceBoundsMapping, error) {
+	var resourceBounds rpc.ResourceBoundsMapping
+	resources := map[string]*rpc.ResourceBounds{
+		"l1_gas":      &resourceBounds.L1Gas,
+		"l1_data_gas": &resourceBounds.L1DataGas,
+		"l2_gas":      &resourceBounds.L2Gas,
+	}
+
+	for _, resourceStr := range strings.Split(s, ",") {
+		name, value, found := strings.Cut(strings.TrimSpace(resourceStr), "=")
+		if !found {
+			return rpc.ResourceBoundsMapping{},
+				fmt.Errorf("expected <resource>=<max amount>:<max price per unit>, got `%s`", resourceStr)
+		}
+
+		bounds, ok := resources[name]
+		if !ok {
+			return rpc.ResourceBoundsMapping{},
+				fmt.Errorf("unknown or repeated resource `%s`", name)
+		}
+		delete(resources, name)
+
+		amountStr, priceStr, found := strings.Cut(value, ":")
+		if !found {
+			return rpc.ResourceBoundsMapping{},
+				fmt.Errorf("expected <max amount>:<max price per unit> for %s, got `%s`", name, value)
+		}
+		amount, err := parseBoundValue(amountStr, 64)
+		if err != nil {
+			return rpc.ResourceBoundsMapping{}, fmt.Errorf("invalid %s max amount: %w", name, err)
+		}
+		price, err := parseBoundValue(priceStr, 128)
+		if err != nil {
+			return rpc.ResourceBoundsMapping{},
+				fmt.Errorf("invalid %s max price per unit: %w", name, err)
+		}
+
+		bounds.MaxAmount = rpc.U64(amount)
+		bounds.MaxPricePerUnit = rpc.U128(price)
+	}
+
+	if len(resources) != 0 {
+		missing := make([]string, 0, len(resources))
+		for name := range resources {
+			missing = append(missing, name)
+		}
+		slices.Sort(missing)
+		return rpc.ResourceBoundsMapping{},
+			fmt.Errorf("missing resources: %s", strings.Join(missing, ", "))
+	}
+
+	return resourceBounds, nil
+}
+
+// Parses a decimal or hexadecimal value and returns it as hexadecimal
+func parseBoundValue(s string, maxBits int) (string, error) {
+	value, ok := new(big.Int).SetString(strings.TrimSpace(s), 0)
+	if !ok || value.Sign() < 0 {
+		return "", fmt.Errorf("`%s` is not a valid positive number", s)
+	}
+	if value.BitLen() > maxBits {
+		return "", fmt.Errorf("`%s` does not fit in %d bits", s, maxBits)
+	}
+	return "0x" + value.Text(16), nil
+}
+
+// Tip the attest transactions are first sent with. Only their replacements raise it
+const AttestTip rpc.U64 = "0x0"
+
+// Returns the maximum fee a transaction with the given resource bounds and tip can pay.
+// The tip is paid for each unit of l2 gas, on top of its price
+func MaxFee(resourceBounds *rpc.ResourceBoundsMapping, tip rpc.U64) (*felt.Felt, error) {
+	tipValue, err := tip.ToUint64()
+	if err != nil {
+		return nil, fmt.Errorf("invalid tip `%s`", tip)
+	}
+
+	maxFee := new(big.Int)
+	for _, bounds := range []*rpc.ResourceBounds{
+		&resourceBounds.L1Gas, &resourceBounds.L1DataGas, &resourceBounds.L2Gas,
+	} {
+		amount, ok := new(big.Int).SetString(string(bounds.MaxAmount), 0)
+		if !ok {
+			return nil, fmt.Errorf("invalid max amount `%s`", bounds.MaxAmount)
+		}
+		price, ok := new(big.Int).SetString(string(bounds.MaxPricePerUnit), 0)
+		if !ok {
+			return nil, fmt.Errorf("invalid max price per unit `%s`", bounds.MaxPricePerUnit)
+		}
+		if bounds == &resourceBounds.L2Gas {
+			price.Add(price, new(big.Int).SetUint64(tipValue))
+		}
+		maxFee.Add(maxFee, amount.Mul(amount, price))
+	}
+	return new(felt.Felt).SetBigInt(maxFee), nil
+}
+
 type ValidationContracts struct {
 	Staking Address
 	Attest  Address
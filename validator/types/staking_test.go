@@ -0,0 +1,124 @@
+package types_test
+
+import (
+	"testing"
+
+	"github.com/NethermindEth/juno/core/felt"
+	"github.com/NethermindEth/starknet-staking-v2/validator/constants"
+	"github.com/NethermindEth/starknet-staking-v2/validator/types"
+	"github.com/NethermindEth/starknet.go/rpc"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAttestFeeFromString(t *testing.T) {
+	t.Run("Fee is estimated for every attest by default", func(t *testing.T) {
+		for _, option := range []string{"", types.AttestFeeAlways} {
+			attestFee, err := types.AttestFeeFromString(option, 0)
+			require.NoError(t, err)
+			require.Nil(t, attestFee.ResourceBounds())
+			require.Equal(t, constants.DEFAULT_FEE_ESTIMATION_MULTIPLIER, attestFee.Multiplier())
+
+			attestFee.SetEstimated(&rpc.ResourceBoundsMapping{})
+			require.Nil(t, attestFee.ResourceBounds())
+		}
+	})
+
+	t.Run("Fee is estimated only once", func(t *testing.T) {
+		attestFee, err := types.AttestFeeFromString(types.AttestFeeOnce, 2)
+		require.NoError(t, err)
+		require.Nil(t, attestFee.ResourceBounds())
+		require.Equal(t, 2.0, attestFee.Multiplier())
+
+		estimated := rpc.ResourceBoundsMapping{
+			L1Gas: rpc.ResourceBounds{MaxAmount: "0x1", MaxPricePerUnit: "0x2"},
+		}
+		attestFee.SetEstimated(&estimated)
+		require.Equal(t, &estimated, attestFee.ResourceBounds())
+	})
+
+	t.Run("Fee is given as resource bounds", func(t *testing.T) {
+		attestFee, err := types.AttestFeeFromString(
+			"l1_gas=0:0x10, l1_data_gas=128:0x20, l2_gas=0x100:48", 0,
+		)
+		require.NoError(t, err)
+
+		expected := rpc.ResourceBoundsMapping{
+			L1Gas:     rpc.ResourceBounds{MaxAmount: "0x0", MaxPricePerUnit: "0x10"},
+			L1DataGas: rpc.ResourceBounds{MaxAmount: "0x80", MaxPricePerUnit: "0x20"},
+			L2Gas:     rpc.ResourceBounds{MaxAmount: "0x100", MaxPricePerUnit: "0x30"},
+		}
+		require.Equal(t, &expected, attestFee.ResourceBounds())
+	})
+
+	t.Run("Error: multiplier lower than one", func(t *testing.T) {
+		attestFee, err := types.AttestFeeFromString(types.AttestFeeAlways, 0.5)
+		require.Zero(t, attestFee)
+		require.EqualError(t, err, "fee estimation multiplier must be at least 1, got 0.5")
+	})
+
+	t.Run("Error: neither an option nor resource bounds", func(t *testing.T) {
+		attestFee, err := types.AttestFeeFromString("sometimes", 0)
+		require.Zero(t, attestFee)
+		require.ErrorContains(t, err, "`sometimes` is not a valid option nor valid resource bounds")
+	})
+}
+
+func TestResourceBoundsFromString(t *testing.T) {
+	invalid := map[string]string{
+		"l1_gas=1:1,l1_data_gas=1:1":       "missing resources: l2_gas",
+		"l1_gas=1:1":                       "missing resources: l1_data_gas, l2_gas",
+		"l1_gas=1:1,l1_gas=1:1,l2_gas=1:1": "unknown or repeated resource `l1_gas`",
+		"l3_gas=1:1":                       "unknown or repeated resource `l3_gas`",
+		"l1_gas":                           "expected <resource>=<max amount>:<max price per unit>",
+		"l1_gas=1":                         "expected <max amount>:<max price per unit> for l1_gas",
+		"l1_gas=-1:1":                      "invalid l1_gas max amount",
+		"l1_gas=0x10000000000000000:1":     "does not fit in 64 bits",
+		"l1_gas=1:0x100000000000000000000000000000000":     "does not fit in 128 bits",
+		"l1_gas=1:1,l1_data_gas=1:price,l2_gas=1:1":        "invalid l1_data_gas max price per unit",
+		"l1_gas=1:1,l1_data_gas=1:1,l2_gas=1:1,l2_gas=1:1": "unknown or repeated resource `l2_gas`",
+	}
+	for s, expectedErr := range invalid {
+		resourceBounds, err := types.ResourceBoundsFromString(s)
+		require.Zero(t, resourceBounds, s)
+		require.ErrorContains(t, err, expectedErr, s)
+	}
+
+	resourceBounds, err := types.ResourceBoundsFromString(
+		"l2_gas=0xffffffffffffffff:1,l1_gas=2:3,l1_data_gas=4:0x5",
+	)
+	require.NoError(t, err)
+	require.Equal(t, rpc.ResourceBoundsMapping{
+		L1Gas:     rpc.ResourceBounds{MaxAmount: "0x2", MaxPricePerUnit: "0x3"},
+		L1DataGas: rpc.ResourceBounds{MaxAmount: "0x4", MaxPricePerUnit: "0x5"},
+		L2Gas:     rpc.ResourceBounds{MaxAmount: "0xffffffffffffffff", MaxPricePerUnit: "0x1"},
+	}, resourceBounds)
+}
+
+func TestMaxFee(t *testing.T) {
+	maxFee, err := types.MaxFee(&rpc.ResourceBoundsMapping{
+		L1Gas:     rpc.ResourceBounds{MaxAmount: "0x2", MaxPricePerUnit: "0x3"},
+		L1DataGas: rpc.ResourceBounds{MaxAmount: "0x4", MaxPricePerUnit: "0x5"},
+		L2Gas:     rpc.ResourceBounds{MaxAmount: "0x6", MaxPricePerUnit: "0x7"},
+	}, types.AttestTip)
+	require.NoError(t, err)
+	require.Equal(t, new(felt.Felt).SetUint64(2*3+4*5+6*7), maxFee)
+
+	// The tip is paid for each unit of l2 gas
+	maxFee, err = types.MaxFee(&rpc.ResourceBoundsMapping{
+		L1Gas:     rpc.ResourceBounds{MaxAmount: "0x2", MaxPricePerUnit: "0x3"},
+		L1DataGas: rpc.ResourceBounds{MaxAmount: "0x4", MaxPricePerUnit: "0x5"},
+		L2Gas:     rpc.ResourceBounds{MaxAmount: "0x6", MaxPricePerUnit: "0x7"},
+	}, "0x2")
+	require.NoError(t, err)
+	require.Equal(t, new(felt.Felt).SetUint64(2*3+4*5+6*(7+2)), maxFee)
+
+	maxFee, err = types.MaxFee(&rpc.ResourceBoundsMapping{
+		L1Gas: rpc.ResourceBounds{MaxAmount: "0x2", MaxPricePerUnit: "three"},
+	}, types.AttestTip)
+	require.Nil(t, maxFee)
+	require.EqualError(t, err, "invalid max price per unit `three`")
+
+	maxFee, err = types.MaxFee(&rpc.ResourceBoundsMapping{}, "lots")
+	require.Nil(t, maxFee)
+	require.EqualError(t, err, "invalid tip `lots`")
+}